@@ -0,0 +1,511 @@
+// Package audit implements a local, append-only and tamper-evident log of
+// the mutating API calls performed by the tool: each entry carries the hash
+// of the previous one, so that any entry that is modified, removed or
+// inserted afterwards breaks the chain and is detected on verification.
+// Hashes are keyed with a per-installation secret kept outside the log, so
+// that the chain cannot be rebuilt and hashed recipients cannot be brute
+// forced without it; the head of the chain is signed in a separate file, so
+// that entries removed from the end of the log are detected too.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of an audited API call.
+type Status string
+
+// List of audited API call outcomes.
+const (
+	// OK calls were accepted by the platform.
+	OK Status = "ok"
+	// Failed calls were rejected by the platform, or got no response.
+	Failed Status = "failed"
+)
+
+// Genesis is the previous hash of the first entry of a log.
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// ErrTampered is returned when the hash chain of a log is broken.
+var ErrTampered = errors.New("audit log tampered with")
+
+// hashPrefix is the prefix of hashed recipients.
+const hashPrefix = "hmac-sha256:"
+
+// keySize is the size of the key generated by LoadKey, in bytes.
+const keySize = 32
+
+// LoadKey reads the hex encoded audit log key from the given file; if the
+// file does not exist, a random key is generated and stored in it. The key
+// must be kept outside the log, and preserved as long as the log is: it is
+// needed to verify the log and to look up hashed recipients.
+func LoadKey(path string) ([]byte, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		slog.Error("error creating audit key directory", "path", path, "error", err)
+		return nil, err
+	}
+	key := make([]byte, keySize)
+	rand.Read(key)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err == nil {
+		_, err = file.WriteString(hex.EncodeToString(key) + "\n")
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			slog.Error("error writing audit key", "path", path, "error", err)
+			os.Remove(path)
+			return nil, err
+		}
+		slog.Info("audit key generated", "path", path)
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		slog.Error("error creating audit key", "path", path, "error", err)
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("error reading audit key", "path", path, "error", err)
+		return nil, err
+	}
+	key, err = hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) < keySize/2 {
+		slog.Error("invalid audit key", "path", path, "error", err)
+		return nil, fmt.Errorf("invalid audit key in %s", path)
+	}
+	return key, nil
+}
+
+// Entry is a record of a mutating API call.
+type Entry struct {
+	// Seq is the sequence number of the entry, starting at 1.
+	Seq  int64     `json:"seq" yaml:"seq"`
+	Time time.Time `json:"time" yaml:"time"`
+	// User is the operating system user that performed the call.
+	User string `json:"user" yaml:"user"`
+	// Profile is the configuration profile (.env file) in use, if any.
+	Profile  string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	Account  string `json:"account,omitempty" yaml:"account,omitempty"`
+	// Operation is the name of the operation (e.g. "sms.send").
+	Operation string `json:"operation" yaml:"operation"`
+	Method    string `json:"method" yaml:"method"`
+	// Path is the entity path of the call, before path parameters are
+	// substituted.
+	Path string `json:"path" yaml:"path"`
+	// Subject identifies the entity acted upon, if not a message (e.g. a
+	// sender, or the fingerprint of a token).
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	// Reference is the client reference of the message, if any.
+	Reference string `json:"reference,omitempty" yaml:"reference,omitempty"`
+	// Recipients holds the recipients of the message, or their hashes if
+	// the log hashes recipients.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty" pii:"phone"`
+	Status     Status   `json:"status" yaml:"status"`
+	StatusCode int      `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	Error      string   `json:"error,omitempty" yaml:"error,omitempty"`
+	// Prev is the hash of the previous entry.
+	Prev string `json:"prev" yaml:"prev"`
+	// Hash is the hash of this entry, computed over all the other fields.
+	Hash string `json:"hash" yaml:"hash"`
+}
+
+// head is the signed record of the last entry of a log, stored next to it.
+type head struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+	// Signature is the keyed hash of the sequence number and hash.
+	Signature string `json:"signature"`
+}
+
+// Log is an audit log, stored as a file with one JSON entry per line.
+type Log struct {
+	// HashRecipients sets whether recipients are recorded as hashes rather
+	// than in clear.
+	HashRecipients bool
+
+	path string
+	key  []byte
+}
+
+// Open opens the audit log at the given path, keyed with the given key; the
+// file is created when the first entry is appended.
+func Open(path string, key []byte) (*Log, error) {
+	if len(key) == 0 {
+		slog.Error("no audit log key", "path", path)
+		return nil, errors.New("no audit log key")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		slog.Error("error creating audit log directory", "path", path, "error", err)
+		return nil, err
+	}
+	return &Log{path: filepath.Clean(path), key: key}, nil
+}
+
+// mac returns the hex encoded keyed hash of the given data.
+func (l *Log) mac(data ...[]byte) string {
+	hash := hmac.New(sha256.New, l.key)
+	for _, chunk := range data {
+		hash.Write(chunk)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// digest computes the keyed hash of the entry.
+func (l *Log) digest(entry *Entry) (string, error) {
+	unhashed := *entry
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	return l.mac(data), nil
+}
+
+// sign computes the signature of the head of the log.
+func (l *Log) sign(h *head) string {
+	return l.mac([]byte("head:"), strconv.AppendInt(nil, h.Seq, 10), []byte(":"+h.Hash))
+}
+
+// HashNumber returns the keyed hash under which a recipient is recorded
+// when the log hashes recipients.
+func (l *Log) HashNumber(number string) string {
+	return hashPrefix + l.mac([]byte(number))
+}
+
+// Path returns the path of the audit log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append chains the entry to the last one in the log and writes it; the
+// sequence number, the hashes and (if zero) the time are set by the log.
+func (l *Log) Append(entry *Entry) error {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	last, err := l.last()
+	if err != nil {
+		return err
+	}
+	entry.Seq, entry.Prev = 1, Genesis
+	if last != nil {
+		entry.Seq, entry.Prev = last.Seq+1, last.Hash
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if l.HashRecipients {
+		for i, recipient := range entry.Recipients {
+			if !strings.HasPrefix(recipient, hashPrefix) {
+				entry.Recipients[i] = l.HashNumber(recipient)
+			}
+		}
+	}
+	if entry.Hash, err = l.digest(entry); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Error("error opening audit log", "path", l.path, "error", err)
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		slog.Error("error writing audit log", "path", l.path, "error", err)
+		return err
+	}
+	if err := l.writeHead(entry); err != nil {
+		return err
+	}
+	slog.Debug("audit entry appended", "seq", entry.Seq, "operation", entry.Operation)
+	return nil
+}
+
+// writeHead records the entry as the signed head of the log.
+func (l *Log) writeHead(entry *Entry) error {
+	h := &head{Seq: entry.Seq, Hash: entry.Hash}
+	h.Signature = l.sign(h)
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	path := l.path + ".head"
+	if err = os.WriteFile(path+".tmp", append(data, '\n'), 0600); err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		slog.Error("error writing audit log head", "path", path, "error", err)
+		return err
+	}
+	return nil
+}
+
+// readHead returns the signed head of the log, or nil if there is none.
+func (l *Log) readHead() (*head, error) {
+	path := l.path + ".head"
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error reading audit log head", "path", path, "error", err)
+		return nil, err
+	}
+	h := &head{}
+	if err := json.Unmarshal(data, h); err != nil {
+		slog.Error("error parsing audit log head", "path", path, "error", err)
+		return nil, fmt.Errorf("%w: the head is not valid", ErrTampered)
+	}
+	if !hmac.Equal([]byte(h.Signature), []byte(l.sign(h))) {
+		return nil, fmt.Errorf("%w: the head does not match its signature", ErrTampered)
+	}
+	return h, nil
+}
+
+// Entries returns all the entries in the log, oldest first; an empty list is
+// returned if the log does not exist yet.
+func (l *Log) Entries() ([]*Entry, error) {
+	entries := []*Entry{}
+	err := l.scan(func(entry *Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// Select returns the entries in the log that match the filter, oldest
+// first.
+func (l *Log) Select(filter *Filter) ([]*Entry, error) {
+	hashed := ""
+	if filter != nil && filter.Recipient != "" {
+		hashed = l.HashNumber(filter.Recipient)
+	}
+	entries := []*Entry{}
+	err := l.scan(func(entry *Entry) error {
+		if filter.matches(entry, hashed) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Verify checks the hash chain of the whole log against the signed head and
+// returns the number of entries verified; if an entry was modified, removed
+// or inserted, an error wrapping ErrTampered and reporting the first broken
+// entry is returned.
+func (l *Log) Verify() (int, error) {
+	count := 0
+	h, err := l.readHead()
+	prev, seq := Genesis, int64(0)
+	if err == nil {
+		err = l.scan(func(entry *Entry) error {
+			hash, err := l.digest(entry)
+			if err != nil {
+				return err
+			}
+			switch {
+			case entry.Seq != seq+1:
+				return fmt.Errorf("%w: entry %d follows entry %d", ErrTampered, entry.Seq, seq)
+			case entry.Prev != prev:
+				return fmt.Errorf("%w: entry %d does not chain to the previous entry", ErrTampered, entry.Seq)
+			case !hmac.Equal([]byte(entry.Hash), []byte(hash)):
+				return fmt.Errorf("%w: entry %d does not match its hash", ErrTampered, entry.Seq)
+			case h != nil && entry.Seq == h.Seq && entry.Hash != h.Hash:
+				return fmt.Errorf("%w: entry %d does not match the head", ErrTampered, entry.Seq)
+			}
+			prev, seq = entry.Hash, entry.Seq
+			count++
+			return nil
+		})
+	}
+	switch {
+	case err != nil:
+	case h == nil && seq > 0:
+		err = fmt.Errorf("%w: the head is missing", ErrTampered)
+	case h != nil && h.Seq > seq:
+		// entries appended after the head was written (e.g. by a process
+		// that crashed in between) are still chained, and thus accepted
+		err = fmt.Errorf("%w: entries after entry %d removed", ErrTampered, seq)
+	}
+	if err != nil {
+		slog.Error("audit log verification failed", "path", l.path, "verified", count, "error", err)
+	}
+	return count, err
+}
+
+// scan parses the log line by line and calls the visitor on each entry.
+func (l *Log) scan(visit func(*Entry) error) error {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("error opening audit log", "path", l.path, "error", err)
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			entry := &Entry{}
+			if err := json.Unmarshal(data, entry); err != nil {
+				slog.Error("error parsing audit log entry", "path", l.path, "line", line, "error", err)
+				return fmt.Errorf("%w: line %d is not a valid entry", ErrTampered, line)
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			slog.Error("error reading audit log", "path", l.path, "error", err)
+			return err
+		}
+	}
+}
+
+// last returns the last entry in the log, or nil if the log is empty; only
+// the tail of the file is read.
+func (l *Log) last() (*Entry, error) {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error opening audit log", "path", l.path, "error", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	for chunk := int64(4096); ; chunk *= 2 {
+		offset := max(size-chunk, 0)
+		data := make([]byte, size-offset)
+		if _, err := file.ReadAt(data, offset); err != nil && err != io.EOF {
+			slog.Error("error reading audit log", "path", l.path, "error", err)
+			return nil, err
+		}
+		data = bytes.TrimRight(data, "\n")
+		index := bytes.LastIndexByte(data, '\n')
+		if index < 0 && offset > 0 {
+			// the last line is longer than the chunk
+			continue
+		}
+		data = data[index+1:]
+		if len(data) == 0 {
+			return nil, nil
+		}
+		entry := &Entry{}
+		if err := json.Unmarshal(data, entry); err != nil {
+			slog.Error("error parsing last audit log entry", "path", l.path, "error", err)
+			return nil, fmt.Errorf("%w: the last entry is not valid", ErrTampered)
+		}
+		return entry, nil
+	}
+}
+
+// lock makes the caller the only writer of the log, until the returned
+// function is called; locks older than a few seconds are assumed to be left
+// by crashed processes and taken over.
+func (l *Log) lock() (func(), error) {
+	path := l.path + ".lock"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			slog.Error("error creating audit log lock", "path", path, "error", err)
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > 10*time.Second {
+			slog.Warn("removing stale audit log lock", "path", path)
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			slog.Error("timeout waiting for audit log lock", "path", path)
+			return nil, fmt.Errorf("audit log %s is locked", l.path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Filter selects audit log entries; zero-valued fields match any entry.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+	// Recipient is a recipient in E.164 format; it matches both clear and
+	// hashed recipients.
+	Recipient string
+	Status    Status
+	// Operation matches the operation with the given name, or all the
+	// operations in the given family (e.g. "token" matches "token.create").
+	Operation string
+}
+
+// matches reports whether the entry satisfies all the filter criteria;
+// hashed is the hash of the recipient, if any.
+func (f *Filter) matches(entry *Entry, hashed string) bool {
+	if f == nil {
+		return true
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	if f.Recipient != "" && !slices.Contains(entry.Recipients, f.Recipient) && !slices.Contains(entry.Recipients, hashed) {
+		return false
+	}
+	if f.Status != "" && entry.Status != f.Status {
+		return false
+	}
+	if f.Operation != "" && entry.Operation != f.Operation && !strings.HasPrefix(entry.Operation, f.Operation+".") {
+		return false
+	}
+	return true
+}
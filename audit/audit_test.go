@@ -0,0 +1,376 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// populate creates an audit log with the given number of entries.
+func populate(t *testing.T, count int) *Log {
+	t.Helper()
+	dir := t.TempDir()
+	key, err := LoadKey(filepath.Join(dir, "audit.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := Open(filepath.Join(dir, "audit.log"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.HashRecipients = true
+	for i := range count {
+		entry := &Entry{
+			Operation:  "sms.send",
+			Method:     "POST",
+			Path:       "/api/v2/{account}/cds/sms/messages/",
+			Recipients: []string{"+39333123456" + string(rune('0'+i))},
+			Status:     OK,
+		}
+		if err := log.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return log
+}
+
+// lines returns the lines of the log file.
+func lines(t *testing.T, log *Log) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+}
+
+// rewrite replaces the contents of the log file with the given lines.
+func rewrite(t *testing.T, log *Log, lines [][]byte) {
+	t.Helper()
+	if err := os.WriteFile(log.Path(), append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// plain returns the unkeyed hash of an entry, as computed by someone that
+// does not hold the key.
+func plain(t *testing.T, entry *Entry) string {
+	t.Helper()
+	unhashed := *entry
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// modify decodes an entry, applies the change and encodes it back.
+func modify(t *testing.T, line []byte, change func(*Entry)) []byte {
+	t.Helper()
+	entry := &Entry{}
+	if err := json.Unmarshal(line, entry); err != nil {
+		t.Fatal(err)
+	}
+	change(entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAppend(t *testing.T) {
+	log := populate(t, 3)
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	prev := Genesis
+	for i, entry := range entries {
+		if entry.Seq != int64(i+1) {
+			t.Errorf("expected entry %d, got %d", i+1, entry.Seq)
+		}
+		if entry.Prev != prev {
+			t.Errorf("entry %d: expected previous hash %s, got %s", entry.Seq, prev, entry.Prev)
+		}
+		if hash, _ := log.digest(entry); entry.Hash != hash {
+			t.Errorf("entry %d: expected hash %s, got %s", entry.Seq, hash, entry.Hash)
+		}
+		if entry.Time.IsZero() {
+			t.Errorf("entry %d: no time set", entry.Seq)
+		}
+		if len(entry.Recipients) != 1 || entry.Recipients[0] != log.HashNumber("+39333123456"+string(rune('0'+i))) {
+			t.Errorf("entry %d: expected hashed recipients, got %v", entry.Seq, entry.Recipients)
+		}
+		prev = entry.Hash
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "audit.key")
+	generated, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(generated) != keySize {
+		t.Errorf("expected a %d bytes key, got %d", keySize, len(generated))
+	}
+	loaded, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, loaded) {
+		t.Errorf("expected the generated key to be loaded")
+	}
+	if err := os.WriteFile(path, []byte("not a key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKey(path); err == nil {
+		t.Errorf("expected an invalid key to be rejected")
+	}
+}
+
+func TestHashNumber(t *testing.T) {
+	log := populate(t, 0)
+	other := populate(t, 0)
+	number := "+393331234567"
+	sum := sha256.Sum256([]byte(number))
+	switch hashed := log.HashNumber(number); {
+	case !strings.HasPrefix(hashed, "hmac-sha256:"):
+		t.Errorf("expected a keyed hash, got %s", hashed)
+	case strings.HasSuffix(hashed, hex.EncodeToString(sum[:])):
+		t.Errorf("expected a keyed hash, got the plain hash %s", hashed)
+	case hashed != log.HashNumber(number):
+		t.Errorf("expected the same hash for the same number")
+	case hashed == other.HashNumber(number):
+		t.Errorf("expected different hashes under different keys")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	log := populate(t, 3)
+	entries, err := log.Select(&Filter{Recipient: "+393331234561"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Seq != 2 {
+		t.Errorf("expected entry 2 to match the hashed recipient, got %d entries", len(entries))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(t *testing.T, lines [][]byte) [][]byte
+		head     func(t *testing.T, log *Log)
+		verified int
+		message  string
+	}{
+		{
+			name:     "intact",
+			tamper:   func(t *testing.T, lines [][]byte) [][]byte { return lines },
+			verified: 4,
+		},
+		{
+			name: "modified entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[1] = modify(t, lines[1], func(entry *Entry) { entry.Status = Failed })
+				return lines
+			},
+			verified: 1,
+			message:  "entry 2 does not match its hash",
+		},
+		{
+			name: "modified and rehashed entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[1] = modify(t, lines[1], func(entry *Entry) {
+					entry.Recipients = []string{"+393330000000"}
+					entry.Hash = plain(t, entry)
+				})
+				return lines
+			},
+			verified: 1,
+			message:  "entry 2 does not match its hash",
+		},
+		{
+			name: "rewritten log",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				prev := Genesis
+				for i := range lines {
+					lines[i] = modify(t, lines[i], func(entry *Entry) {
+						entry.Recipients = []string{"+393330000000"}
+						entry.Prev = prev
+						entry.Hash = plain(t, entry)
+						prev = entry.Hash
+					})
+				}
+				return lines
+			},
+			verified: 0,
+			message:  "entry 1 does not match its hash",
+		},
+		{
+			name: "removed last entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return lines[:len(lines)-1]
+			},
+			verified: 3,
+			message:  "entries after entry 3 removed",
+		},
+		{
+			name: "removed all entries",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return nil
+			},
+			verified: 0,
+			message:  "entries after entry 0 removed",
+		},
+		{
+			name: "removed last entry and head",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return lines[:len(lines)-1]
+			},
+			head: func(t *testing.T, log *Log) {
+				if err := os.Remove(log.Path() + ".head"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			verified: 3,
+			message:  "the head is missing",
+		},
+		{
+			name: "removed last entry and forged head",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return lines[:len(lines)-1]
+			},
+			head: func(t *testing.T, log *Log) {
+				entries, err := log.Entries()
+				if err != nil {
+					t.Fatal(err)
+				}
+				last := entries[len(entries)-1]
+				data, _ := json.Marshal(&head{Seq: last.Seq, Hash: last.Hash, Signature: last.Hash})
+				if err := os.WriteFile(log.Path()+".head", data, 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			verified: 0,
+			message:  "the head does not match its signature",
+		},
+		{
+			name: "head written before a crash",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return lines
+			},
+			head: func(t *testing.T, log *Log) {
+				entries, err := log.Entries()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := log.writeHead(entries[1]); err != nil {
+					t.Fatal(err)
+				}
+			},
+			verified: 4,
+		},
+		{
+			name: "removed first entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return lines[1:]
+			},
+			verified: 0,
+			message:  "entry 2 follows entry 0",
+		},
+		{
+			name: "removed entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return slices.Delete(lines, 1, 2)
+			},
+			verified: 1,
+			message:  "entry 3 follows entry 1",
+		},
+		{
+			name: "removed and renumbered entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines = slices.Delete(lines, 1, 2)
+				for i := 1; i < len(lines); i++ {
+					lines[i] = modify(t, lines[i], func(entry *Entry) { entry.Seq-- })
+				}
+				return lines
+			},
+			verified: 1,
+			message:  "entry 2 does not chain to the previous entry",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			verified: 1,
+			message:  "entry 3 follows entry 1",
+		},
+		{
+			name: "reordered and renumbered entries",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				lines[1] = modify(t, lines[1], func(entry *Entry) { entry.Seq = 2 })
+				lines[2] = modify(t, lines[2], func(entry *Entry) { entry.Seq = 3 })
+				return lines
+			},
+			verified: 1,
+			message:  "entry 2 does not chain to the previous entry",
+		},
+		{
+			name: "duplicated entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				return slices.Insert(lines, 2, lines[1])
+			},
+			verified: 2,
+			message:  "entry 2 follows entry 2",
+		},
+		{
+			name: "corrupted entry",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[2] = lines[2][:len(lines[2])/2]
+				return lines
+			},
+			verified: 2,
+			message:  "line 3 is not a valid entry",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := populate(t, 4)
+			rewrite(t, log, test.tamper(t, lines(t, log)))
+			if test.head != nil {
+				test.head(t, log)
+			}
+			verified, err := log.Verify()
+			if verified != test.verified {
+				t.Errorf("expected %d entries verified, got %d", test.verified, verified)
+			}
+			switch {
+			case test.message == "" && err != nil:
+				t.Errorf("expected no error, got %v", err)
+			case test.message != "" && !errors.Is(err, ErrTampered):
+				t.Errorf("expected %v, got %v", ErrTampered, err)
+			case test.message != "" && !strings.Contains(err.Error(), test.message):
+				t.Errorf("expected error %q, got %q", test.message, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// operations maps the mutating API calls to the names of the operations
+// they perform.
+var operations = map[rdcom.Call]string{
+	{Method: http.MethodPost, Path: "/api/v2/{account}/cds/sms/messages/"}:       "sms.send",
+	{Method: http.MethodPost, Path: "/api/v2/{account}/cds/rcs/messages/"}:       "rcs.send",
+	{Method: http.MethodPost, Path: "/api/v2/{account}/cds/sms/senders/"}:        "sender.request",
+	{Method: http.MethodDelete, Path: "/api/v2/{account}/cds/sms/senders/{id}/"}: "sender.cancel",
+	{Method: http.MethodPost, Path: "/api/v2/{account}/cds/sms/blacklist/"}:      "blacklist.add",
+	{Method: http.MethodPost, Path: "/api/v2/tokens/"}:                           "token.create",
+	{Method: http.MethodDelete, Path: "/api/v2/tokens/"}:                         "token.delete",
+}
+
+// Recorder records the mutating API calls (i.e. all but GET, HEAD and
+// OPTIONS) of a client in an audit log; every attempt is recorded, retries
+// included.
+type Recorder struct {
+	Log *Log
+	// User is the operating system user performing the calls.
+	User string
+	// Profile is the configuration profile in use, if any.
+	Profile string
+	// Endpoint is the API endpoint of the client.
+	Endpoint string
+}
+
+// Hooks returns the client hooks that record the API calls; a failure to
+// write the audit log is logged, as the call has already been performed.
+func (r *Recorder) Hooks() rdcom.Hooks {
+	return rdcom.Hooks{
+		AfterResponse: r.record,
+		OnError: func(event *rdcom.Event) {
+			// calls with a response are recorded by AfterResponse
+			if event.StatusCode == 0 {
+				r.record(event)
+			}
+		},
+	}
+}
+
+// record appends an entry for the API call, if it is a mutating one.
+func (r *Recorder) record(event *rdcom.Event) {
+	switch event.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+
+	entry := &Entry{
+		Time:       event.Start,
+		User:       r.User,
+		Profile:    r.Profile,
+		Endpoint:   r.Endpoint,
+		Account:    pathParam(event.Path, event.URL.Path, "account"),
+		Operation:  Operation(event.Call),
+		Method:     event.Method,
+		Path:       event.Path,
+		Status:     OK,
+		StatusCode: event.StatusCode,
+	}
+	if event.Err != nil || event.StatusCode >= http.StatusBadRequest {
+		entry.Status = Failed
+	}
+	if event.Err != nil {
+		entry.Error = event.Err.Error()
+	}
+
+	switch entity := event.Entity.(type) {
+	case *rdcom.Message:
+		entry.Reference = entity.ClientRef
+		entry.Recipients = slices.Clone(entity.Recipients)
+	case *rdcom.RCSMessage:
+		entry.Recipients = slices.Clone(entity.Recipients)
+	case *rdcom.Sender:
+		entry.Subject = entity.Sender
+	case *rdcom.BlacklistEntry:
+		entry.Recipients = []string{entity.Number}
+	case *rdcom.Token:
+		// never record secrets, only a fingerprint
+		sum := sha256.Sum256([]byte(entity.Token))
+		entry.Subject = "token:" + hex.EncodeToString(sum[:])[:12]
+	}
+	if entry.Subject == "" {
+		entry.Subject = pathParam(event.Path, event.URL.Path, "id")
+	}
+
+	if err := r.Log.Append(entry); err != nil {
+		slog.Error("error recording API call in audit log", "operation", entry.Operation, "error", err)
+	}
+}
+
+// Operation returns the name of the operation performed by an API call; the
+// name of calls that are not known is made of the last fixed element of the
+// path and the method (e.g. "accounts.patch").
+func Operation(call rdcom.Call) string {
+	if name, ok := operations[call]; ok {
+		return name
+	}
+	segments := strings.Split(strings.Trim(call.Path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] != "" && !strings.HasPrefix(segments[i], "{") {
+			return segments[i] + "." + strings.ToLower(call.Method)
+		}
+	}
+	return strings.ToLower(call.Method)
+}
+
+// pathParam extracts the value of a path parameter by matching the entity
+// path template with the actual path.
+func pathParam(template string, path string, name string) string {
+	placeholder := "{" + name + "}"
+	templates := strings.Split(strings.Trim(template, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	// the actual path may have a prefix from the base URL
+	offset := len(segments) - len(templates)
+	if offset < 0 {
+		return ""
+	}
+	for i, segment := range templates {
+		if segment == placeholder {
+			return segments[offset+i]
+		}
+	}
+	return ""
+}
@@ -6,7 +6,7 @@ import (
 
 	"github.com/dihedron/sms/command/base"
 	"github.com/dihedron/sms/format"
-	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/pii"
 	"github.com/fatih/color"
 )
 
@@ -19,24 +19,7 @@ type List struct {
 func (cmd *List) Execute(args []string) error {
 	slog.Debug("called account list command")
 
-	options := []rdcom.Option{
-		rdcom.WithBaseURL(cmd.Endpoint),
-		rdcom.WithUserAgent("bancaditalia/0.1"),
-	}
-	if cmd.SkipVerifyTLS {
-		options = append(options, rdcom.WithSkipTLSVerify(true))
-	}
-	if cmd.EnableDebug {
-		options = append(options, rdcom.WithDebug())
-	}
-	if cmd.EnableTrace {
-		options = append(options, rdcom.WithTrace())
-	}
-	if cmd.Token != nil {
-		options = append(options, rdcom.WithAuthToken(*cmd.Token))
-	}
-
-	client, err := rdcom.New(options...)
+	client, err := cmd.NewClient()
 	if err != nil {
 		slog.Error("error initialising API client", "error", err)
 		return err
@@ -62,18 +45,23 @@ func (cmd *List) Execute(args []string) error {
 		} else {
 			fmt.Printf(" - expiration             : %s\n", color.YellowString(account.ExpirationDate.Format(base.DefaultDateFormat)))
 		}
+		if account.EnableSmsUnlimitedCredit {
+			fmt.Printf(" - SMS credit             : %s\n", color.YellowString("unlimited"))
+		} else {
+			fmt.Printf(" - SMS credit             : %s\n", color.YellowString(fmt.Sprintf("%.2f", account.SmsCredits)))
+		}
 		fmt.Printf(" - main contact           :\n")
-		fmt.Printf("   - name                 : %s\n", color.YellowString(account.Infos.MainContactName))
-		fmt.Printf("   - name                 : %s\n", color.YellowString(account.Infos.MainContactSurname))
-		fmt.Printf("   - email                : %s\n", color.YellowString(account.Infos.MainContactEmail))
-		fmt.Printf("   - mobile               : %s\n", color.YellowString(account.Infos.MainContactCell))
+		fmt.Printf("   - name                 : %s\n", color.YellowString(pii.PersonalOf(account.Infos.MainContactName)))
+		fmt.Printf("   - name                 : %s\n", color.YellowString(pii.PersonalOf(account.Infos.MainContactSurname)))
+		fmt.Printf("   - email                : %s\n", color.YellowString(pii.PersonalOf(account.Infos.MainContactEmail)))
+		fmt.Printf("   - mobile               : %s\n", color.YellowString(pii.PhoneOf(account.Infos.MainContactCell)))
 		fmt.Printf(" - representative         :\n")
-		fmt.Printf("   - name                 : %s\n", color.YellowString(account.Infos.ReprName))
-		fmt.Printf("   - name                 : %s\n", color.YellowString(account.Infos.ReprSurname))
-		fmt.Printf("   - email                : %s\n", color.YellowString(account.Infos.ReprEmail))
+		fmt.Printf("   - name                 : %s\n", color.YellowString(pii.PersonalOf(account.Infos.ReprName)))
+		fmt.Printf("   - name                 : %s\n", color.YellowString(pii.PersonalOf(account.Infos.ReprSurname)))
+		fmt.Printf("   - email                : %s\n", color.YellowString(pii.PersonalOf(account.Infos.ReprEmail)))
 		fmt.Printf(" - company                :\n")
 		fmt.Printf("   - name                 : %s\n", color.YellowString(account.Infos.Company))
-		fmt.Printf("   - address              : %s\n", color.YellowString(account.Infos.Address))
+		fmt.Printf("   - address              : %s\n", color.YellowString(pii.PersonalOf(account.Infos.Address)))
 		fmt.Printf("   - city                 : %s\n", color.YellowString(account.Infos.City))
 		fmt.Printf("   - state                : %s\n", color.YellowString(account.Infos.State))
 		fmt.Printf("   - country              : %s\n", color.YellowString(account.Infos.Country))
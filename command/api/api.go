@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/format"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// API is the command that performs raw calls to arbitrary RDCom API
+// endpoints, for those that have no dedicated command.
+type API struct {
+	base.TokenCommand
+	base.Confirmation
+	// Account is the account substituted for {account} in the path.
+	Account *string `short:"a" long:"account" description:"The account to substitute for {account} in the path." env:"SMS_ACCOUNT"`
+	// Params are the path parameters.
+	Params []string `short:"p" long:"param" description:"A path parameter, as name=value, substituted for {name} in the path; can be repeated."`
+	// Query are the query parameters.
+	Query []string `short:"q" long:"query" description:"A query parameter, as name=value; can be repeated."`
+	// Headers are the additional request headers.
+	Headers []string `short:"H" long:"header" description:"An additional request header, as name:value; can be repeated."`
+	// Data is the path to the file with the request body.
+	Data *string `short:"d" long:"data" description:"The path to a JSON or YAML file with the request body, sent as JSON (use '-' for STDIN)."`
+	// Paginate sets whether to retrieve all the pages of a paginated list.
+	Paginate bool `long:"paginate" description:"Whether to retrieve all the pages of a paginated list and print their results as a single list (GET only)." optional:"yes"`
+	// PageSize is the number of results per page when paginating.
+	PageSize int `long:"page-size" description:"The number of results per page when paginating." default:"100"`
+	// Output is the output format.
+	Output string `short:"o" long:"output" description:"The output format of JSON responses." choice:"json" choice:"yaml" choice:"compact" default:"json" env:"SMS_API_OUTPUT"`
+}
+
+// placeholder matches the path parameter placeholders in a path.
+var placeholder = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// Execute is the real implementation of the api command; arguments are
+// the HTTP method and the path, e.g. "GET /api/v2/{account}/cds/sms/".
+func (cmd *API) Execute(args []string) error {
+	slog.Debug("called api command", "args", args)
+
+	if len(args) != 2 {
+		slog.Error("invalid number of arguments", "args", args)
+		return errors.New("usage: api <METHOD> <path>")
+	}
+	method := strings.ToUpper(args[0])
+	if !slices.Contains(rdcom.RawMethods, method) {
+		slog.Error("unsupported HTTP method", "method", method)
+		return fmt.Errorf("unsupported HTTP method %q (supported: %s)", args[0], strings.Join(rdcom.RawMethods, ", "))
+	}
+	path := args[1]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if rdcom.IsSendCall(method, path) {
+		slog.Error("api command cannot send messages", "method", method, "path", path)
+		return fmt.Errorf("%w: use the send command to send messages", rdcom.ErrSendCall)
+	}
+	if cmd.Paginate && method != "GET" {
+		slog.Error("pagination requires GET", "method", method)
+		return fmt.Errorf("--paginate cannot be used with %s", method)
+	}
+	if cmd.PageSize <= 0 {
+		slog.Error("invalid page size", "page size", cmd.PageSize)
+		return fmt.Errorf("invalid page size: %d", cmd.PageSize)
+	}
+
+	options := rdcom.Options{EntityPath: path}
+	var err error
+	if options.PathParams, err = pairs(cmd.Params, "=", "path parameter"); err != nil {
+		return err
+	}
+	if cmd.Account != nil && *cmd.Account != "" {
+		if _, ok := options.PathParams["account"]; !ok {
+			options.PathParams["account"] = *cmd.Account
+		}
+	}
+	missing := []string{}
+	for _, match := range placeholder.FindAllStringSubmatch(path, -1) {
+		if _, ok := options.PathParams[match[1]]; !ok && !slices.Contains(missing, match[1]) {
+			missing = append(missing, match[1])
+		}
+	}
+	if len(missing) > 0 {
+		slog.Error("missing path parameters", "names", missing)
+		return fmt.Errorf("missing path parameters: %s (use --param name=value, or --account)", strings.Join(missing, ", "))
+	}
+	if options.QueryParams, err = pairs(cmd.Query, "=", "query parameter"); err != nil {
+		return err
+	}
+	if options.Headers, err = pairs(cmd.Headers, ":", "header"); err != nil {
+		return err
+	}
+
+	var body any
+	if cmd.Data != nil {
+		if body, err = loadBody(*cmd.Data); err != nil {
+			return err
+		}
+	}
+
+	if method != http.MethodGet {
+		if ok, err := cmd.Confirm(fmt.Sprintf("call %s %s?", method, path)); !ok {
+			return err
+		}
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	if cmd.Paginate {
+		results, err := rdcom.PaginatedList[json.RawMessage](client, &rdcom.PaginatedListOptions{
+			Options:  options,
+			PageSize: &cmd.PageSize,
+		})
+		if err != nil {
+			slog.Error("error performing paginated API call", "path", path, "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			return fmt.Errorf("error performing API call: %w", err)
+		}
+		data, err := json.Marshal(results)
+		if err != nil {
+			slog.Error("error encoding results", "error", err)
+			return err
+		}
+		slog.Debug("paginated API call success", "results", len(results))
+		cmd.print(data)
+		return nil
+	}
+
+	response, err := rdcom.Raw(client, method, &rdcom.RawOptions{
+		Options: options,
+		Body:    body,
+	})
+	if response != nil {
+		if len(response.Body) == 0 {
+			fmt.Fprintf(os.Stderr, "status: %s\n", response.Status)
+		} else {
+			cmd.print(response.Body)
+		}
+	}
+	if err != nil {
+		slog.Error("error performing API call", "method", method, "path", path, "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+	return nil
+}
+
+// print writes a response body in the selected format, with PII masked
+// unless explicitly shown; bodies that are not JSON are written as they
+// are, or redacted altogether if PII is not shown.
+func (cmd *API) print(data []byte) {
+	redacted := pii.RedactJSON(string(data))
+	decoder := json.NewDecoder(strings.NewReader(redacted))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		slog.Debug("response is not JSON, printing as is", "error", err)
+		fmt.Println(redacted)
+		return
+	}
+	document = numbers(document)
+	switch cmd.Output {
+	case "yaml":
+		fmt.Print(format.ToYAML(document))
+	case "compact":
+		fmt.Println(format.ToJSON(document))
+	default:
+		fmt.Println(format.ToPrettyJSON(document))
+	}
+}
+
+// numbers replaces the JSON numbers in a decoded document with integers
+// where possible, so that identifiers do not lose precision nor get
+// printed in exponential notation.
+func numbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+// pairs parses a list of name-value pairs separated by the given string.
+func pairs(values []string, separator string, kind string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, value := range values {
+		name, v, ok := strings.Cut(value, separator)
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			slog.Error("invalid name-value pair", "kind", kind, "value", value)
+			return nil, fmt.Errorf("invalid %s %q: expected name%svalue", kind, value, separator)
+		}
+		result[name] = strings.TrimSpace(v)
+	}
+	return result, nil
+}
+
+// loadBody reads the request body; files with a .json extension are
+// parsed as JSON, anything else (including STDIN) as YAML, which is a
+// superset of JSON.
+func loadBody(path string) (any, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filepath.Clean(path))
+	}
+	if err != nil {
+		slog.Error("error reading request body", "path", path, "error", err)
+		return nil, err
+	}
+	var body any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&body)
+	} else {
+		err = yaml.Unmarshal(data, &body)
+	}
+	if err != nil {
+		slog.Error("error parsing request body", "path", path, "error", err)
+		return nil, fmt.Errorf("invalid request body %s: %w", path, err)
+	}
+	return body, nil
+}
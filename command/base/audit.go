@@ -0,0 +1,91 @@
+package base
+
+import (
+	"log/slog"
+	"os"
+	"os/user"
+
+	"github.com/dihedron/sms/audit"
+	"github.com/dihedron/sms/metadata"
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Audit is the set of flags used by commands that record their mutating API
+// calls in, or query, the local audit log.
+type Audit struct {
+	// AuditLog is the path to the audit log file.
+	AuditLog *string `long:"audit-log" description:"The path to the audit log (default: in the user configuration directory)." env:"SMS_AUDIT_LOG"`
+	// AuditKey is the path to the key of the audit log.
+	AuditKey *string `long:"audit-key" description:"The path to the key used to sign the audit log and hash recipients, kept apart from the log (default: in the user configuration directory)." env:"SMS_AUDIT_KEY"`
+	// AuditHashRecipients sets whether recipients are recorded as hashes.
+	AuditHashRecipients bool `long:"audit-hash-recipients" description:"Whether to record recipients in the audit log as hashes rather than in clear." optional:"yes" env:"SMS_AUDIT_HASH_RECIPIENTS"`
+}
+
+// Open opens the audit log.
+func (a *Audit) Open() (*audit.Log, error) {
+	path := ""
+	if a.AuditLog != nil {
+		path = *a.AuditLog
+	} else {
+		var err error
+		if path, err = StatePath("audit.log"); err != nil {
+			return nil, err
+		}
+	}
+	keyPath := ""
+	if a.AuditKey != nil {
+		keyPath = *a.AuditKey
+	} else {
+		var err error
+		if keyPath, err = StatePath("audit.key"); err != nil {
+			return nil, err
+		}
+	}
+	key, err := audit.LoadKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	log, err := audit.Open(path, key)
+	if err != nil {
+		return nil, err
+	}
+	log.HashRecipients = a.AuditHashRecipients
+	return log, nil
+}
+
+// Auditor returns the client option that records the mutating API calls
+// performed by the command in the audit log; replayed calls are not
+// recorded.
+func (cmd *Command) Auditor() rdcom.Option {
+	if cmd.Replay != nil {
+		// replayed calls were not performed
+		return func(*rdcom.Client) {}
+	}
+	log, err := cmd.Audit.Open()
+	if err != nil {
+		slog.Error("error opening audit log, API calls will not be recorded", "error", err)
+		return func(*rdcom.Client) {}
+	}
+	recorder := &audit.Recorder{
+		Log:      log,
+		User:     currentUser(),
+		Endpoint: cmd.Endpoint,
+	}
+	if metadata.DotEnvVarName != "" {
+		recorder.Profile = os.Getenv(metadata.DotEnvVarName)
+	}
+	return rdcom.WithHooks(recorder.Hooks())
+}
+
+// currentUser returns the name of the operating system user.
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	for _, name := range []string{"USER", "USERNAME", "LOGNAME"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
@@ -20,6 +20,14 @@ type Command struct {
 	CPUProfile *string `short:"C" long:"cpu-profile" description:"The (optional) path where the CPU profiler will store its data." optional:"yes" env:"SMS_CPU_PROFILE"`
 	// MemProfile sets the (optional) path of the file for memory profiling info.
 	MemProfile *string `short:"M" long:"mem-profile" description:"The (optional) path where the memory profiler will store its data." optional:"yes" env:"SMS_MEM_PROFILE"`
+	// Transport holds the network options.
+	Transport
+	// Planning holds the dry-run options.
+	Planning
+	// Audit holds the audit log options.
+	Audit
+	// Suppression holds the suppression list options.
+	Suppression
 }
 
 type TokenCommand struct {
@@ -0,0 +1,69 @@
+package base
+
+import (
+	"log/slog"
+
+	"github.com/dihedron/sms/metadata"
+	"github.com/dihedron/sms/rdcom"
+)
+
+// ClientHook returns an option to apply to the API clients created by a
+// command; hooks add cross-cutting features (e.g. auditing) to all clients.
+type ClientHook func(cmd *Command) rdcom.Option
+
+// clientHooks are the hooks applied to all API clients, in order.
+var clientHooks = []ClientHook{
+	(*Command).Auditor,
+	(*Command).Planner,
+	(*Command).Suppressor,
+}
+
+// RegisterClientHook adds hooks to be applied to all the API clients created
+// by commands from now on.
+func RegisterClientHook(hooks ...ClientHook) {
+	clientHooks = append(clientHooks, hooks...)
+}
+
+// options returns the client options common to all commands, hooks
+// included.
+func (cmd *Command) options() []rdcom.Option {
+	options := []rdcom.Option{
+		rdcom.WithBaseURL(cmd.Endpoint),
+		rdcom.WithUserAgent(metadata.UserAgent()),
+	}
+	options = append(options, cmd.Transport.options()...)
+	if cmd.SkipVerifyTLS {
+		options = append(options, rdcom.WithSkipTLSVerify(true))
+	}
+	if cmd.EnableDebug {
+		options = append(options, rdcom.WithDebug())
+	}
+	if cmd.EnableTrace {
+		options = append(options, rdcom.WithTrace())
+	}
+	for _, hook := range clientHooks {
+		options = append(options, hook(cmd))
+	}
+	return options
+}
+
+// NewClient initialises an API client authenticated with the token; extra
+// options are applied last, so they can override the common ones (e.g. the
+// token itself).
+func (cmd *TokenCommand) NewClient(extra ...rdcom.Option) (*rdcom.Client, error) {
+	options := cmd.options()
+	if cmd.Token != nil {
+		options = append(options, rdcom.WithAuthToken(*cmd.Token))
+	}
+	slog.Debug("initialising API client", "endpoint", cmd.Endpoint, "extra options", len(extra))
+	return rdcom.New(append(options, extra...)...)
+}
+
+// NewClient initialises an API client authenticated with the credentials;
+// extra options are applied last, so they can override the common ones.
+func (cmd *CredentialsCommand) NewClient(extra ...rdcom.Option) (*rdcom.Client, error) {
+	options := cmd.options()
+	options = append(options, rdcom.WithUserCredentials(cmd.Username, cmd.Password))
+	slog.Debug("initialising API client", "endpoint", cmd.Endpoint, "extra options", len(extra))
+	return rdcom.New(append(options, extra...)...)
+}
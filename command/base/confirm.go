@@ -0,0 +1,92 @@
+package base
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ErrNonInteractive is returned when an operation needs confirmation, but
+// there is no terminal to ask it on and it was not given on the command line.
+var ErrNonInteractive = errors.New("confirmation required but not running interactively (use --yes)")
+
+// Confirmation is the set of flags used by commands that ask for
+// confirmation before performing destructive or costly operations.
+type Confirmation struct {
+	// Yes sets whether to proceed without asking for confirmation.
+	Yes bool `short:"y" long:"yes" description:"Whether to proceed without asking for confirmation." optional:"yes" env:"SMS_YES"`
+}
+
+// Confirm asks the user to confirm an operation on the terminal, after
+// printing its summary (one detail per line), unless confirmation was given
+// on the command line. It returns false and no error if the user declines
+// (anything but an explicit "yes" is taken as a refusal), and false and
+// ErrNonInteractive if the standard input or error is not a terminal.
+func (c *Confirmation) Confirm(question string, summary ...string) (bool, error) {
+	if c.Yes {
+		slog.Debug("operation confirmed on the command line", "question", question)
+		return true, nil
+	}
+	if !interactive() {
+		slog.Error("confirmation required but not running interactively", "question", question)
+		fmt.Printf("error: %s\n", color.RedString(ErrNonInteractive.Error()))
+		return false, ErrNonInteractive
+	}
+
+	// the prompt goes to stderr, so it is shown even if the output is not
+	for _, detail := range summary {
+		fmt.Fprintf(os.Stderr, " - %s\n", detail)
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", color.YellowString(question))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		slog.Debug("no confirmation received", "error", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		slog.Debug("operation confirmed", "question", question)
+		return true, nil
+	}
+	slog.Info("operation not confirmed", "question", question)
+	fmt.Printf("operation: %s\n", color.YellowString("aborted"))
+	return false, nil
+}
+
+// interactive reports whether the standard input and error are terminals.
+func interactive() bool {
+	for _, file := range []*os.File{os.Stdin, os.Stderr} {
+		info, err := file.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Limits is the set of flags setting the policy limits above which sending
+// messages requires confirmation; negative limits are disabled.
+type Limits struct {
+	// ConfirmRecipients is the number of recipients requiring confirmation.
+	ConfirmRecipients int `long:"confirm-recipients" description:"The number of recipients above which sending requires confirmation (negative to disable)." default:"100" env:"SMS_CONFIRM_RECIPIENTS"`
+	// ConfirmCost is the expected cost requiring confirmation.
+	ConfirmCost float64 `long:"confirm-cost" description:"The expected cost (in credits) above which sending requires confirmation (negative to disable)." default:"10" env:"SMS_CONFIRM_COST"`
+}
+
+// Exceeded returns the descriptions of the limits exceeded by a send to the
+// given number of recipients at the given expected cost, if any.
+func (l *Limits) Exceeded(recipients int, cost float64) []string {
+	exceeded := []string{}
+	if l.ConfirmRecipients >= 0 && recipients > l.ConfirmRecipients {
+		exceeded = append(exceeded, fmt.Sprintf("recipients             : %s (limit %d)", color.RedString("%d", recipients), l.ConfirmRecipients))
+	}
+	if l.ConfirmCost >= 0 && cost > l.ConfirmCost {
+		exceeded = append(exceeded, fmt.Sprintf("expected cost          : %s (limit %.4f)", color.RedString("%.4f", cost), l.ConfirmCost))
+	}
+	return exceeded
+}
@@ -0,0 +1,96 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// ErrDryRunUnsupported is returned by commands that cannot run in dry-run
+// mode, because they would record as done calls that were only planned.
+var ErrDryRunUnsupported = errors.New("command not supported in dry-run mode")
+
+// Planning is the set of flags controlling the dry-run mode, in which
+// mutating API calls are only planned.
+type Planning struct {
+	// DryRun sets whether mutating API calls are only planned.
+	DryRun bool `long:"dry-run" description:"Whether to validate and print mutating API calls (and their expected cost) without performing them." optional:"yes" env:"SMS_DRY_RUN"`
+	// DryRunPlan is the (optional) path of the file recording planned calls.
+	DryRunPlan *string `long:"dry-run-plan" description:"The (optional) path of a file where planned API calls are recorded, one JSON object per line." env:"SMS_DRY_RUN_PLAN"`
+}
+
+// Planner returns the client option that, in dry-run mode, prints (and
+// optionally records) the mutating API calls instead of performing them;
+// outside dry-run mode the option does nothing.
+func (cmd *Command) Planner() rdcom.Option {
+	if !cmd.DryRun {
+		return func(*rdcom.Client) {}
+	}
+	return rdcom.WithDryRun(func(call *rdcom.PlannedCall) {
+		// the plan goes to stderr, so the (simulated) output can be parsed
+		fmt.Fprintf(os.Stderr, "%s %s %s\n", color.MagentaString("dry-run:"), color.YellowString(call.Method), call.URL)
+		if call.Body != "" {
+			fmt.Fprintf(os.Stderr, " - body                   :\n%s\n", indent(pii.RedactJSON(call.Body)))
+		}
+		if quotation := call.Quotation; quotation != nil {
+			fmt.Fprintf(os.Stderr, " - expected cost          : %s\n", color.GreenString(fmt.Sprintf("%.4f (%d recipients, %d segments)", quotation.Total, quotation.Recipients, quotation.Segments)))
+			if len(quotation.Unpriced) > 0 {
+				fmt.Fprintf(os.Stderr, " - unpriced               : %s\n", color.RedString(strings.Join(pii.PhonesOf(quotation.Unpriced), ", ")))
+			}
+		}
+		if cmd.DryRunPlan != nil {
+			cmd.record(call)
+		}
+	})
+}
+
+// record appends a planned call to the plan file, with PII masked.
+func (cmd *Command) record(call *rdcom.PlannedCall) {
+	planned := pii.Redact(*call)
+	if body := pii.RedactJSON(call.Body); body != "" {
+		compacted := &bytes.Buffer{}
+		if err := json.Compact(compacted, []byte(body)); err == nil {
+			body = compacted.String()
+		}
+		planned.Body = body
+	}
+	data, err := json.Marshal(&planned)
+	if err != nil {
+		slog.Error("error encoding planned API call", "error", err)
+		return
+	}
+	file, err := os.OpenFile(*cmd.DryRunPlan, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Error("error opening dry-run plan file", "path", *cmd.DryRunPlan, "error", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		slog.Error("error writing dry-run plan file", "path", *cmd.DryRunPlan, "error", err)
+	}
+}
+
+// indent indents all the lines of a text for printing under a label.
+func indent(text string) string {
+	return "   " + strings.ReplaceAll(text, "\n", "\n   ")
+}
+
+// RefuseDryRun returns an error if the command is run in dry-run mode; it
+// is used by commands that keep local state about the calls they perform.
+func (cmd *Command) RefuseDryRun(command string) error {
+	if !cmd.DryRun {
+		return nil
+	}
+	err := fmt.Errorf("%w: %s", ErrDryRunUnsupported, command)
+	slog.Error("command cannot run in dry-run mode", "command", command)
+	fmt.Printf("error: %s\n", color.RedString(err.Error()))
+	return err
+}
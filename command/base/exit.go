@@ -0,0 +1,32 @@
+package base
+
+import (
+	"fmt"
+)
+
+// ExitError is an error that carries the exit code the application should
+// terminate with.
+type ExitError struct {
+	// Code is the process exit code.
+	Code int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error returns the message of the underlying error.
+func (e *ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
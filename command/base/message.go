@@ -0,0 +1,49 @@
+package base
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Message is the set of flags used by commands that accept the text of an
+// SMS message, either on the command line or from a file.
+type Message struct {
+	// Text is the text of the message.
+	Text *string `short:"m" long:"message" description:"The text of the message."`
+	// TextFile is the path to a file containing the text of the message ("-" for STDIN).
+	TextFile *string `short:"F" long:"message-file" description:"The path to a file containing the text of the message (use '-' for STDIN)."`
+}
+
+// Load returns the text of the message; exactly one of the message text
+// and the message file must be provided.
+func (m *Message) Load() (string, error) {
+	switch {
+	case m.Text != nil && m.TextFile != nil:
+		slog.Error("both message text and message file provided")
+		return "", errors.New("only one of message text and message file can be provided")
+	case m.Text != nil:
+		return *m.Text, nil
+	case m.TextFile != nil:
+		var (
+			data []byte
+			err  error
+		)
+		if *m.TextFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(filepath.Clean(*m.TextFile))
+		}
+		if err != nil {
+			slog.Error("error reading message file", "path", *m.TextFile, "error", err)
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		slog.Error("no message text provided")
+		return "", errors.New("no message text provided")
+	}
+}
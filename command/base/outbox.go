@@ -0,0 +1,25 @@
+package base
+
+import (
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Outbox is the set of flags used by commands that access the durable
+// outbox of outgoing messages.
+type Outbox struct {
+	// OutboxDir is the path to the outbox directory.
+	OutboxDir *string `long:"outbox" description:"The path to the outbox directory (default: in the user configuration directory)." env:"SMS_OUTBOX"`
+}
+
+// Open opens the outbox; messages are sent through the given client, which
+// can be nil if the outbox is only inspected.
+func (o *Outbox) Open(client *rdcom.Client) (*rdcom.Outbox, error) {
+	if o.OutboxDir != nil {
+		return rdcom.OpenOutbox(client, *o.OutboxDir)
+	}
+	dir, err := StatePath("outbox")
+	if err != nil {
+		return nil, err
+	}
+	return rdcom.OpenOutbox(client, dir)
+}
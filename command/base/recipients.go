@@ -0,0 +1,66 @@
+package base
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/dihedron/sms/phone"
+)
+
+// Prefix is the flag used by commands that accept phone numbers, which may
+// be given without an international prefix.
+type Prefix struct {
+	// DefaultPrefix is the calling code applied to numbers without an international prefix.
+	DefaultPrefix string `short:"P" long:"default-prefix" description:"The international calling code to apply to numbers without an international prefix." env:"SMS_DEFAULT_PREFIX" default:"39"`
+}
+
+// Normalise converts the given phone number to E.164 format, applying the
+// default prefix if it has no international prefix.
+func (p *Prefix) Normalise(number string) (string, error) {
+	return phone.Normalise(number, p.DefaultPrefix)
+}
+
+// Recipients is the set of flags used by commands that accept a list of
+// recipient phone numbers, either on the command line or from a file.
+type Recipients struct {
+	Prefix
+	// RecipientsFile is the path to a file containing recipients, one per line.
+	RecipientsFile *string `short:"f" long:"recipients-file" description:"The path to a file containing the recipients' phone numbers, one per line."`
+}
+
+// Load reads the recipients from the recipients file (if any) and from the
+// given command line arguments, normalises them to E.164 format and removes
+// duplicates; numbers that cannot be normalised are returned separately.
+func (r *Recipients) Load(args []string) (valid []string, invalid []string, err error) {
+	numbers := []string{}
+	if r.RecipientsFile != nil {
+		file, err := os.Open(filepath.Clean(*r.RecipientsFile))
+		if err != nil {
+			slog.Error("error opening recipients file", "path", *r.RecipientsFile, "error", err)
+			return nil, nil, err
+		}
+		defer file.Close()
+		if numbers, err = phone.ReadList(file); err != nil {
+			slog.Error("error reading recipients file", "path", *r.RecipientsFile, "error", err)
+			return nil, nil, err
+		}
+	}
+	numbers = append(numbers, args...)
+
+	seen := map[string]bool{}
+	for _, number := range numbers {
+		normalised, err := r.Normalise(number)
+		if err != nil {
+			slog.Warn("invalid recipient", "number", number, "error", err)
+			invalid = append(invalid, number)
+			continue
+		}
+		if !seen[normalised] {
+			seen[normalised] = true
+			valid = append(valid, normalised)
+		}
+	}
+	slog.Debug("recipients loaded", "valid", len(valid), "invalid", len(invalid))
+	return valid, invalid, nil
+}
@@ -0,0 +1,24 @@
+package base
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// StatePath returns the default path of a local state file, in the "sms"
+// directory under the user configuration directory; the directory is
+// created if it does not exist.
+func StatePath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		slog.Error("error locating user configuration directory", "error", err)
+		return "", err
+	}
+	dir = filepath.Join(dir, "sms")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		slog.Error("error creating state directory", "path", dir, "error", err)
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,41 @@
+package base
+
+import (
+	"log/slog"
+
+	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/suppression"
+)
+
+// Suppression is the set of flags used by commands that consult or manage
+// the local suppression list.
+type Suppression struct {
+	// SuppressionList is the path to the suppression list file.
+	SuppressionList *string `long:"suppression-list" description:"The path to the suppression list (default: in the user configuration directory)." env:"SMS_SUPPRESSION_LIST"`
+}
+
+// Open loads the suppression list.
+func (s *Suppression) Open() (*suppression.List, error) {
+	path := ""
+	if s.SuppressionList != nil {
+		path = *s.SuppressionList
+	} else {
+		var err error
+		if path, err = StatePath("suppression.json"); err != nil {
+			return nil, err
+		}
+	}
+	return suppression.Open(path)
+}
+
+// Suppressor returns the client option that makes every message sent by the
+// command skip the recipients in the suppression list; if the list cannot be
+// loaded, the client is left without one and refuses to send.
+func (cmd *Command) Suppressor() rdcom.Option {
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		slog.Error("error opening suppression list, messages will not be sent", "error", err)
+		return func(*rdcom.Client) {}
+	}
+	return rdcom.WithSuppressor(list)
+}
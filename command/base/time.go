@@ -0,0 +1,25 @@
+package base
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTime parses a point in time given on the command line, either in
+// RFC3339 format or as a date, a date and time, or a time of today in the
+// local time zone.
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04", time.DateTime} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339, YYYY-MM-DD, YYYY-MM-DD HH:MM or HH:MM", value)
+}
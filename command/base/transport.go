@@ -0,0 +1,68 @@
+package base
+
+import (
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Transport is the set of flags controlling how commands connect to the API
+// endpoint: proxy, TLS and timeouts.
+type Transport struct {
+	// Proxy is the (optional) URL of the proxy to use.
+	Proxy *string `long:"proxy" description:"The URL of the HTTP(S) or SOCKS5 proxy to use, with credentials if needed (default: from the HTTP_PROXY and HTTPS_PROXY environment variables)." env:"SMS_PROXY" cfg:"proxy"`
+	// CABundles are the (optional) PEM files with additional trusted CAs.
+	CABundles []string `long:"ca-bundle" description:"The path to a PEM file with additional certification authorities to trust (repeatable)." env:"SMS_CA_BUNDLE" env-delim:"," cfg:"ca_bundle"`
+	// ClientCert is the (optional) PEM file with the client certificate.
+	ClientCert *string `long:"client-cert" description:"The path to the PEM client certificate for mutual TLS authentication." env:"SMS_CLIENT_CERT" cfg:"client_cert"`
+	// ClientKey is the (optional) PEM file with the client private key.
+	ClientKey *string `long:"client-key" description:"The path to the PEM private key of the client certificate." env:"SMS_CLIENT_KEY" cfg:"client_key"`
+	// MinTLSVersion is the minimum TLS version accepted.
+	MinTLSVersion string `long:"min-tls-version" description:"The minimum TLS version accepted." choice:"1.0" choice:"1.1" choice:"1.2" choice:"1.3" default:"1.2" env:"SMS_MIN_TLS_VERSION" cfg:"min_tls_version"`
+	// ConnectTimeout is the maximum duration of connecting to the endpoint.
+	ConnectTimeout time.Duration `long:"connect-timeout" description:"The maximum duration of connecting to the endpoint, TLS handshake included (0 for no timeout)." default:"10s" env:"SMS_CONNECT_TIMEOUT" cfg:"connect_timeout"`
+	// ReadTimeout is the maximum duration of waiting for a response.
+	ReadTimeout time.Duration `long:"read-timeout" description:"The maximum duration of waiting for a response once a request is sent (0 for no timeout)." default:"30s" env:"SMS_READ_TIMEOUT" cfg:"read_timeout"`
+	// Timeout is the maximum total duration of each API call attempt.
+	Timeout time.Duration `long:"timeout" description:"The maximum total duration of each API call attempt (0 for no timeout)." default:"60s" env:"SMS_TIMEOUT" cfg:"timeout"`
+	// Record is the (optional) directory where API exchanges are recorded.
+	Record *string `long:"record" description:"The path to a directory where all API exchanges are recorded, with secrets and PII redacted." env:"SMS_RECORD"`
+	// Replay is the (optional) directory API exchanges are replayed from.
+	Replay *string `long:"replay" description:"The path to a directory of recorded API exchanges to replay, without accessing the network." env:"SMS_REPLAY"`
+	// Retries is the number of times failed idempotent API calls are retried.
+	Retries int `long:"retries" description:"The number of times API calls failing with a transient error are retried (only if idempotent)." default:"2" env:"SMS_RETRIES" cfg:"retries"`
+}
+
+// options returns the client options that set up the transport.
+func (t *Transport) options() []rdcom.Option {
+	options := []rdcom.Option{
+		rdcom.WithMinTLSVersion(t.MinTLSVersion),
+		rdcom.WithConnectTimeout(t.ConnectTimeout),
+		rdcom.WithReadTimeout(t.ReadTimeout),
+		rdcom.WithTimeout(t.Timeout),
+		rdcom.WithRetries(t.Retries, 500*time.Millisecond, 10*time.Second),
+	}
+	if t.Proxy != nil && *t.Proxy != "" {
+		options = append(options, rdcom.WithProxy(*t.Proxy))
+	}
+	if len(t.CABundles) > 0 {
+		options = append(options, rdcom.WithCABundle(t.CABundles...))
+	}
+	if t.ClientCert != nil || t.ClientKey != nil {
+		certificate, key := "", ""
+		if t.ClientCert != nil {
+			certificate = *t.ClientCert
+		}
+		if t.ClientKey != nil {
+			key = *t.ClientKey
+		}
+		options = append(options, rdcom.WithClientCertificate(certificate, key))
+	}
+	if t.Record != nil {
+		options = append(options, rdcom.WithRecord(*t.Record))
+	}
+	if t.Replay != nil {
+		options = append(options, rdcom.WithReplay(*t.Replay))
+	}
+	return options
+}
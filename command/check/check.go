@@ -0,0 +1,206 @@
+package check
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pointer"
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Check is the command that performs a health check of the RDCom platform
+// and reports it according to the monitoring plugin conventions used by
+// Nagios, Icinga and compatible systems.
+type Check struct {
+	base.TokenCommand
+	// Account is the account to check.
+	Account string `short:"a" long:"account" description:"The account to check." required:"yes" env:"SMS_ACCOUNT"`
+	// LatencyWarning is the API latency above which a warning is raised.
+	LatencyWarning time.Duration `long:"latency-warning" description:"The API latency above which a warning is raised." default:"2s"`
+	// LatencyCritical is the API latency above which a critical error is raised.
+	LatencyCritical time.Duration `long:"latency-critical" description:"The API latency above which a critical error is raised." default:"5s"`
+	// ExpiryWarning is the number of days to token expiry below which a warning is raised.
+	ExpiryWarning int `long:"expiry-warning" description:"The number of days to token expiry below which a warning is raised." default:"14"`
+	// ExpiryCritical is the number of days to token expiry below which a critical error is raised.
+	ExpiryCritical int `long:"expiry-critical" description:"The number of days to token expiry below which a critical error is raised." default:"3"`
+	// CreditWarning is the SMS credit below which a warning is raised.
+	CreditWarning *float64 `long:"credit-warning" description:"The SMS credit below which a warning is raised." env:"SMS_CHECK_CREDIT_WARNING"`
+	// CreditCritical is the SMS credit below which a critical error is raised.
+	CreditCritical *float64 `long:"credit-critical" description:"The SMS credit below which a critical error is raised." env:"SMS_CHECK_CREDIT_CRITICAL"`
+}
+
+// Execute is the real implementation of the check command.
+func (cmd *Check) Execute(args []string) error {
+	slog.Debug("called check command", "account", cmd.Account, "endpoint", cmd.Endpoint)
+
+	report := &Report{
+		Service: "SMS",
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		report.Add("client", Unknown, "invalid configuration: %v", err)
+		return cmd.exit(report)
+	}
+
+	defer client.Close()
+
+	if cmd.checkToken(client, report) {
+		cmd.checkAccount(client, report)
+		cmd.checkGateway(client, report)
+	}
+	return cmd.exit(report)
+}
+
+// checkToken checks the endpoint reachability, the API latency and the
+// validity and expiry of the token; it returns whether the API can be used
+// for further checks.
+func (cmd *Check) checkToken(client *rdcom.Client, report *Report) bool {
+	start := time.Now()
+	tokens, err := client.TokenService.List()
+	latency := time.Since(start)
+	if err != nil {
+		slog.Error("error performing token list API call", "error", err)
+		if rdcom.IsUnauthorized(err) {
+			report.Add("endpoint", OK, "%s reachable", cmd.Endpoint)
+			report.Add("token", Critical, "token rejected: %v", err)
+		} else {
+			report.Add("endpoint", Critical, "%s unreachable: %v", cmd.Endpoint, err)
+		}
+		return false
+	}
+
+	report.Measure(PerfData{
+		Label:    "latency",
+		Value:    latency.Seconds(),
+		UOM:      "s",
+		Warning:  pointer.To(cmd.LatencyWarning.Seconds()),
+		Critical: pointer.To(cmd.LatencyCritical.Seconds()),
+		Min:      pointer.To(0.0),
+	})
+	switch {
+	case latency >= cmd.LatencyCritical:
+		report.Add("endpoint", Critical, "API latency %s above %s", latency.Round(time.Millisecond), cmd.LatencyCritical)
+	case latency >= cmd.LatencyWarning:
+		report.Add("endpoint", Warning, "API latency %s above %s", latency.Round(time.Millisecond), cmd.LatencyWarning)
+	default:
+		report.Add("endpoint", OK, "%s reachable in %s", cmd.Endpoint, latency.Round(time.Millisecond))
+	}
+
+	for _, token := range tokens {
+		if cmd.Token == nil || token.Token != *cmd.Token {
+			continue
+		}
+		if token.ExpiryDate.IsZero() {
+			report.Add("token", OK, "token valid, no expiration")
+			return true
+		}
+		days := int(math.Floor(time.Until(token.ExpiryDate).Hours() / 24))
+		report.Measure(PerfData{
+			Label:        "token_expiry_days",
+			Value:        float64(days),
+			Warning:      pointer.To(float64(cmd.ExpiryWarning)),
+			Critical:     pointer.To(float64(cmd.ExpiryCritical)),
+			LowerIsWorse: true,
+		})
+		switch {
+		case days < cmd.ExpiryCritical:
+			report.Add("token", Critical, "token expires in %d days", days)
+		case days < cmd.ExpiryWarning:
+			report.Add("token", Warning, "token expires in %d days", days)
+		default:
+			report.Add("token", OK, "token valid, expires in %d days", days)
+		}
+		return true
+	}
+	report.Add("token", Unknown, "token accepted but not found among the account tokens")
+	return true
+}
+
+// checkAccount checks the account state and SMS credit.
+func (cmd *Check) checkAccount(client *rdcom.Client, report *Report) {
+	accounts, err := client.AccountService.List()
+	if err != nil {
+		slog.Error("error performing account list API call", "error", err)
+		report.Add("account", Unknown, "cannot retrieve accounts: %v", err)
+		return
+	}
+
+	for _, account := range accounts {
+		if account.Code != cmd.Account {
+			continue
+		}
+		switch {
+		case !account.Enabled:
+			report.Add("account", Critical, "account %s disabled", account.Code)
+		case account.SuspensionState != 0:
+			report.Add("account", Critical, "account %s suspended (state %d)", account.Code, account.SuspensionState)
+		default:
+			report.Add("account", OK, "account %s enabled", account.Code)
+		}
+
+		if account.EnableSmsUnlimitedCredit {
+			report.Add("credit", OK, "unlimited SMS credit")
+			return
+		}
+		report.Measure(PerfData{
+			Label:        "credit",
+			Value:        account.SmsCredits,
+			Warning:      cmd.CreditWarning,
+			Critical:     cmd.CreditCritical,
+			Min:          pointer.To(0.0),
+			LowerIsWorse: true,
+		})
+		switch {
+		case cmd.CreditCritical != nil && account.SmsCredits < *cmd.CreditCritical:
+			report.Add("credit", Critical, "SMS credit %.2f below %.2f", account.SmsCredits, *cmd.CreditCritical)
+		case cmd.CreditWarning != nil && account.SmsCredits < *cmd.CreditWarning:
+			report.Add("credit", Warning, "SMS credit %.2f below %.2f", account.SmsCredits, *cmd.CreditWarning)
+		default:
+			report.Add("credit", OK, "SMS credit %.2f", account.SmsCredits)
+		}
+		return
+	}
+	report.Add("account", Unknown, "account %s not found", cmd.Account)
+}
+
+// checkGateway checks that the account's default SMS gateway is ready to send.
+func (cmd *Check) checkGateway(client *rdcom.Client, report *Report) {
+	gateways, err := client.SMSGatewayService.List(cmd.Account)
+	if err != nil {
+		slog.Error("error performing SMS gateway list API call", "error", err)
+		report.Add("gateway", Unknown, "cannot retrieve SMS gateways: %v", err)
+		return
+	}
+	for _, gateway := range gateways {
+		if !gateway.IsDefault {
+			continue
+		}
+		if !gateway.SenderReady {
+			report.Add("gateway", Critical, "default SMS gateway %d not sender ready", gateway.ID)
+		} else {
+			report.Add("gateway", OK, "default SMS gateway %d sender ready", gateway.ID)
+		}
+		return
+	}
+	report.Add("gateway", Critical, "no default SMS gateway")
+}
+
+// exit prints the report and returns an error carrying the plugin exit
+// code, unless all checks passed.
+func (cmd *Check) exit(report *Report) error {
+	report.Write(os.Stdout)
+	status := report.Status()
+	if status == OK {
+		return nil
+	}
+	return &base.ExitError{
+		Code: int(status),
+		Err:  fmt.Errorf("check %s", status),
+	}
+}
@@ -0,0 +1,155 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Status is the outcome of a monitoring plugin check; its value is the
+// plugin exit code.
+type Status int
+
+// List of monitoring plugin statuses.
+const (
+	OK       Status = 0
+	Warning  Status = 1
+	Critical Status = 2
+	Unknown  Status = 3
+)
+
+// String returns the plugin output label of the status.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// severity ranks statuses so that the overall outcome is the most severe:
+// CRITICAL beats WARNING, which beats UNKNOWN, which beats OK.
+func (s Status) severity() int {
+	switch s {
+	case OK:
+		return 0
+	case Unknown:
+		return 1
+	case Warning:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	// Name is the name of the check.
+	Name string
+	// Status is the outcome of the check.
+	Status Status
+	// Message is a human readable description of the outcome.
+	Message string
+}
+
+// PerfData is a performance data item, as per the monitoring plugin
+// development guidelines: 'label'=value[UOM];[warn];[crit];[min];[max].
+type PerfData struct {
+	Label    string
+	Value    float64
+	UOM      string
+	Warning  *float64
+	Critical *float64
+	Min      *float64
+	Max      *float64
+	// LowerIsWorse is set when the value is bad when it falls below the
+	// thresholds rather than when it rises above them; the thresholds are
+	// then written as "N:" ranges, which alert when the value is below N.
+	LowerIsWorse bool
+}
+
+// String returns the perfdata representation of the item.
+func (p PerfData) String() string {
+	optional := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%g", *v)
+	}
+	threshold := func(v *float64) string {
+		if v == nil || !p.LowerIsWorse {
+			return optional(v)
+		}
+		return fmt.Sprintf("%g:", *v)
+	}
+	return fmt.Sprintf("'%s'=%g%s;%s;%s;%s;%s", p.Label, p.Value, p.UOM, threshold(p.Warning), threshold(p.Critical), optional(p.Min), optional(p.Max))
+}
+
+// Report collects the results of all checks and the performance data.
+type Report struct {
+	// Service is the service name printed at the beginning of the output.
+	Service string
+	// Results is the list of check results.
+	Results []Result
+	// PerfData is the list of performance data items.
+	PerfData []PerfData
+}
+
+// Add appends a check result to the report.
+func (r *Report) Add(name string, status Status, format string, args ...any) {
+	r.Results = append(r.Results, Result{
+		Name:    name,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Measure appends a performance data item to the report.
+func (r *Report) Measure(item PerfData) {
+	r.PerfData = append(r.PerfData, item)
+}
+
+// Status returns the overall status of the report.
+func (r *Report) Status() Status {
+	status := OK
+	for _, result := range r.Results {
+		if result.Status.severity() > status.severity() {
+			status = result.Status
+		}
+	}
+	return status
+}
+
+// Write prints the report in monitoring plugin format: a status line with
+// the messages of the failed checks (or a summary, if all checks passed)
+// and the performance data, followed by one line per check.
+func (r *Report) Write(writer io.Writer) {
+	status := r.Status()
+	messages := []string{}
+	for _, result := range r.Results {
+		if result.Status == status && status != OK {
+			messages = append(messages, result.Message)
+		}
+	}
+	if len(messages) == 0 {
+		messages = append(messages, fmt.Sprintf("%d checks passed", len(r.Results)))
+	}
+	perfdata := []string{}
+	for _, item := range r.PerfData {
+		perfdata = append(perfdata, item.String())
+	}
+
+	fmt.Fprintf(writer, "%s %s - %s", r.Service, status, strings.Join(messages, ", "))
+	if len(perfdata) > 0 {
+		fmt.Fprintf(writer, " | %s", strings.Join(perfdata, " "))
+	}
+	fmt.Fprintln(writer)
+	for _, result := range r.Results {
+		fmt.Fprintf(writer, "[%s] %s: %s\n", result.Status, result.Name, result.Message)
+	}
+}
@@ -0,0 +1,49 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/dihedron/sms/pointer"
+)
+
+func TestPerfDataString(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     PerfData
+		expected string
+	}{
+		{
+			"value only",
+			PerfData{Label: "credit", Value: 12.5},
+			"'credit'=12.5;;;;",
+		},
+		{
+			"higher is worse",
+			PerfData{Label: "latency", Value: 0.25, UOM: "s", Warning: pointer.To(2.0), Critical: pointer.To(5.0), Min: pointer.To(0.0)},
+			"'latency'=0.25s;2;5;0;",
+		},
+		{
+			"lower is worse",
+			PerfData{Label: "token_expiry_days", Value: 30, Warning: pointer.To(14.0), Critical: pointer.To(3.0), LowerIsWorse: true},
+			"'token_expiry_days'=30;14:;3:;;",
+		},
+		{
+			"lower is worse, no thresholds",
+			PerfData{Label: "credit", Value: 100, Min: pointer.To(0.0), LowerIsWorse: true},
+			"'credit'=100;;;0;",
+		},
+		{
+			"lower is worse, warning only",
+			PerfData{Label: "credit", Value: 100, Warning: pointer.To(50.0), Min: pointer.To(0.0), Max: pointer.To(1000.0), LowerIsWorse: true},
+			"'credit'=100;50:;;0;1000",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.item.String(); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package credit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Credit is the command that shows the SMS credit balance of one or more
+// accounts, optionally watching it and firing alerts when it crosses a
+// threshold.
+type Credit struct {
+	base.TokenCommand
+	// Accounts is the (optional) list of accounts to show, by code or name.
+	Accounts []string `short:"a" long:"account" description:"The code or name of an account to show (default: all); can be repeated."`
+	// Watch enables polling the balance at regular intervals.
+	Watch bool `short:"w" long:"watch" description:"Whether to keep polling the balance at regular intervals." optional:"yes"`
+	// Interval is the polling interval in watch mode.
+	Interval time.Duration `short:"i" long:"interval" description:"The polling interval in watch mode." env:"SMS_CREDIT_INTERVAL" default:"5m"`
+	// Threshold is the (optional) balance below which alerts are fired.
+	Threshold *float64 `short:"l" long:"threshold" description:"The balance below which alerts are fired." env:"SMS_CREDIT_THRESHOLD"`
+	// Actions is the list of actions to perform when the threshold is crossed.
+	//lint:ignore SA5008 flags can have multiple choices
+	Actions []string `short:"A" long:"action" description:"The action to perform when the balance crosses the threshold; can be repeated." choice:"exit" choice:"log" choice:"exec" choice:"webhook"`
+	// Exec is the command to run when the exec action fires.
+	Exec *string `short:"x" long:"exec" description:"The command to run with the exec action; the alert is passed in SMS_CREDIT_* environment variables." env:"SMS_CREDIT_EXEC"`
+	// Webhook is the URL to POST to when the webhook action fires.
+	Webhook *string `short:"k" long:"webhook" description:"The URL to POST the alert to with the webhook action." env:"SMS_CREDIT_WEBHOOK"`
+}
+
+// ErrBelowThreshold is returned by the exit action when an account's
+// balance drops below the threshold.
+var ErrBelowThreshold = errors.New("SMS credit below threshold")
+
+// Execute is the real implementation of the credit command.
+func (cmd *Credit) Execute(args []string) error {
+	slog.Debug("called credit command", "accounts", cmd.Accounts, "watch", cmd.Watch)
+
+	if len(cmd.Actions) > 0 && cmd.Threshold == nil {
+		slog.Error("actions require a threshold")
+		return errors.New("alert actions require a threshold")
+	}
+	if slices.Contains(cmd.Actions, "exec") && cmd.Exec == nil {
+		slog.Error("exec action requires a command")
+		return errors.New("the exec action requires a command")
+	}
+	if slices.Contains(cmd.Actions, "webhook") && cmd.Webhook == nil {
+		slog.Error("webhook action requires a URL")
+		return errors.New("the webhook action requires a URL")
+	}
+	if cmd.Watch && cmd.Interval <= 0 {
+		slog.Error("invalid polling interval", "interval", cmd.Interval)
+		return fmt.Errorf("invalid polling interval: %s", cmd.Interval)
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	monitor := &Monitor{
+		Threshold: cmd.Threshold,
+		Actions:   cmd.Actions,
+		Exec:      cmd.Exec,
+		Webhook:   cmd.Webhook,
+		states:    map[string]State{},
+	}
+
+	if !cmd.Watch {
+		return cmd.poll(client, monitor)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(cmd.Interval)
+	defer ticker.Stop()
+	for {
+		if err := cmd.poll(client, monitor); err != nil {
+			if errors.Is(err, ErrBelowThreshold) {
+				return err
+			}
+			// transient API errors must not stop the watch
+			slog.Warn("error polling SMS credit", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			slog.Debug("watch interrupted")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll retrieves the accounts, prints their balances and feeds them to the
+// monitor.
+func (cmd *Credit) poll(client *rdcom.Client, monitor *Monitor) error {
+	accounts, err := client.AccountService.List()
+	if err != nil {
+		slog.Error("error performing account list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	found := map[string]bool{}
+	var result error
+	for _, account := range accounts {
+		if len(cmd.Accounts) > 0 && !slices.Contains(cmd.Accounts, account.Code) && !slices.Contains(cmd.Accounts, account.Name) {
+			continue
+		}
+		found[account.Code] = true
+		found[account.Name] = true
+
+		state, crossed := monitor.Check(&account)
+		balance := fmt.Sprintf("%.2f", account.SmsCredits)
+		switch state {
+		case Unlimited:
+			balance = color.YellowString("unlimited")
+		case Below:
+			balance = color.RedString(balance)
+		case Above:
+			balance = color.GreenString(balance)
+		default:
+			balance = color.YellowString(balance)
+		}
+		fmt.Printf("%s account: %s (%s), credit: %s\n", time.Now().Format(time.RFC3339), color.YellowString(account.Name), account.Code, balance)
+
+		if crossed {
+			if err := monitor.Fire(&account, state); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+
+	for _, account := range cmd.Accounts {
+		if !found[account] {
+			slog.Warn("account not found", "account", account)
+			fmt.Printf("account: %s (%s)\n", color.YellowString(account), color.RedString("not found"))
+		}
+	}
+	return result
+}
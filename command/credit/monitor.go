@@ -0,0 +1,159 @@
+package credit
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+	"resty.dev/v3"
+)
+
+// State is the state of an account's balance with respect to the threshold.
+type State string
+
+const (
+	// Unknown means that no threshold is set.
+	Unknown State = "unknown"
+	// Unlimited means that the account has unlimited SMS credit.
+	Unlimited State = "unlimited"
+	// Above means that the balance is at or above the threshold.
+	Above State = "above"
+	// Below means that the balance is below the threshold.
+	Below State = "below"
+)
+
+// Alert is the payload sent to webhooks when the balance of an account
+// crosses the threshold.
+type Alert struct {
+	Account   string    `json:"account"`
+	Name      string    `json:"name"`
+	Balance   float64   `json:"balance"`
+	Threshold float64   `json:"threshold"`
+	State     State     `json:"state"`
+	Time      time.Time `json:"time"`
+}
+
+// Monitor keeps track of the state of each account's balance and fires the
+// configured actions whenever it crosses the threshold; the first check of
+// an account counts as a crossing only when it finds the balance below the
+// threshold.
+type Monitor struct {
+	// Threshold is the balance below which alerts are fired.
+	Threshold *float64
+	// Actions is the list of actions to perform on crossings.
+	Actions []string
+	// Exec is the command run by the exec action.
+	Exec *string
+	// Webhook is the URL the webhook action POSTs to.
+	Webhook *string
+	// states holds the last known state of each account, by code.
+	states map[string]State
+}
+
+// Check computes the current state of the account's balance and reports
+// whether it crossed the threshold since the previous check.
+func (m *Monitor) Check(account *rdcom.Account) (State, bool) {
+	state := Unknown
+	switch {
+	case account.EnableSmsUnlimitedCredit:
+		state = Unlimited
+	case m.Threshold == nil:
+		state = Unknown
+	case account.SmsCredits < *m.Threshold:
+		state = Below
+	default:
+		state = Above
+	}
+
+	previous, ok := m.states[account.Code]
+	m.states[account.Code] = state
+	if !ok {
+		return state, state == Below
+	}
+	return state, previous != state && (state == Below || (previous == Below && state == Above))
+}
+
+// Fire performs the configured actions for the given account and state;
+// all actions are attempted, and the first error (if any) is returned.
+func (m *Monitor) Fire(account *rdcom.Account, state State) error {
+	alert := &Alert{
+		Account:   account.Code,
+		Name:      account.Name,
+		Balance:   account.SmsCredits,
+		Threshold: *m.Threshold,
+		State:     state,
+		Time:      time.Now(),
+	}
+
+	var result error
+	if slices.Contains(m.Actions, "log") {
+		// the application logger is silenced by default, so alerts get
+		// their own logger that always writes to STDERR
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		if state == Below {
+			logger.Warn("SMS credit below threshold", "account", alert.Account, "name", alert.Name, "balance", alert.Balance, "threshold", alert.Threshold)
+		} else {
+			logger.Info("SMS credit back above threshold", "account", alert.Account, "name", alert.Name, "balance", alert.Balance, "threshold", alert.Threshold)
+		}
+	}
+	if slices.Contains(m.Actions, "exec") {
+		if err := m.exec(alert); err != nil && result == nil {
+			result = err
+		}
+	}
+	if slices.Contains(m.Actions, "webhook") {
+		if err := m.post(alert); err != nil && result == nil {
+			result = err
+		}
+	}
+	if slices.Contains(m.Actions, "exit") && state == Below && result == nil {
+		result = fmt.Errorf("%w: account %s has %.2f credits (threshold %.2f)", ErrBelowThreshold, alert.Account, alert.Balance, alert.Threshold)
+	}
+	return result
+}
+
+// exec runs the configured command through the shell, passing the alert
+// details in the environment.
+func (m *Monitor) exec(alert *Alert) error {
+	command := exec.Command("/bin/sh", "-c", *m.Exec)
+	command.Env = append(os.Environ(),
+		fmt.Sprintf("SMS_CREDIT_ACCOUNT=%s", alert.Account),
+		fmt.Sprintf("SMS_CREDIT_NAME=%s", alert.Name),
+		fmt.Sprintf("SMS_CREDIT_BALANCE=%.2f", alert.Balance),
+		fmt.Sprintf("SMS_CREDIT_THRESHOLD=%.2f", alert.Threshold),
+		fmt.Sprintf("SMS_CREDIT_STATE=%s", alert.State),
+	)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		slog.Error("error running alert command", "command", *m.Exec, "error", err)
+		return fmt.Errorf("error running alert command: %w", err)
+	}
+	slog.Debug("alert command run", "command", *m.Exec)
+	return nil
+}
+
+// post sends the alert as a JSON document to the configured webhook.
+func (m *Monitor) post(alert *Alert) error {
+	client := resty.New().SetTimeout(30 * time.Second)
+	defer client.Close()
+
+	response, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(alert).
+		Post(*m.Webhook)
+	if err != nil {
+		slog.Error("error posting alert to webhook", "url", *m.Webhook, "error", err)
+		return fmt.Errorf("error posting alert to webhook: %w", err)
+	}
+	if response.IsError() {
+		slog.Error("webhook request failed", "url", *m.Webhook, "status", response.StatusCode())
+		return fmt.Errorf("webhook error: %d (%s)", response.StatusCode(), response.Status())
+	}
+	slog.Debug("alert posted to webhook", "url", *m.Webhook)
+	return nil
+}
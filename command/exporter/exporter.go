@@ -0,0 +1,262 @@
+package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Exporter is the command that serves the state of the RDCom accounts,
+// tokens and SMS gateways as Prometheus metrics.
+type Exporter struct {
+	base.TokenCommand
+	// Address is the address to listen on.
+	Address string `short:"l" long:"listen" description:"The address to listen on for metrics scrapes." env:"SMS_EXPORTER_LISTEN" default:":9876"`
+	// Path is the HTTP path metrics are served on.
+	Path string `long:"path" description:"The HTTP path to serve metrics on." env:"SMS_EXPORTER_PATH" default:"/metrics"`
+	// Interval is the interval between two collections.
+	Interval time.Duration `short:"i" long:"interval" description:"The interval between two collections of the platform state." env:"SMS_EXPORTER_INTERVAL" default:"1m"`
+	// Accounts is the (optional) list of accounts whose SMS gateways to collect.
+	Accounts []string `short:"a" long:"account" description:"The code of an account whose SMS gateways to collect (default: all); can be repeated."`
+}
+
+// Execute is the real implementation of the exporter command.
+func (cmd *Exporter) Execute(args []string) error {
+	slog.Debug("called exporter command", "address", cmd.Address, "interval", cmd.Interval)
+
+	if cmd.Interval <= 0 {
+		slog.Error("invalid collection interval", "interval", cmd.Interval)
+		return fmt.Errorf("invalid collection interval: %s", cmd.Interval)
+	}
+
+	statistics := rdcom.NewStatistics()
+
+	client, err := cmd.NewClient(rdcom.WithStatistics(statistics))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	collector := &Collector{
+		client:     client,
+		statistics: statistics,
+		accounts:   cmd.Accounts,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		ticker := time.NewTicker(cmd.Interval)
+		defer ticker.Stop()
+		for {
+			collector.Collect()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(cmd.Path, collector)
+	server := &http.Server{
+		Addr:              cmd.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	slog.Info("serving metrics", "address", cmd.Address, "path", cmd.Path)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("error serving metrics", "error", err)
+		return err
+	}
+	slog.Debug("exporter stopped")
+	return nil
+}
+
+// Collector periodically collects the platform state and serves it along
+// with the API call statistics.
+type Collector struct {
+	client     *rdcom.Client
+	statistics *rdcom.Statistics
+	accounts   []string
+
+	lock       sync.RWMutex
+	families   []*Family
+	errors     float64
+	duration   time.Duration
+	lastUpdate time.Time
+}
+
+// Collect retrieves accounts, tokens and SMS gateways and converts them
+// into metric families; failures are logged and counted, and the metrics
+// that could be collected are retained.
+func (c *Collector) Collect() {
+	start := time.Now()
+	failures := 0
+
+	credit := &Family{Name: "sms_account_credit", Help: "Remaining SMS credit of the account.", Type: "gauge"}
+	unlimited := &Family{Name: "sms_account_unlimited_credit", Help: "Whether the account has unlimited SMS credit.", Type: "gauge"}
+	enabled := &Family{Name: "sms_account_enabled", Help: "Whether the account is enabled.", Type: "gauge"}
+	suspension := &Family{Name: "sms_account_suspension_state", Help: "Suspension state of the account (0 if not suspended).", Type: "gauge"}
+	expiration := &Family{Name: "sms_account_expiration_timestamp_seconds", Help: "Expiration time of the account, as a UNIX timestamp.", Type: "gauge"}
+	limits := &Family{Name: "sms_account_limit", Help: "Limits applied to the account.", Type: "gauge"}
+	tokens := &Family{Name: "sms_token_expiry_timestamp_seconds", Help: "Expiry time of the API token, as a UNIX timestamp; tokens are identified by a fingerprint.", Type: "gauge"}
+	ready := &Family{Name: "sms_gateway_ready", Help: "Whether the SMS gateway is ready for the given capability.", Type: "gauge"}
+	isDefault := &Family{Name: "sms_gateway_default", Help: "Whether the SMS gateway is the account default.", Type: "gauge"}
+	prices := &Family{Name: "sms_gateway_price", Help: "Price of a single SMS segment sent through the gateway to the given destination.", Type: "gauge"}
+
+	accounts, err := c.client.AccountService.List()
+	if err != nil {
+		slog.Error("error collecting accounts", "error", err)
+		failures++
+	}
+	for _, account := range accounts {
+		labels := Labels{"account": account.Code, "name": account.Name}
+		credit.Add(labels, account.SmsCredits)
+		unlimited.Add(labels, boolValue(account.EnableSmsUnlimitedCredit))
+		enabled.Add(labels, boolValue(account.Enabled))
+		suspension.Add(labels, float64(account.SuspensionState))
+		if !account.ExpirationDate.IsZero() {
+			expiration.Add(labels, float64(account.ExpirationDate.Unix()))
+		}
+		for limit, value := range map[string]int{
+			"max_recipients_per_day":   account.Limits.MaxRecipientsPerDay,
+			"max_recipients_per_month": account.Limits.MaxRecipientsPerMonth,
+			"max_recipients_per_year":  account.Limits.MaxRecipientsPerYear,
+			"max_lists":                account.Limits.MaxLists,
+		} {
+			limits.Add(Labels{"account": account.Code, "limit": limit}, float64(value))
+		}
+	}
+
+	list, err := c.client.TokenService.List()
+	if err != nil {
+		slog.Error("error collecting tokens", "error", err)
+		failures++
+	}
+	for _, token := range list {
+		if !token.ExpiryDate.IsZero() {
+			tokens.Add(Labels{"token": fingerprint(token.Token)}, float64(token.ExpiryDate.Unix()))
+		}
+	}
+
+	codes := c.accounts
+	if len(codes) == 0 {
+		for _, account := range accounts {
+			codes = append(codes, account.Code)
+		}
+	}
+	for _, code := range codes {
+		gateways, err := c.client.SMSGatewayService.List(code)
+		if err != nil {
+			slog.Error("error collecting SMS gateways", "account", code, "error", err)
+			failures++
+			continue
+		}
+		for _, gateway := range gateways {
+			id := strconv.Itoa(gateway.ID)
+			for capability, value := range map[string]bool{
+				"sender": gateway.SenderReady,
+				"twoway": gateway.TwowayReady,
+				"mo":     gateway.MoReady,
+				"rcs":    gateway.RcsReady,
+			} {
+				ready.Add(Labels{"account": code, "gateway": id, "type": gateway.GatewayType, "capability": capability}, boolValue(value))
+			}
+			isDefault.Add(Labels{"account": code, "gateway": id, "type": gateway.GatewayType}, boolValue(gateway.IsDefault))
+			for destination, price := range gateway.Prices {
+				prices.Add(Labels{"account": code, "gateway": id, "destination": destination}, price)
+			}
+		}
+	}
+
+	families := []*Family{credit, unlimited, enabled, suspension, expiration, limits, tokens, ready, isDefault, prices}
+	for _, family := range families {
+		// map iteration order is random: keep the output stable
+		sort.SliceStable(family.Samples, func(i, j int) bool {
+			return family.Samples[i].Labels.String() < family.Samples[j].Labels.String()
+		})
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.families = families
+	c.errors += float64(failures)
+	c.duration = time.Since(start)
+	c.lastUpdate = time.Now()
+	slog.Debug("platform state collected", "duration", c.duration, "failures", failures)
+}
+
+// ServeHTTP writes the metrics in Prometheus text exposition format.
+func (c *Collector) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	c.lock.RLock()
+	for _, family := range c.families {
+		family.Write(writer)
+	}
+	collection := []*Family{
+		{Name: "sms_exporter_collect_errors_total", Help: "Number of failed collections of platform state.", Type: "counter", Samples: []Sample{{Value: c.errors}}},
+		{Name: "sms_exporter_collect_duration_seconds", Help: "Duration of the last collection of platform state.", Type: "gauge", Samples: []Sample{{Value: c.duration.Seconds()}}},
+	}
+	if !c.lastUpdate.IsZero() {
+		collection = append(collection, &Family{Name: "sms_exporter_last_collect_timestamp_seconds", Help: "Time of the last collection of platform state, as a UNIX timestamp.", Type: "gauge", Samples: []Sample{{Value: float64(c.lastUpdate.Unix())}}})
+	}
+	c.lock.RUnlock()
+	for _, family := range collection {
+		family.Write(writer)
+	}
+
+	requests := &Family{Name: "sms_api_requests_total", Help: "Number of RDCom API requests.", Type: "counter"}
+	failures := &Family{Name: "sms_api_errors_total", Help: "Number of failed RDCom API requests.", Type: "counter"}
+	latency := &Family{Name: "sms_api_request_duration_seconds", Help: "Latency of RDCom API requests.", Type: "histogram"}
+	snapshot := c.statistics.Snapshot()
+	for _, call := range snapshot.Calls() {
+		statistics := snapshot[call]
+		labels := Labels{"method": call.Method, "path": call.Path}
+		requests.Add(labels, float64(statistics.Count))
+		failures.Add(labels, float64(statistics.Errors))
+		for i, bound := range rdcom.DefaultLatencyBuckets {
+			latency.Samples = append(latency.Samples, Sample{Suffix: "_bucket", Labels: Labels{"method": call.Method, "path": call.Path, "le": formatValue(bound)}, Value: float64(statistics.Buckets[i])})
+		}
+		latency.Samples = append(latency.Samples,
+			Sample{Suffix: "_bucket", Labels: Labels{"method": call.Method, "path": call.Path, "le": "+Inf"}, Value: float64(statistics.Count)},
+			Sample{Suffix: "_sum", Labels: labels, Value: statistics.Latency.Seconds()},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(statistics.Count)},
+		)
+	}
+	for _, family := range []*Family{requests, failures, latency} {
+		family.Write(writer)
+	}
+}
+
+// fingerprint returns a short, non-reversible identifier of a token, so
+// that secrets never end up in metric labels.
+func fingerprint(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])[:12]
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Labels is a set of metric labels.
+type Labels map[string]string
+
+// String returns the labels in Prometheus text format, sorted by name.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(l[name])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Sample is a single metric value.
+type Sample struct {
+	// Suffix is appended to the family name (e.g. "_bucket").
+	Suffix string
+	Labels Labels
+	Value  float64
+}
+
+// Family is a group of samples sharing name, help text and type.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Add appends a sample to the family.
+func (f *Family) Add(labels Labels, value float64) {
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+// Write outputs the family in Prometheus text exposition format; families
+// without samples are skipped.
+func (f *Family) Write(writer io.Writer) {
+	if len(f.Samples) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "# HELP %s %s\n", f.Name, f.Help)
+	fmt.Fprintf(writer, "# TYPE %s %s\n", f.Name, f.Type)
+	for _, sample := range f.Samples {
+		fmt.Fprintf(writer, "%s%s%s %s\n", f.Name, sample.Suffix, sample.Labels, formatValue(sample.Value))
+	}
+}
+
+// formatValue formats a sample value as per the exposition format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, +1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// boolValue converts a boolean into a gauge value.
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
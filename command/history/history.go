@@ -0,0 +1,145 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/audit"
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/fatih/color"
+)
+
+// History is the command that queries the local audit log of the mutating
+// API calls (messages sent, tokens created and deleted, etc.).
+type History struct {
+	base.Audit
+	base.Prefix
+	// Since is the (optional) start of the time range.
+	Since *string `short:"s" long:"since" description:"Only show calls performed since the given time (RFC3339, YYYY-MM-DD, YYYY-MM-DD HH:MM or HH:MM)."`
+	// Until is the (optional) end of the time range.
+	Until *string `short:"u" long:"until" description:"Only show calls performed until the given time (RFC3339, YYYY-MM-DD, YYYY-MM-DD HH:MM or HH:MM)."`
+	// Recipient is the (optional) recipient to filter by.
+	Recipient *string `short:"r" long:"recipient" description:"Only show calls involving the given recipient (also when recorded as a hash)."`
+	// Status is the (optional) outcome to filter by.
+	Status *string `short:"S" long:"status" description:"Only show calls with the given outcome." choice:"ok" choice:"failed"`
+	// Operation is the (optional) operation to filter by.
+	Operation *string `short:"o" long:"operation" description:"Only show the given operation (e.g. sms.send) or family of operations (e.g. token)."`
+	// Last is the (optional) maximum number of entries to show.
+	Last int `short:"n" long:"last" description:"Only show the last N matching calls (0 for all)." default:"0"`
+	// Verify sets whether to verify the integrity of the whole log.
+	Verify bool `short:"V" long:"verify" description:"Verify the hash chain of the whole audit log before querying it." optional:"yes"`
+}
+
+// Execute is the real implementation of the history command.
+func (cmd *History) Execute(args []string) error {
+	slog.Debug("called history command", "args", args)
+
+	filter, err := cmd.filter()
+	if err != nil {
+		return err
+	}
+
+	log, err := cmd.Audit.Open()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Verify {
+		count, err := log.Verify()
+		if err != nil {
+			fmt.Printf("integrity: %s\n", color.RedString(err.Error()))
+			return err
+		}
+		fmt.Printf("integrity: %s\n", color.GreenString(fmt.Sprintf("verified (%d entries)", count)))
+	}
+
+	matching, err := log.Select(filter)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	if cmd.Last > 0 && len(matching) > cmd.Last {
+		matching = matching[len(matching)-cmd.Last:]
+	}
+	for _, entry := range matching {
+		printEntry(entry)
+	}
+	return nil
+}
+
+// filter builds the audit log filter from the command line.
+func (cmd *History) filter() (*audit.Filter, error) {
+	filter := &audit.Filter{}
+	if cmd.Since != nil {
+		t, err := base.ParseTime(*cmd.Since)
+		if err != nil {
+			slog.Error("invalid start time", "value", *cmd.Since, "error", err)
+			return nil, err
+		}
+		filter.Since = t
+	}
+	if cmd.Until != nil {
+		t, err := base.ParseTime(*cmd.Until)
+		if err != nil {
+			slog.Error("invalid end time", "value", *cmd.Until, "error", err)
+			return nil, err
+		}
+		filter.Until = t
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		slog.Error("invalid time range", "since", filter.Since, "until", filter.Until)
+		return nil, errors.New("the end of the time range is before its start")
+	}
+	if cmd.Recipient != nil {
+		number, err := cmd.Normalise(*cmd.Recipient)
+		if err != nil {
+			slog.Error("invalid recipient", "number", *cmd.Recipient, "error", err)
+			return nil, err
+		}
+		filter.Recipient = number
+	}
+	if cmd.Status != nil {
+		filter.Status = audit.Status(*cmd.Status)
+	}
+	if cmd.Operation != nil {
+		filter.Operation = *cmd.Operation
+	}
+	return filter, nil
+}
+
+// printEntry prints an audit log entry.
+func printEntry(entry *audit.Entry) {
+	fmt.Printf("entry: %s\n", color.YellowString(fmt.Sprintf("%d", entry.Seq)))
+	fmt.Printf(" - time                   : %s\n", color.YellowString(entry.Time.Local().Format(time.DateTime)))
+	fmt.Printf(" - user                   : %s\n", color.YellowString(entry.User))
+	if entry.Profile != "" {
+		fmt.Printf(" - profile                : %s\n", color.YellowString(entry.Profile))
+	}
+	fmt.Printf(" - endpoint               : %s\n", color.YellowString(entry.Endpoint))
+	if entry.Account != "" {
+		fmt.Printf(" - account                : %s\n", color.YellowString(entry.Account))
+	}
+	fmt.Printf(" - operation              : %s\n", color.YellowString(fmt.Sprintf("%s (%s %s)", entry.Operation, entry.Method, entry.Path)))
+	if entry.Subject != "" {
+		fmt.Printf(" - subject                : %s\n", color.YellowString(entry.Subject))
+	}
+	if entry.Reference != "" {
+		fmt.Printf(" - reference              : %s\n", color.YellowString(entry.Reference))
+	}
+	if len(entry.Recipients) > 0 {
+		fmt.Printf(" - recipients             : %s\n", color.YellowString(strings.Join(pii.PhonesOf(entry.Recipients), ", ")))
+	}
+	result := entry.Error
+	if result == "" {
+		result = fmt.Sprintf("HTTP %d", entry.StatusCode)
+	}
+	if entry.Status == audit.OK {
+		fmt.Printf(" - result                 : %s\n", color.GreenString(fmt.Sprintf("%s (%s)", entry.Status, result)))
+	} else {
+		fmt.Printf(" - result                 : %s\n", color.RedString(fmt.Sprintf("%s (%s)", entry.Status, result)))
+	}
+}
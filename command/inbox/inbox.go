@@ -0,0 +1,120 @@
+package inbox
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/phone"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Inbox is the command that shows the inbound messages of an account,
+// grouped into conversation threads by counterpart number.
+type Inbox struct {
+	base.TokenCommand
+	base.Prefix
+	// Account is the account whose inbound messages to show.
+	Account string `short:"a" long:"account" description:"The account whose inbound messages to show." required:"yes" env:"SMS_ACCOUNT"`
+	// Since is how far back to look for messages, unless From is given.
+	Since time.Duration `short:"s" long:"since" description:"How far back to look for messages (ignored if --from is given)." env:"SMS_INBOX_SINCE" default:"168h"`
+	// From is the (optional) start of the time range.
+	From *string `long:"from" description:"The start of the time range (RFC3339, YYYY-MM-DD or YYYY-MM-DD HH:MM)."`
+	// To is the (optional) end of the time range.
+	To *string `long:"to" description:"The end of the time range (RFC3339, YYYY-MM-DD or YYYY-MM-DD HH:MM)."`
+	// Sender is the (optional) number of the sender to filter by.
+	Sender *string `short:"n" long:"sender" description:"Only show messages from the given number."`
+	// Keyword is the (optional) keyword to filter by.
+	Keyword *string `short:"k" long:"keyword" description:"Only show messages containing the given keyword."`
+	// Gateway is the (optional) ID of the SMS gateway to filter by.
+	Gateway *int `short:"g" long:"gateway" description:"Only show messages received on the given SMS gateway."`
+	// Full sets whether to show all messages in each thread.
+	Full bool `short:"l" long:"full" description:"Show all messages in each thread, not only the last one." optional:"yes"`
+}
+
+// Execute is the real implementation of the inbox command; threads are
+// identified by the counterpart number, which is what "reply" expects.
+func (cmd *Inbox) Execute(args []string) error {
+	slog.Debug("called inbox command", "args", args)
+
+	filter, err := cmd.filter()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	messages, err := client.InboundService.List(cmd.Account, filter)
+	if err != nil {
+		slog.Error("error performing inbound message list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	threads := rdcom.Threads(messages)
+	slog.Info("threads", "length", len(threads), "messages", len(messages))
+
+	for _, thread := range threads {
+		fmt.Printf("thread: %s (%s)\n", color.YellowString(pii.PhoneOf(thread.Counterpart)), color.YellowString(fmt.Sprintf("%d message(s)", len(thread.Messages))))
+		fmt.Printf(" - gateway                : %s\n", color.YellowString(fmt.Sprintf("%d", thread.Gateway)))
+		fmt.Printf(" - to                     : %s\n", color.YellowString(pii.PhoneOf(thread.Number)))
+		if country, ok := phone.CountryOf(thread.Counterpart); ok {
+			fmt.Printf(" - country                : %s\n", color.YellowString(country.Name))
+		}
+		shown := thread.Messages
+		if !cmd.Full {
+			shown = shown[len(shown)-1:]
+		}
+		for _, message := range shown {
+			fmt.Printf("   - %s : %s\n", color.YellowString(message.Received.Local().Format(time.DateTime)), pii.TextOf(message.Text))
+		}
+	}
+	return nil
+}
+
+// filter builds the inbound message filter from the command options.
+func (cmd *Inbox) filter() (*rdcom.InboundFilter, error) {
+	filter := &rdcom.InboundFilter{
+		Gateway: cmd.Gateway,
+	}
+	if cmd.From != nil {
+		from, err := base.ParseTime(*cmd.From)
+		if err != nil {
+			slog.Error("invalid start of time range", "value", *cmd.From, "error", err)
+			return nil, err
+		}
+		filter.From = from
+	} else if cmd.Since > 0 {
+		filter.From = time.Now().Add(-cmd.Since)
+	}
+	if cmd.To != nil {
+		to, err := base.ParseTime(*cmd.To)
+		if err != nil {
+			slog.Error("invalid end of time range", "value", *cmd.To, "error", err)
+			return nil, err
+		}
+		filter.To = to
+	}
+	if cmd.Sender != nil {
+		sender, err := cmd.Normalise(*cmd.Sender)
+		if err != nil {
+			slog.Error("invalid sender number", "value", *cmd.Sender, "error", err)
+			return nil, err
+		}
+		filter.Sender = sender
+	}
+	if cmd.Keyword != nil {
+		filter.Keyword = strings.TrimSpace(*cmd.Keyword)
+	}
+	return filter, nil
+}
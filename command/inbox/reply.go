@@ -0,0 +1,102 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Reply is the command that answers a conversation thread, through the
+// same SMS gateway and from the same number the last message was received on.
+type Reply struct {
+	base.TokenCommand
+	base.Prefix
+	base.Message
+	// Account is the account the thread belongs to.
+	Account string `short:"a" long:"account" description:"The account the thread belongs to." required:"yes" env:"SMS_ACCOUNT"`
+	// Since is how far back to look for the thread.
+	Since time.Duration `short:"s" long:"since" description:"How far back to look for the last message in the thread." env:"SMS_INBOX_SINCE" default:"168h"`
+}
+
+// Execute is the real implementation of the reply command; the thread is
+// identified by the counterpart number, as shown by the inbox command.
+func (cmd *Reply) Execute(args []string) error {
+	slog.Debug("called reply command", "args", args)
+
+	if len(args) != 1 {
+		slog.Error("exactly one thread must be provided", "args", args)
+		return errors.New("exactly one thread must be provided")
+	}
+	counterpart, err := cmd.Normalise(args[0])
+	if err != nil {
+		slog.Error("invalid thread", "value", args[0], "error", err)
+		return err
+	}
+
+	text, err := cmd.Message.Load()
+	if err != nil {
+		return err
+	}
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	filter := &rdcom.InboundFilter{
+		Sender: counterpart,
+	}
+	if cmd.Since > 0 {
+		filter.From = time.Now().Add(-cmd.Since)
+	}
+	messages, err := client.InboundService.List(cmd.Account, filter)
+	if err != nil {
+		slog.Error("error performing inbound message list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+	threads := rdcom.Threads(messages)
+	if len(threads) == 0 {
+		slog.Error("thread not found", "counterpart", counterpart, "since", cmd.Since)
+		return fmt.Errorf("no messages from %s in the last %s", counterpart, cmd.Since)
+	}
+	thread := threads[0]
+	last := thread.Last()
+
+	sent, err := client.MessageService.Send(cmd.Account, &rdcom.Message{
+		Gateway:    thread.Gateway,
+		Sender:     thread.Number,
+		Recipients: []string{counterpart},
+		Text:       text,
+		InReplyTo:  last.ID,
+	})
+	if err != nil {
+		slog.Error("error performing message send API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	fmt.Printf("reply: %s\n", color.YellowString(sent.ID))
+	fmt.Printf(" - to                     : %s\n", color.YellowString(pii.PhoneOf(counterpart)))
+	fmt.Printf(" - from                   : %s\n", color.YellowString(pii.PhoneOf(thread.Number)))
+	fmt.Printf(" - gateway                : %s\n", color.YellowString(fmt.Sprintf("%d", thread.Gateway)))
+	fmt.Printf(" - in reply to            : %s\n", color.YellowString(pii.TextOf(last.Text)))
+	if sent.Status != "" {
+		fmt.Printf(" - status                 : %s\n", color.YellowString(sent.Status))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package outbox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+type Outbox struct {
+	// Drain is the command to send the pending messages in the outbox.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Drain Drain `command:"drain" alias:"d" description:"Send the pending messages in the outbox, retrying failures."`
+
+	// List is the command to list the messages in the outbox.
+	//lint:ignore SA5008 commands can have multiple aliases
+	List List `command:"list" alias:"ls" alias:"l" description:"List the messages in the outbox."`
+
+	// Purge is the command to remove delivered and failed messages.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Purge Purge `command:"purge" alias:"p" description:"Remove the messages that are no longer pending from the outbox."`
+}
+
+// printEntry prints a message in the outbox.
+func printEntry(entry *rdcom.OutboxEntry) {
+	fmt.Printf("message: %s\n", color.YellowString(entry.Ref))
+	fmt.Printf(" - status                 : %s\n", coloredStatus(entry.Status))
+	fmt.Printf(" - account                : %s\n", color.YellowString(entry.Account))
+	fmt.Printf(" - gateway                : %s\n", color.YellowString(fmt.Sprintf("%d", entry.Message.Gateway)))
+	fmt.Printf(" - recipients             : %s\n", color.YellowString(strings.Join(pii.PhonesOf(entry.Message.Recipients), ", ")))
+	fmt.Printf(" - attempts               : %s\n", color.YellowString(fmt.Sprintf("%d", entry.Attempts)))
+	if entry.Result != nil && entry.Result.ID != "" {
+		fmt.Printf(" - id                     : %s\n", color.YellowString(entry.Result.ID))
+	}
+	if !entry.Next.IsZero() {
+		fmt.Printf(" - next attempt           : %s\n", color.YellowString(entry.Next.Local().Format(time.DateTime)))
+	}
+	if entry.Error != "" {
+		fmt.Printf(" - last error             : %s\n", color.RedString(entry.Error))
+	}
+	fmt.Printf(" - created                : %s\n", color.YellowString(entry.Created.Local().Format(time.DateTime)))
+}
+
+// coloredStatus returns the status of a message, coloured by outcome.
+func coloredStatus(status rdcom.OutboxStatus) string {
+	switch status {
+	case rdcom.OutboxSent:
+		return color.GreenString(string(status))
+	case rdcom.OutboxFailed:
+		return color.RedString(string(status))
+	default:
+		return color.YellowString(string(status))
+	}
+}
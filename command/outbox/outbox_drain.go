@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Drain is the outbox drain command.
+type Drain struct {
+	base.TokenCommand
+	base.Outbox
+	// Watch sets whether to keep draining until interrupted.
+	Watch bool `short:"w" long:"watch" description:"Keep draining the outbox at every interval, until interrupted." optional:"yes"`
+	// Interval is the interval between two drains, when watching.
+	Interval time.Duration `short:"i" long:"interval" description:"The interval between two drains, when watching." env:"SMS_OUTBOX_INTERVAL" default:"30s"`
+	// MaxAttempts is the maximum number of attempts per message.
+	MaxAttempts int `short:"m" long:"max-attempts" description:"The maximum number of attempts per message, after which it is marked as failed." env:"SMS_OUTBOX_MAX_ATTEMPTS" default:"20"`
+}
+
+// Execute is the real implementation of the outbox drain command.
+func (cmd *Drain) Execute(args []string) error {
+	slog.Debug("called outbox drain command", "watch", cmd.Watch, "interval", cmd.Interval)
+
+	if err := cmd.RefuseDryRun("outbox drain"); err != nil {
+		return err
+	}
+
+	if cmd.Watch && cmd.Interval <= 0 {
+		slog.Error("invalid drain interval", "interval", cmd.Interval)
+		return fmt.Errorf("invalid drain interval: %s", cmd.Interval)
+	}
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	outbox, err := cmd.Outbox.Open(client)
+	if err != nil {
+		return err
+	}
+	outbox.MaxAttempts = cmd.MaxAttempts
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if cmd.Watch {
+		slog.Info("draining outbox", "interval", cmd.Interval)
+		return outbox.Run(ctx, cmd.Interval)
+	}
+
+	sent, err := outbox.Drain(ctx)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	entries, err := outbox.List()
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, entry := range entries {
+		if entry.Status == rdcom.OutboxPending {
+			pending++
+		}
+	}
+	fmt.Printf("sent: %s\n", color.GreenString(fmt.Sprintf("%d", sent)))
+	if pending > 0 {
+		fmt.Printf("pending: %s\n", color.YellowString(fmt.Sprintf("%d", pending)))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// List is the outbox list command.
+type List struct {
+	base.Outbox
+	// All sets whether to list sent, suppressed and failed messages too.
+	All bool `short:"A" long:"all" description:"Also list sent, suppressed and failed messages." optional:"yes"`
+}
+
+// Execute is the real implementation of the outbox list command; messages
+// can be selected by client reference.
+func (cmd *List) Execute(args []string) error {
+	slog.Debug("called outbox list command", "args", args)
+
+	outbox, err := cmd.Outbox.Open(nil)
+	if err != nil {
+		return err
+	}
+	entries, err := outbox.List()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	for _, entry := range entries {
+		if len(args) > 0 && !slices.Contains(args, entry.Ref) {
+			continue
+		}
+		if len(args) == 0 && !cmd.All && entry.Status != rdcom.OutboxPending {
+			continue
+		}
+		printEntry(entry)
+	}
+	return nil
+}
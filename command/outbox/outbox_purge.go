@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/fatih/color"
+)
+
+// Purge is the outbox purge command.
+type Purge struct {
+	base.Outbox
+	// OlderThan is the minimum age of the messages to remove.
+	OlderThan time.Duration `short:"o" long:"older-than" description:"Only remove the messages last updated at least this long ago." default:"0s"`
+}
+
+// Execute is the real implementation of the outbox purge command; pending
+// messages are never removed.
+func (cmd *Purge) Execute(args []string) error {
+	slog.Debug("called outbox purge command", "older than", cmd.OlderThan)
+
+	outbox, err := cmd.Outbox.Open(nil)
+	if err != nil {
+		return err
+	}
+	count, err := outbox.Purge(time.Now().Add(-cmd.OlderThan))
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	fmt.Printf("purged: %s\n", color.GreenString(fmt.Sprintf("%d", count)))
+	return nil
+}
@@ -11,32 +11,15 @@ import (
 
 type Ping struct {
 	base.TokenCommand
-	// Account is the account to use in APIs invocation.
-	// Account string `short:"a" long:"account" description:"The account to use for authentication." required:"yes" env:"SMS_ACCOUNT" cfg:"account"`
+	// Account is the (optional) account whose API access to check as well.
+	Account string `short:"a" long:"account" description:"The account whose API access to check as well." env:"SMS_ACCOUNT" cfg:"account"`
 }
 
 // Execute is the real implementation of the Ping command.
 func (cmd *Ping) Execute(args []string) error {
-	slog.Debug("called ping command", "token", cmd.Token, "endpoint", cmd.Endpoint)
+	slog.Debug("called ping command", "token", cmd.Token, "endpoint", cmd.Endpoint, "account", cmd.Account)
 
-	options := []rdcom.Option{
-		rdcom.WithBaseURL(cmd.Endpoint),
-		rdcom.WithUserAgent("bancaditalia/0.1"),
-	}
-	if cmd.SkipVerifyTLS {
-		options = append(options, rdcom.WithSkipTLSVerify(true))
-	}
-	if cmd.EnableDebug {
-		options = append(options, rdcom.WithDebug())
-	}
-	if cmd.EnableTrace {
-		options = append(options, rdcom.WithTrace())
-	}
-	if cmd.Token != nil {
-		options = append(options, rdcom.WithAuthToken(*cmd.Token))
-	}
-
-	client, err := rdcom.New(options...)
+	client, err := cmd.NewClient(rdcom.WithAccount(cmd.Account))
 	if err != nil {
 		slog.Error("error initialising API client", "error", err)
 		return err
@@ -52,5 +35,16 @@ func (cmd *Ping) Execute(args []string) error {
 
 	slog.Debug("successful token list API call")
 	fmt.Printf("connection: %s\n", color.GreenString("OK"))
+
+	if client.Account() != "" {
+		// an empty account selects the client one
+		if _, err := client.SMSGatewayService.List(""); err != nil {
+			slog.Error("error performing SMS gateway list API call", "account", client.Account(), "error", err)
+			fmt.Printf("account: %s\n", color.RedString("KO"))
+			return fmt.Errorf("error performing API call: %w", err)
+		}
+		slog.Debug("successful SMS gateway list API call", "account", client.Account())
+		fmt.Printf("account: %s\n", color.GreenString("OK"))
+	}
 	return nil
 }
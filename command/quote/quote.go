@@ -0,0 +1,105 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Quote is the command that estimates the cost of sending a message to a
+// set of recipients through the account's SMS gateways.
+type Quote struct {
+	base.TokenCommand
+	base.Recipients
+	base.Message
+	// Account is the account whose SMS gateways to quote.
+	Account string `short:"a" long:"account" description:"The account whose SMS gateways to quote." required:"yes" env:"SMS_ACCOUNT"`
+	// Gateway is the (optional) ID of the only SMS gateway to quote.
+	Gateway *int `short:"g" long:"gateway" description:"The ID of the SMS gateway to quote (default: all)."`
+}
+
+// Execute is the real implementation of the quote command.
+func (cmd *Quote) Execute(args []string) error {
+	slog.Debug("called quote command", "args", args)
+
+	text, err := cmd.Message.Load()
+	if err != nil {
+		return err
+	}
+
+	recipients, invalid, err := cmd.Recipients.Load(args)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		slog.Error("no valid recipients provided")
+		return errors.New("no valid recipients provided")
+	}
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+	// suppressed recipients are never messaged, so they are never charged
+	recipients, suppressed := list.Filter(recipients)
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	gateways, err := client.SMSGatewayService.List(cmd.Account)
+	if err != nil {
+		slog.Error("error performing SMS gateway list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	found := false
+	for _, gateway := range gateways {
+		if cmd.Gateway != nil && gateway.ID != *cmd.Gateway {
+			continue
+		}
+		found = true
+		quotation := rdcom.Quote(&gateway, recipients, text)
+
+		fmt.Printf("gateway: %s\n", color.YellowString(fmt.Sprintf("%d", quotation.Gateway)))
+		fmt.Printf(" - type                   : %s\n", color.YellowString(quotation.GatewayType))
+		fmt.Printf(" - default                : %s\n", color.YellowString(fmt.Sprintf("%t", gateway.IsDefault)))
+		fmt.Printf(" - encoding               : %s\n", color.YellowString(string(quotation.Segmentation.Encoding)))
+		fmt.Printf(" - length                 : %s\n", color.YellowString(fmt.Sprintf("%d", quotation.Segmentation.Units)))
+		fmt.Printf(" - segments per message   : %s\n", color.YellowString(fmt.Sprintf("%d", quotation.Segmentation.Segments)))
+		fmt.Printf(" - countries              :\n")
+		for _, line := range quotation.Lines {
+			fmt.Printf("   - %-20s : %s\n",
+				fmt.Sprintf("%s (%s)", line.Country.Code, line.PriceKey),
+				color.YellowString(fmt.Sprintf("%d recipients x %d segments x %.4f = %.4f", line.Recipients, line.Segments/line.Recipients, line.UnitPrice, line.Cost)),
+			)
+		}
+		if len(quotation.Unpriced) > 0 {
+			fmt.Printf(" - unpriced               : %s\n", color.RedString(strings.Join(quotation.Unpriced, ", ")))
+		}
+		fmt.Printf(" - total                  : %s\n", color.GreenString(fmt.Sprintf("%.4f (%d recipients, %d segments)", quotation.Total, quotation.Recipients, quotation.Segments)))
+	}
+	if len(invalid) > 0 {
+		fmt.Printf("invalid recipients: %s\n", color.RedString(strings.Join(invalid, ", ")))
+	}
+	if len(suppressed) > 0 {
+		fmt.Printf("suppressed recipients: %s\n", color.RedString(strings.Join(pii.PhonesOf(suppressed), ", ")))
+	}
+
+	if cmd.Gateway != nil && !found {
+		slog.Error("SMS gateway not found", "gateway", *cmd.Gateway)
+		return fmt.Errorf("SMS gateway %d not found", *cmd.Gateway)
+	}
+	return nil
+}
@@ -2,14 +2,58 @@ package command
 
 import (
 	"github.com/dihedron/sms/command/account"
+	"github.com/dihedron/sms/command/api"
+	"github.com/dihedron/sms/command/check"
+	"github.com/dihedron/sms/command/credit"
+	"github.com/dihedron/sms/command/exporter"
+	"github.com/dihedron/sms/command/history"
+	"github.com/dihedron/sms/command/inbox"
+	"github.com/dihedron/sms/command/outbox"
 	"github.com/dihedron/sms/command/ping"
+	"github.com/dihedron/sms/command/quote"
+	"github.com/dihedron/sms/command/rules"
+	"github.com/dihedron/sms/command/schedule"
+	"github.com/dihedron/sms/command/send"
+	"github.com/dihedron/sms/command/sender"
+	"github.com/dihedron/sms/command/simulate"
 	smsgateway "github.com/dihedron/sms/command/sms_gateway"
+	"github.com/dihedron/sms/command/suppress"
 	"github.com/dihedron/sms/command/token"
 	"github.com/dihedron/sms/command/version"
 )
 
 // Commands is the set of root command groups.
 type Commands struct {
+	// ShowPII shows phone numbers, message bodies and personal data in clear
+	// in outputs and logs; by default they are masked.
+	ShowPII bool `long:"show-pii" description:"Show phone numbers, message bodies and personal data in clear in outputs and logs." optional:"yes" env:"SMS_SHOW_PII"`
+
+	// API performs raw calls to arbitrary API endpoints.
+	API api.API `command:"api" description:"Call an arbitrary RDCom API endpoint, with the configured authentication and transport: api <METHOD> <path>."`
+
+	// Check performs a health check in monitoring plugin format.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Check check.Check `command:"check" alias:"chk" description:"Check the health of the RDCom platform (Nagios/Icinga plugin format)."`
+
+	// Credit shows the SMS credit balance of accounts.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Credit credit.Credit `command:"credit" alias:"cred" alias:"c" description:"Show (and optionally watch) the SMS credit balance of accounts."`
+
+	// Exporter serves platform metrics in Prometheus format.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Exporter exporter.Exporter `command:"exporter" alias:"exp" alias:"x" description:"Serve the state of accounts, tokens and SMS gateways as Prometheus metrics."`
+
+	// History queries the local audit log.
+	//lint:ignore SA5008 commands can have multiple aliases
+	History history.History `command:"history" alias:"hist" alias:"h" description:"Query the local, tamper-evident audit log of mutating API calls."`
+
+	// Inbox shows inbound messages grouped into conversation threads.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Inbox inbox.Inbox `command:"inbox" alias:"in" alias:"i" description:"Show inbound messages, grouped into conversation threads by counterpart."`
+
+	// Reply answers a conversation thread.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Reply inbox.Reply `command:"reply" alias:"re" description:"Reply to a conversation thread through the same SMS gateway."`
 
 	// Check checks the connectivity to RDCom API.
 	Ping ping.Ping `command:"ping" alias:"p" description:"Try to connect to the RDCom API server."`
@@ -22,6 +66,46 @@ type Commands struct {
 	//lint:ignore SA5008 commands can have multiple aliases
 	SMSGateway smsgateway.SMSGateway `command:"sms_gateway" alias:"smsgw" alias:"gw" alias:"g" description:"SMS gateway-related operations."`
 
+	// Outbox is a subcommand group related to the durable outbox.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Outbox outbox.Outbox `command:"outbox" alias:"out" alias:"o" description:"Durable outbox operations, for messages that must survive network and process failures."`
+
+	// Quote estimates the cost of sending a message.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Quote quote.Quote `command:"quote" alias:"q" description:"Estimate the cost of sending a message through each SMS gateway."`
+
+	// Rules is a subcommand group related to inbound message rules.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Rules rules.Rules `command:"rules" alias:"rule" alias:"r" description:"Inbound message rules operations."`
+
+	// Serve processes inbound messages through the rules engine.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Serve rules.Serve `command:"serve" alias:"srv" description:"Process inbound messages through the rules engine until interrupted."`
+
+	// Schedule is a subcommand group related to deferred sending.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Schedule schedule.Schedule `command:"schedule" alias:"sch" description:"Scheduled (deferred) sending operations."`
+
+	// Scheduler is a subcommand group related to the scheduler daemon.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Scheduler schedule.Scheduler `command:"scheduler" alias:"sched" description:"Scheduler daemon operations."`
+
+	// Send is a subcommand group related to sending messages.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Send send.Send `command:"send" alias:"sd" description:"Send SMS and RCS messages."`
+
+	// Sender is a subcommand group related to sender registration.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Sender sender.Sender `command:"sender" alias:"snd" alias:"s" description:"Alphanumeric sender registration operations."`
+
+	// Simulate runs a local simulator of the RDCom API.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Simulate simulate.Simulate `command:"simulate" alias:"sim" description:"Run a local, stateful simulator of the RDCom API, for development and testing."`
+
+	// Suppress is a subcommand group related to the suppression list.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Suppress suppress.Suppress `command:"suppress" alias:"sup" description:"Suppression (opt-out, do-not-contact) list operations."`
+
 	// Token is a subcommand group related to token management.
 	//lint:ignore SA5008 commands can have multiple aliases
 	Token token.Token `command:"token" alias:"tok" alias:"tk" alias:"t" description:"Token management operations."`
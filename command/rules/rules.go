@@ -0,0 +1,17 @@
+package rules
+
+type Rules struct {
+	// Test is the command to evaluate the rules against a message, offline.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Test Test `command:"test" alias:"t" description:"Evaluate the rules against a message offline, without performing any action."`
+
+	// Validate is the command to check a rules file.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Validate Validate `command:"validate" alias:"val" alias:"v" description:"Check the syntax of a rules file."`
+}
+
+// RulesFile is the flag holding the path of the rules file.
+type RulesFile struct {
+	// Path is the path to the rules file.
+	Path string `short:"r" long:"rules" description:"The path to the YAML rules file." required:"yes" env:"SMS_RULES"`
+}
@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	engine "github.com/dihedron/sms/rules"
+	"github.com/fatih/color"
+)
+
+// Test is the rules test command; it needs no connection to the platform.
+type Test struct {
+	RulesFile
+	base.Prefix
+	// From is the number of the sender of the test message.
+	From string `short:"f" long:"from" description:"The number of the sender of the test message." default:"+390000000000"`
+	// To is the number the test message is addressed to.
+	To string `long:"to" description:"The number the test message is addressed to."`
+	// Gateway is the ID of the SMS gateway the test message is received on.
+	Gateway int `short:"g" long:"gateway" description:"The ID of the SMS gateway the test message is received on."`
+}
+
+// Execute is the real implementation of the rules test command.
+func (cmd *Test) Execute(args []string) error {
+	slog.Debug("called rules test command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no test message provided")
+		return errors.New("no test message provided")
+	}
+
+	from, err := cmd.Normalise(cmd.From)
+	if err != nil {
+		slog.Error("invalid sender number", "value", cmd.From, "error", err)
+		return err
+	}
+
+	config, err := engine.Load(cmd.Path)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	message := &rdcom.InboundMessage{
+		ID:       "test",
+		Gateway:  cmd.Gateway,
+		From:     from,
+		To:       cmd.To,
+		Text:     strings.Join(args, " "),
+		Received: time.Now(),
+	}
+
+	hits := config.Evaluate(message)
+	if len(hits) == 0 {
+		fmt.Printf("message: %s (%s)\n", color.YellowString(message.Text), color.RedString("no matching rules"))
+		return nil
+	}
+	fmt.Printf("message: %s\n", color.YellowString(message.Text))
+	for _, hit := range hits {
+		fmt.Printf(" - rule                   : %s\n", color.YellowString(hit.Rule.Name))
+		for i, group := range hit.Groups {
+			fmt.Printf("   - group %-14d : %s\n", i, color.YellowString(group))
+		}
+		for _, action := range hit.Rule.Actions {
+			fmt.Printf("   - %-20s : %s\n", action.Type, color.YellowString(action.Describe(hit)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package rules
+
+import (
+	"fmt"
+	"log/slog"
+
+	engine "github.com/dihedron/sms/rules"
+	"github.com/fatih/color"
+)
+
+// Validate is the rules validate command.
+type Validate struct {
+	RulesFile
+}
+
+// Execute is the real implementation of the rules validate command.
+func (cmd *Validate) Execute(args []string) error {
+	slog.Debug("called rules validate command", "path", cmd.Path)
+
+	config, err := engine.Load(cmd.Path)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	for _, rule := range config.Rules {
+		final := ""
+		if rule.Final {
+			final = " (final)"
+		}
+		fmt.Printf("rule: %s%s\n", color.YellowString(rule.Name), final)
+		for _, action := range rule.Actions {
+			fmt.Printf(" - action                 : %s\n", color.YellowString(string(action.Type)))
+		}
+	}
+	fmt.Printf("%s: %d rule(s)\n", color.GreenString("valid"), len(config.Rules))
+	return nil
+}
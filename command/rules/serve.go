@@ -0,0 +1,210 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	engine "github.com/dihedron/sms/rules"
+	"github.com/fatih/color"
+)
+
+// Serve is the command that polls the inbound messages of an account and
+// processes them through the rules engine until interrupted.
+type Serve struct {
+	base.TokenCommand
+	RulesFile
+	// Account is the account whose inbound messages to process.
+	Account string `short:"a" long:"account" description:"The account whose inbound messages to process." required:"yes" env:"SMS_ACCOUNT"`
+	// Interval is the interval between two polls.
+	Interval time.Duration `short:"i" long:"interval" description:"The interval between two polls for inbound messages." env:"SMS_SERVE_INTERVAL" default:"30s"`
+	// Since is how far back to look for messages on the first run.
+	Since time.Duration `short:"s" long:"since" description:"How far back to look for messages when there is no saved state." env:"SMS_SERVE_SINCE" default:"0s"`
+	// State is the path to the file where the processing state is saved.
+	State *string `long:"state" description:"The path to the processing state file (default: in the user configuration directory)." env:"SMS_SERVE_STATE"`
+}
+
+// Execute is the real implementation of the serve command.
+func (cmd *Serve) Execute(args []string) error {
+	slog.Debug("called serve command", "account", cmd.Account, "rules", cmd.Path, "interval", cmd.Interval)
+
+	if err := cmd.RefuseDryRun("serve"); err != nil {
+		return err
+	}
+
+	if cmd.Interval <= 0 {
+		slog.Error("invalid poll interval", "interval", cmd.Interval)
+		return fmt.Errorf("invalid poll interval: %s", cmd.Interval)
+	}
+
+	config, err := engine.Load(cmd.Path)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	statePath, err := pathOrDefault(cmd.State, fmt.Sprintf("serve-%s.json", cmd.Account))
+	if err != nil {
+		return err
+	}
+	// opt-outs go to the suppression list, which also guards the replies
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	state, err := loadState(statePath)
+	if err != nil {
+		return err
+	}
+	if state.Last.IsZero() {
+		state.Last = time.Now().Add(-cmd.Since)
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	processor := &engine.Engine{
+		Config:  config,
+		Replier: &replier{client: client, account: cmd.Account},
+		OptOuts: list,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	slog.Info("processing inbound messages", "account", cmd.Account, "rules", len(config.Rules), "since", state.Last)
+	ticker := time.NewTicker(cmd.Interval)
+	defer ticker.Stop()
+	for {
+		cmd.poll(client, processor, state, statePath)
+		select {
+		case <-ctx.Done():
+			slog.Debug("serve stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll retrieves the messages received since the last one processed and
+// runs them through the rules engine, saving the state after each one so
+// that no message is processed twice across restarts.
+func (cmd *Serve) poll(client *rdcom.Client, processor *engine.Engine, state *state, path string) {
+	messages, err := client.InboundService.List(cmd.Account, &rdcom.InboundFilter{From: state.Last})
+	if err != nil {
+		slog.Error("error performing inbound message list API call", "error", err)
+		return
+	}
+	for _, message := range messages {
+		if message.Received.Equal(state.Last) && slices.Contains(state.Seen, message.ID) {
+			continue
+		}
+		hits, err := processor.Process(&message)
+		for _, hit := range hits {
+			fmt.Printf("%s %s from %s: %s\n", time.Now().Format(time.DateTime), color.YellowString(hit.Rule.Name), color.YellowString(pii.PhoneOf(message.From)), pii.TextOf(message.Text))
+		}
+		if err != nil {
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		}
+		state.add(&message)
+		if err := state.save(path); err != nil {
+			slog.Error("error saving state", "path", path, "error", err)
+		}
+	}
+}
+
+// replier sends replies through the SMS gateway, and from the number, the
+// message was received on.
+type replier struct {
+	client  *rdcom.Client
+	account string
+}
+
+// Reply sends the text to the sender of the message; replies to suppressed
+// numbers are skipped.
+func (r *replier) Reply(hit *engine.Hit, text string) error {
+	_, err := r.client.MessageService.Send(r.account, &rdcom.Message{
+		Gateway:    hit.Message.Gateway,
+		Sender:     hit.Message.To,
+		Recipients: []string{hit.Message.From},
+		Text:       text,
+		InReplyTo:  hit.Message.ID,
+	})
+	if errors.Is(err, rdcom.ErrAllSuppressed) {
+		slog.Info("reply to suppressed number skipped", "rule", hit.Rule.Name, "number", hit.Message.From)
+		return nil
+	}
+	return err
+}
+
+// state is the processing state: the time of the last message processed,
+// and the IDs of the messages processed with that same time.
+type state struct {
+	Last time.Time `json:"last"`
+	Seen []string  `json:"seen"`
+}
+
+// add records the message as processed.
+func (s *state) add(message *rdcom.InboundMessage) {
+	if message.Received.After(s.Last) {
+		s.Last = message.Received
+		s.Seen = nil
+	}
+	s.Seen = append(s.Seen, message.ID)
+}
+
+// loadState reads the state file; a missing file yields an empty state.
+func loadState(path string) (*state, error) {
+	s := &state{}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		slog.Error("error reading state file", "path", path, "error", err)
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		slog.Error("error parsing state file", "path", path, "error", err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// save writes the state file atomically.
+func (s *state) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// pathOrDefault returns the given path or, if nil, the default state path
+// with the given name.
+func pathOrDefault(path *string, name string) (string, error) {
+	if path != nil {
+		return *path, nil
+	}
+	return base.StatePath(name)
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/scheduler"
+)
+
+type Schedule struct {
+	// Add is the command to schedule a message.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Add Add `command:"add" alias:"a" description:"Schedule a message for deferred sending."`
+
+	// Cancel is the command to cancel scheduled jobs.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Cancel Cancel `command:"cancel" alias:"del" alias:"c" description:"Cancel scheduled jobs."`
+
+	// List is the command to list scheduled jobs.
+	//lint:ignore SA5008 commands can have multiple aliases
+	List List `command:"list" alias:"ls" alias:"l" description:"List scheduled jobs."`
+}
+
+type Scheduler struct {
+	// Run is the command to run the scheduler daemon.
+	Run Run `command:"run" description:"Dispatch scheduled jobs when due, until interrupted."`
+}
+
+// Queue is the set of flags used by commands that access the job queue.
+type Queue struct {
+	// QueueDir is the path to the job queue directory.
+	QueueDir *string `short:"Q" long:"queue" description:"The path to the job queue directory (default: in the user configuration directory)." env:"SMS_QUEUE"`
+}
+
+// Open opens the job queue.
+func (q *Queue) Open() (*scheduler.Queue, error) {
+	if q.QueueDir != nil {
+		return scheduler.Open(*q.QueueDir)
+	}
+	dir, err := base.StatePath("queue")
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Open(dir)
+}
@@ -0,0 +1,205 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/scheduler"
+	"github.com/fatih/color"
+)
+
+// Add is the schedule add command.
+type Add struct {
+	base.TokenCommand
+	base.Recipients
+	base.Message
+	Queue
+	base.Confirmation
+	base.Limits
+	// Account is the account to send the message from.
+	Account string `short:"a" long:"account" description:"The account to send the message from." required:"yes" env:"SMS_ACCOUNT"`
+	// Gateway is the (optional) ID of the SMS gateway to send through.
+	Gateway *int `short:"g" long:"gateway" description:"The ID of the SMS gateway to send the message through (default: the account default gateway)."`
+	// Sender is the (optional) sender of the message.
+	Sender *string `short:"s" long:"sender" description:"The sender of the message (default: the gateway default)."`
+	// At is the time the message must be sent at.
+	At *string `long:"at" description:"When to send the message (RFC3339, YYYY-MM-DD HH:MM or HH:MM; default: now)."`
+	// Until is the (optional) end of the window recipients are spread over.
+	Until *string `long:"until" description:"Spread recipients evenly until this time (RFC3339, YYYY-MM-DD HH:MM or HH:MM)."`
+	// QuietHours is the (optional) daily interval recipients must not be messaged in.
+	QuietHours *string `long:"quiet-hours" description:"Do not message recipients in this interval of their local time (e.g. 21:00-08:00)." env:"SMS_QUIET_HOURS"`
+}
+
+// Execute is the real implementation of the schedule add command.
+func (cmd *Add) Execute(args []string) error {
+	slog.Debug("called schedule add command", "args", args)
+
+	text, err := cmd.Message.Load()
+	if err != nil {
+		return err
+	}
+
+	recipients, invalid, err := cmd.Recipients.Load(args)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		slog.Error("no valid recipients provided")
+		return errors.New("no valid recipients provided")
+	}
+
+	start := time.Now()
+	if cmd.At != nil {
+		if start, err = base.ParseTime(*cmd.At); err != nil {
+			slog.Error("invalid start time", "value", *cmd.At, "error", err)
+			return err
+		}
+	}
+	end := time.Time{}
+	if cmd.Until != nil {
+		if end, err = base.ParseTime(*cmd.Until); err != nil {
+			slog.Error("invalid end time", "value", *cmd.Until, "error", err)
+			return err
+		}
+	}
+	var quiet *scheduler.QuietHours
+	if cmd.QuietHours != nil && *cmd.QuietHours != "" {
+		if quiet, err = scheduler.ParseQuietHours(*cmd.QuietHours); err != nil {
+			slog.Error("invalid quiet hours", "value", *cmd.QuietHours, "error", err)
+			return err
+		}
+	}
+
+	gateway, err := gatewayFor(&cmd.TokenCommand, cmd.Account, cmd.Gateway)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	sender := ""
+	if cmd.Sender != nil {
+		sender = *cmd.Sender
+	}
+
+	job, err := scheduler.NewJob(cmd.Account, gateway.ID, sender, text, recipients, start, end, quiet)
+	if err != nil {
+		slog.Error("invalid job", "error", err)
+		return err
+	}
+
+	// only the recipients that are not suppressed now count towards the
+	// limits; the suppression list is applied again when they are dispatched
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+	allowed, _ := list.Filter(recipients)
+	cost := rdcom.Quote(gateway, allowed, text).Total
+	if exceeded := cmd.Limits.Exceeded(len(allowed), cost); len(exceeded) > 0 {
+		slog.Warn("scheduled send exceeds policy limits", "recipients", len(allowed), "cost", cost)
+		if ok, err := cmd.Confirm(fmt.Sprintf("schedule the message to %d recipient(s)?", len(allowed)), exceeded...); !ok {
+			return err
+		}
+	}
+
+	queue, err := cmd.Queue.Open()
+	if err != nil {
+		return err
+	}
+	if err := queue.Add(job); err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	printJob(job, false)
+	if len(invalid) > 0 {
+		fmt.Printf("invalid recipients: %s\n", color.RedString(strings.Join(invalid, ", ")))
+	}
+	return nil
+}
+
+// gatewayFor returns the SMS gateway with the given ID or, if nil, the
+// default gateway of the account.
+func gatewayFor(cmd *base.TokenCommand, account string, id *int) (*rdcom.SMSGateway, error) {
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return nil, err
+	}
+
+	defer client.Close()
+
+	gateways, err := client.SMSGatewayService.List(account)
+	if err != nil {
+		slog.Error("error performing SMS gateway list API call", "error", err)
+		return nil, fmt.Errorf("error performing API call: %w", err)
+	}
+	for _, gateway := range gateways {
+		if (id == nil && gateway.IsDefault) || (id != nil && gateway.ID == *id) {
+			return &gateway, nil
+		}
+	}
+	if id == nil {
+		slog.Error("no default SMS gateway", "account", account)
+		return nil, fmt.Errorf("account %s has no default SMS gateway", account)
+	}
+	slog.Error("SMS gateway not found", "account", account, "gateway", *id)
+	return nil, fmt.Errorf("SMS gateway %d not found", *id)
+}
+
+// printJob prints a job and, optionally, the state of each recipient.
+func printJob(job *scheduler.Job, recipients bool) {
+	fmt.Printf("job: %s\n", color.YellowString(job.ID))
+	fmt.Printf(" - status                 : %s\n", coloredStatus(job.Status))
+	fmt.Printf(" - account                : %s\n", color.YellowString(job.Account))
+	fmt.Printf(" - gateway                : %s\n", color.YellowString(fmt.Sprintf("%d", job.Gateway)))
+	if job.Sender != "" {
+		fmt.Printf(" - sender                 : %s\n", color.YellowString(job.Sender))
+	}
+	fmt.Printf(" - text                   : %s\n", color.YellowString(pii.TextOf(job.Text)))
+	fmt.Printf(" - start                  : %s\n", color.YellowString(job.Start.Format(base.DefaultDateFormat)))
+	if !job.End.IsZero() {
+		fmt.Printf(" - end                    : %s\n", color.YellowString(job.End.Format(base.DefaultDateFormat)))
+	}
+	fmt.Printf(" - quiet hours            : %s\n", color.YellowString(job.QuietHours.String()))
+	if next, ok := job.NextDue(); ok && (job.Status == scheduler.Pending || job.Status == scheduler.Running) {
+		fmt.Printf(" - next due               : %s\n", color.YellowString(next.Local().Format(base.DefaultDateFormat)))
+	}
+	counts := []string{}
+	for _, status := range []scheduler.Status{scheduler.Pending, scheduler.InFlight, scheduler.Sent, scheduler.Suppressed, scheduler.Failed, scheduler.Cancelled} {
+		if count := job.Count(status); count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count, status))
+		}
+	}
+	fmt.Printf(" - recipients             : %s\n", color.YellowString(fmt.Sprintf("%d (%s)", len(job.Recipients), strings.Join(counts, ", "))))
+	if recipients {
+		for _, recipient := range job.Recipients {
+			detail := string(recipient.Status)
+			if recipient.Error != "" {
+				detail = fmt.Sprintf("%s: %s", detail, recipient.Error)
+			} else if !recipient.Sent.IsZero() {
+				detail = fmt.Sprintf("%s at %s", detail, recipient.Sent.Format(time.DateTime))
+			} else if recipient.Status == scheduler.Pending {
+				detail = fmt.Sprintf("%s, due %s", detail, job.QuietHours.Defer(recipient.Number, recipient.Due).Local().Format(time.DateTime))
+			}
+			fmt.Printf("   - %-20s : %s\n", pii.PhoneOf(recipient.Number), color.YellowString(detail))
+		}
+	}
+}
+
+// coloredStatus returns the job status coloured by outcome.
+func coloredStatus(status scheduler.Status) string {
+	switch status {
+	case scheduler.Done:
+		return color.GreenString(string(status))
+	case scheduler.Failed, scheduler.Cancelled:
+		return color.RedString(string(status))
+	default:
+		return color.YellowString(string(status))
+	}
+}
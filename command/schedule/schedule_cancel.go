@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/scheduler"
+	"github.com/fatih/color"
+)
+
+// Cancel is the schedule cancel command.
+type Cancel struct {
+	Queue
+	base.Confirmation
+}
+
+// Execute is the real implementation of the schedule cancel command;
+// recipients already dispatched are not affected.
+func (cmd *Cancel) Execute(args []string) error {
+	slog.Debug("called schedule cancel command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no job ID provided")
+		return errors.New("no job ID provided")
+	}
+
+	if ok, err := cmd.Confirm(fmt.Sprintf("cancel %d job(s)?", len(args)), "jobs                   : "+color.YellowString(strings.Join(args, ", "))); !ok {
+		return err
+	}
+
+	queue, err := cmd.Queue.Open()
+	if err != nil {
+		return err
+	}
+	var result error
+	for _, id := range args {
+		job, err := queue.Cancel(id)
+		if err != nil {
+			slog.Error("error cancelling job", "id", id, "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			result = errors.Join(result, err)
+			continue
+		}
+		fmt.Printf("job: %s (%s, %d recipients already dispatched)\n", color.YellowString(job.ID), color.RedString(string(job.Status)), len(job.Recipients)-job.Count(scheduler.Cancelled))
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/dihedron/sms/scheduler"
+	"github.com/fatih/color"
+)
+
+// List is the schedule list command.
+type List struct {
+	Queue
+	// All sets whether to list completed and cancelled jobs too.
+	All bool `short:"A" long:"all" description:"Also list completed, failed and cancelled jobs." optional:"yes"`
+	// Recipients sets whether to show the state of each recipient.
+	Recipients bool `short:"r" long:"recipients" description:"Show the state of each recipient." optional:"yes"`
+}
+
+// Execute is the real implementation of the schedule list command; jobs can
+// be selected by ID.
+func (cmd *List) Execute(args []string) error {
+	slog.Debug("called schedule list command", "args", args)
+
+	queue, err := cmd.Queue.Open()
+	if err != nil {
+		return err
+	}
+	jobs, err := queue.List()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	for _, job := range jobs {
+		if len(args) > 0 && !slices.Contains(args, job.ID) {
+			continue
+		}
+		if len(args) == 0 && !cmd.All && !(job.Status == scheduler.Pending || job.Status == scheduler.Running) {
+			continue
+		}
+		printJob(job, cmd.Recipients)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/scheduler"
+	"github.com/fatih/color"
+)
+
+// Run is the scheduler run command.
+type Run struct {
+	base.TokenCommand
+	Queue
+	// Interval is the interval between two dispatch rounds.
+	Interval time.Duration `short:"i" long:"interval" description:"The interval between two dispatch rounds." env:"SMS_SCHEDULER_INTERVAL" default:"30s"`
+	// Rate is the maximum number of recipients dispatched per minute.
+	Rate int `short:"r" long:"rate" description:"The maximum number of recipients dispatched per minute, across all jobs (0 for no limit)." env:"SMS_SCHEDULER_RATE" default:"0"`
+	// BatchSize is the maximum number of recipients per message.
+	BatchSize int `short:"b" long:"batch-size" description:"The maximum number of recipients per message." env:"SMS_SCHEDULER_BATCH_SIZE" default:"100"`
+}
+
+// Execute is the real implementation of the scheduler run command; only one
+// scheduler at a time can run on a queue.
+func (cmd *Run) Execute(args []string) error {
+	slog.Debug("called scheduler run command", "interval", cmd.Interval, "rate", cmd.Rate)
+
+	if err := cmd.RefuseDryRun("scheduler run"); err != nil {
+		return err
+	}
+
+	if cmd.Interval <= 0 {
+		slog.Error("invalid dispatch interval", "interval", cmd.Interval)
+		return fmt.Errorf("invalid dispatch interval: %s", cmd.Interval)
+	}
+
+	queue, err := cmd.Queue.Open()
+	if err != nil {
+		return err
+	}
+	unlock, err := queue.Lock()
+	if err != nil {
+		slog.Error("error locking queue", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	defer unlock()
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	dispatcher := &scheduler.Dispatcher{
+		Queue:     queue,
+		Sender:    client.MessageService,
+		Rate:      cmd.Rate,
+		BatchSize: cmd.BatchSize,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	slog.Info("scheduler running", "interval", cmd.Interval, "rate", cmd.Rate)
+	return dispatcher.Run(ctx, cmd.Interval)
+}
@@ -0,0 +1,56 @@
+package send
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/suppression"
+)
+
+type Send struct {
+	// RCS is the command to send an RCS message.
+	RCS RCS `command:"rcs" description:"Send an RCS message defined in a JSON or YAML file, with SMS fallback."`
+
+	// SMS is the command to send an SMS message.
+	SMS SMS `command:"sms" description:"Send an SMS message."`
+}
+
+// gatewayFor returns the SMS gateway with the given ID or, if nil, the
+// default gateway of the account.
+func gatewayFor(client *rdcom.Client, account string, id *int) (*rdcom.SMSGateway, error) {
+	gateways, err := client.SMSGatewayService.List(account)
+	if err != nil {
+		slog.Error("error performing SMS gateway list API call", "error", err)
+		return nil, fmt.Errorf("error performing API call: %w", err)
+	}
+	for _, gateway := range gateways {
+		if (id == nil && gateway.IsDefault) || (id != nil && gateway.ID == *id) {
+			return &gateway, nil
+		}
+	}
+	if id == nil {
+		slog.Error("no default SMS gateway", "account", account)
+		return nil, fmt.Errorf("account %s has no default SMS gateway", account)
+	}
+	slog.Error("SMS gateway not found", "account", account, "gateway", *id)
+	return nil, fmt.Errorf("SMS gateway %d not found", *id)
+}
+
+// confirm asks for confirmation before sending a message whose recipients
+// (those not suppressed) or expected cost exceed the policy limits; a nil
+// gateway means the cost cannot be estimated.
+func confirm(confirmation *base.Confirmation, limits *base.Limits, list *suppression.List, gateway *rdcom.SMSGateway, recipients []string, text string) (bool, error) {
+	allowed, _ := list.Filter(recipients)
+	cost := 0.0
+	if gateway != nil {
+		cost = rdcom.Quote(gateway, allowed, text).Total
+	}
+	exceeded := limits.Exceeded(len(allowed), cost)
+	if len(exceeded) == 0 {
+		return true, nil
+	}
+	slog.Warn("send exceeds policy limits", "recipients", len(allowed), "cost", cost)
+	return confirmation.Confirm(fmt.Sprintf("send the message to %d recipient(s)?", len(allowed)), exceeded...)
+}
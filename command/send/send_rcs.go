@@ -0,0 +1,154 @@
+package send
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+// RCS is the send rcs command; the message is defined in a JSON or YAML
+// file, whose recipients (if any) are merged with those on the command line.
+type RCS struct {
+	base.TokenCommand
+	base.Recipients
+	base.Confirmation
+	base.Limits
+	// Account is the account to send the message from.
+	Account string `short:"a" long:"account" description:"The account to send the message from." required:"yes" env:"SMS_ACCOUNT"`
+	// Gateway is the (optional) ID of the gateway to send through.
+	Gateway *int `short:"g" long:"gateway" description:"The ID of the gateway to send the message through (default: the one in the file, or the account default gateway)."`
+	// Definition is the path to the message definition file.
+	Definition string `short:"d" long:"definition" description:"The path to the JSON or YAML message definition file (use '-' for STDIN)." required:"yes"`
+	// Validate sets whether to only validate the message and show the fallback text.
+	Validate bool `long:"validate" description:"Only validate the message definition and show the SMS fallback text." optional:"yes"`
+}
+
+// Execute is the real implementation of the send rcs command; if the gateway
+// is not RCS-ready, the fallback text is sent as an SMS message to everyone.
+func (cmd *RCS) Execute(args []string) error {
+	slog.Debug("called send rcs command", "definition", cmd.Definition, "args", args)
+
+	message, err := loadDefinition(cmd.Definition)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	if err := message.Validate(); err != nil {
+		slog.Error("invalid RCS message", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	if cmd.Validate {
+		fmt.Printf("%s: SMS fallback text follows\n%s\n", color.GreenString("valid"), message.FallbackText())
+		return nil
+	}
+
+	recipients, invalid, err := cmd.Recipients.Load(append(message.Recipients, args...))
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		slog.Error("no valid recipients provided")
+		return errors.New("no valid recipients provided")
+	}
+	message.Recipients = recipients
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	id := cmd.Gateway
+	if id == nil && message.Gateway != 0 {
+		id = &message.Gateway
+	}
+	gateway, err := gatewayFor(client, cmd.Account, id)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	// RCS prices are not known, so the cost is only estimated for the fallback
+	quoted := gateway
+	if gateway.RcsReady {
+		quoted = nil
+	}
+	if ok, err := confirm(&cmd.Confirmation, &cmd.Limits, list, quoted, recipients, message.FallbackText()); !ok {
+		return err
+	}
+
+	if !gateway.RcsReady {
+		slog.Warn("gateway is not RCS-ready, sending SMS fallback", "gateway", gateway.ID)
+		fmt.Printf("warning: %s\n", color.YellowString(fmt.Sprintf("gateway %d is not RCS-ready, sending the SMS fallback text", gateway.ID)))
+		sent, err := client.MessageService.Send(cmd.Account, &rdcom.Message{
+			Gateway:    gateway.ID,
+			Recipients: recipients,
+			Text:       message.FallbackText(),
+		})
+		if err != nil {
+			slog.Error("error performing message send API call", "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			return fmt.Errorf("error performing API call: %w", err)
+		}
+		printResult(sent.ID, sent.Status, "sms (fallback)", gateway.ID, len(recipients)-len(sent.Suppressed), sent.Suppressed, invalid)
+		return nil
+	}
+
+	message.Gateway = gateway.ID
+	sent, err := client.MessageService.SendRCS(cmd.Account, message)
+	if err != nil {
+		slog.Error("error performing RCS send API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+	printResult(sent.ID, sent.Status, "rcs", gateway.ID, len(recipients)-len(sent.Suppressed), sent.Suppressed, invalid)
+	return nil
+}
+
+// loadDefinition reads an RCS message definition; files with a .json
+// extension are parsed as JSON, anything else (including STDIN) as YAML,
+// which is a superset of JSON.
+func loadDefinition(path string) (*rdcom.RCSMessage, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filepath.Clean(path))
+	}
+	if err != nil {
+		slog.Error("error reading message definition", "path", path, "error", err)
+		return nil, err
+	}
+	message := &rdcom.RCSMessage{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, message)
+	} else {
+		err = yaml.Unmarshal(data, message)
+	}
+	if err != nil {
+		slog.Error("error parsing message definition", "path", path, "error", err)
+		return nil, fmt.Errorf("invalid message definition %s: %w", path, err)
+	}
+	return message, nil
+}
@@ -0,0 +1,171 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// SMS is the send sms command.
+type SMS struct {
+	base.TokenCommand
+	base.Recipients
+	base.Message
+	base.Outbox
+	base.Confirmation
+	base.Limits
+	// Durable sets whether to store the message in the outbox before sending.
+	Durable bool `long:"durable" description:"Store the message in the outbox before sending it, so that it is retried by 'outbox drain' if sending fails." optional:"yes"`
+	// Account is the account to send the message from.
+	Account string `short:"a" long:"account" description:"The account to send the message from." required:"yes" env:"SMS_ACCOUNT"`
+	// Gateway is the (optional) ID of the SMS gateway to send through.
+	Gateway *int `short:"g" long:"gateway" description:"The ID of the SMS gateway to send the message through (default: the account default gateway)."`
+	// Sender is the (optional) sender of the message.
+	Sender *string `short:"s" long:"sender" description:"The sender of the message (default: the gateway default)."`
+}
+
+// Execute is the real implementation of the send sms command.
+func (cmd *SMS) Execute(args []string) error {
+	slog.Debug("called send sms command", "args", args)
+
+	text, err := cmd.Message.Load()
+	if err != nil {
+		return err
+	}
+
+	recipients, invalid, err := cmd.Recipients.Load(args)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		slog.Error("no valid recipients provided")
+		return errors.New("no valid recipients provided")
+	}
+	if cmd.Sender != nil && !isNumber(*cmd.Sender) {
+		if err := rdcom.ValidateSender(*cmd.Sender); err != nil {
+			slog.Error("invalid sender", "sender", *cmd.Sender, "error", err)
+			return err
+		}
+	}
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.NewClient(rdcom.WithSuppressor(list))
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	gateway, err := gatewayFor(client, cmd.Account, cmd.Gateway)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	message := &rdcom.Message{
+		Gateway:    gateway.ID,
+		Recipients: recipients,
+		Text:       text,
+	}
+	if cmd.Sender != nil {
+		message.Sender = *cmd.Sender
+	}
+	if ok, err := confirm(&cmd.Confirmation, &cmd.Limits, list, gateway, recipients, text); !ok {
+		return err
+	}
+	if cmd.Durable {
+		return cmd.sendDurable(client, message, gateway.ID, len(recipients), invalid)
+	}
+
+	sent, err := client.MessageService.Send(cmd.Account, message)
+	if err != nil {
+		slog.Error("error performing message send API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	printResult(sent.ID, sent.Status, "sms", gateway.ID, len(recipients)-len(sent.Suppressed), sent.Suppressed, invalid)
+	return nil
+}
+
+// sendDurable stores the message in the outbox and attempts to send it; if
+// the attempt fails with a transient error, the message is left in the
+// outbox to be retried.
+func (cmd *SMS) sendDurable(client *rdcom.Client, message *rdcom.Message, gateway int, count int, invalid []string) error {
+	if err := cmd.RefuseDryRun("send sms --durable"); err != nil {
+		return err
+	}
+	outbox, err := cmd.Outbox.Open(client)
+	if err != nil {
+		return err
+	}
+	entry, err := outbox.Enqueue(cmd.Account, message)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	if _, err := outbox.Drain(context.Background()); err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	if entry, err = outbox.Get(entry.Ref); err != nil {
+		return err
+	}
+	switch entry.Status {
+	case rdcom.OutboxSent:
+		result := entry.Result
+		if result == nil {
+			result = &rdcom.Message{}
+		}
+		printResult(result.ID, result.Status, "sms", gateway, count-len(entry.Suppressed), entry.Suppressed, invalid)
+	case rdcom.OutboxPending:
+		fmt.Printf("message: %s\n", color.YellowString(entry.Ref))
+		fmt.Printf(" - status                 : %s\n", color.YellowString("queued, will be retried by 'outbox drain'"))
+		fmt.Printf(" - last error             : %s\n", color.RedString(entry.Error))
+	default:
+		slog.Error("message not sent", "ref", entry.Ref, "status", entry.Status, "error", entry.Error)
+		fmt.Printf("error: %s\n", color.RedString(entry.Error))
+		return fmt.Errorf("message %s %s: %s", entry.Ref, entry.Status, entry.Error)
+	}
+	return nil
+}
+
+// printResult prints the outcome of a send.
+func printResult(id string, status string, channel string, gateway int, sent int, suppressed []string, invalid []string) {
+	fmt.Printf("message: %s\n", color.YellowString(id))
+	fmt.Printf(" - channel                : %s\n", color.YellowString(channel))
+	fmt.Printf(" - gateway                : %s\n", color.YellowString(fmt.Sprintf("%d", gateway)))
+	if status != "" {
+		fmt.Printf(" - status                 : %s\n", color.YellowString(status))
+	}
+	fmt.Printf(" - recipients             : %s\n", color.GreenString(fmt.Sprintf("%d", sent)))
+	if len(suppressed) > 0 {
+		fmt.Printf(" - suppressed             : %s\n", color.RedString(strings.Join(pii.PhonesOf(suppressed), ", ")))
+	}
+	if len(invalid) > 0 {
+		fmt.Printf(" - invalid                : %s\n", color.RedString(strings.Join(invalid, ", ")))
+	}
+}
+
+// isNumber reports whether the sender is a phone number rather than an
+// alphanumeric sender.
+func isNumber(sender string) bool {
+	for i, r := range sender {
+		if (r < '0' || r > '9') && !(i == 0 && r == '+') {
+			return false
+		}
+	}
+	return sender != ""
+}
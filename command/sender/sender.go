@@ -0,0 +1,19 @@
+package sender
+
+type Sender struct {
+	// Cancel is the command to withdraw a sender registration request.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Cancel Cancel `command:"cancel" alias:"del" alias:"c" description:"Cancel a sender registration request."`
+
+	// List is the command to list sender registrations.
+	//lint:ignore SA5008 commands can have multiple aliases
+	List List `command:"list" alias:"ls" alias:"l" description:"List sender registrations and the gateways they are usable on."`
+
+	// Request is the command to request the registration of a new sender.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Request Request `command:"request" alias:"req" alias:"r" description:"Request the registration of a new alphanumeric sender."`
+
+	// Status is the command to show the status of a sender registration.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Status Status `command:"status" alias:"st" alias:"s" description:"Show the status of sender registrations."`
+}
@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Cancel is the sender cancel command.
+type Cancel struct {
+	base.TokenCommand
+	base.Confirmation
+	// Account is the account the senders belong to.
+	Account string `short:"a" long:"account" description:"The account the senders belong to." required:"yes" env:"SMS_ACCOUNT"`
+}
+
+// Execute is the real implementation of the sender cancel command; only
+// pending requests can be cancelled.
+func (cmd *Cancel) Execute(args []string) error {
+	slog.Debug("called sender cancel command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no sender ID provided")
+		return fmt.Errorf("no sender ID provided")
+	}
+
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			slog.Error("invalid sender ID", "id", arg, "error", err)
+			return fmt.Errorf("invalid sender ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	if ok, err := cmd.Confirm(fmt.Sprintf("cancel %d sender request(s)?", len(ids)), "ids                    : "+color.YellowString(strings.Join(args, ", "))); !ok {
+		return err
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	for _, id := range ids {
+		sender, err := client.SenderService.Get(cmd.Account, id)
+		if err != nil {
+			slog.Error("error performing sender get API call", "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			return fmt.Errorf("error performing API call: %w", err)
+		}
+		if sender.Status != rdcom.SenderPending {
+			slog.Error("only pending requests can be cancelled", "id", id, "status", sender.Status)
+			return fmt.Errorf("sender %s (%d) is %s: only pending requests can be cancelled", sender.Sender, id, sender.Status)
+		}
+		if _, err := client.SenderService.Cancel(cmd.Account, id); err != nil {
+			slog.Error("error performing sender cancel API call", "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			return fmt.Errorf("error performing API call: %w", err)
+		}
+		fmt.Printf("sender: %s (%s)\n", color.YellowString(sender.Sender), color.RedString(string(rdcom.SenderCancelled)))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package sender
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/format"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// List is the sender list command.
+type List struct {
+	base.TokenCommand
+	// Account is the account whose senders to list.
+	Account string `short:"a" long:"account" description:"The account whose senders to list." required:"yes" env:"SMS_ACCOUNT"`
+	// Status is the (optional) status to filter senders by.
+	Status *string `short:"s" long:"status" description:"Only list senders in the given status." choice:"pending" choice:"approved" choice:"rejected" choice:"cancelled"`
+}
+
+// Execute is the real implementation of the sender list command.
+func (cmd *List) Execute(args []string) error {
+	slog.Debug("called sender list command")
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	senders, err := client.SenderService.List(cmd.Account)
+	if err != nil {
+		slog.Error("error performing sender list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	gateways := gatewaysOf(client, cmd.Account)
+
+	slog.Info("senders", "length", len(senders))
+
+	for _, sender := range senders {
+		if cmd.Status != nil && string(sender.Status) != *cmd.Status {
+			continue
+		}
+		printSender(&sender, gateways)
+	}
+	return nil
+}
+
+// gatewaysOf returns the SMS gateways of the account by ID; failures are
+// logged and result in gateways being shown by ID only.
+func gatewaysOf(client *rdcom.Client, account string) map[int]rdcom.SMSGateway {
+	result := map[int]rdcom.SMSGateway{}
+	gateways, err := client.SMSGatewayService.List(account)
+	if err != nil {
+		slog.Warn("error retrieving SMS gateways", "account", account, "error", err)
+		return result
+	}
+	for _, gateway := range gateways {
+		result[gateway.ID] = gateway
+	}
+	return result
+}
+
+// printSender prints a sender registration along with the gateways it is
+// linked to and their readiness.
+func printSender(sender *rdcom.Sender, gateways map[int]rdcom.SMSGateway) {
+	fmt.Printf("sender: %s\n", color.YellowString(sender.Sender))
+	fmt.Printf(" - id                     : %s\n", color.YellowString(fmt.Sprintf("%d", sender.ID)))
+	fmt.Printf(" - status                 : %s\n", coloredStatus(sender.Status))
+	if !sender.Created.IsZero() {
+		fmt.Printf(" - requested              : %s\n", color.YellowString(sender.Created.Format(base.DefaultDateFormat)))
+	}
+	if !sender.Updated.IsZero() {
+		fmt.Printf(" - updated                : %s\n", color.YellowString(sender.Updated.Format(base.DefaultDateFormat)))
+	}
+	if sender.Reason != "" {
+		fmt.Printf(" - reason                 : %s\n", color.YellowString(sender.Reason))
+	}
+	if sender.Notes != "" {
+		fmt.Printf(" - notes                  : %s\n", color.YellowString(sender.Notes))
+	}
+	if len(sender.Gateways) == 0 {
+		fmt.Printf(" - gateways               : %s\n", color.YellowString("none"))
+		return
+	}
+	fmt.Printf(" - gateways               :\n")
+	for _, id := range sender.Gateways {
+		gateway, ok := gateways[id]
+		if !ok {
+			fmt.Printf("   - %-20d : %s\n", id, color.RedString("unknown"))
+			continue
+		}
+		fmt.Printf("   - %-20d : %s (sender ready: %s)\n", id, color.YellowString(gateway.GatewayType), format.ColoredBool(gateway.SenderReady))
+	}
+}
+
+// coloredStatus returns the sender status coloured by outcome.
+func coloredStatus(status rdcom.SenderStatus) string {
+	switch status {
+	case rdcom.SenderApproved:
+		return color.GreenString(string(status))
+	case rdcom.SenderRejected, rdcom.SenderCancelled:
+		return color.RedString(string(status))
+	default:
+		return color.YellowString(string(status))
+	}
+}
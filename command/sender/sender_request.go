@@ -0,0 +1,83 @@
+package sender
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Request is the sender request command.
+type Request struct {
+	base.TokenCommand
+	// Account is the account to register the sender for.
+	Account string `short:"a" long:"account" description:"The account to register the sender for." required:"yes" env:"SMS_ACCOUNT"`
+	// Gateways is the list of SMS gateways the sender must be usable on.
+	Gateways []int `short:"g" long:"gateway" description:"The ID of an SMS gateway the sender must be usable on (default: the account default gateway); can be repeated."`
+	// Reason is the motivation of the request.
+	Reason *string `short:"r" long:"reason" description:"The motivation of the request (e.g. the requesting department)."`
+}
+
+// Execute is the real implementation of the sender request command.
+func (cmd *Request) Execute(args []string) error {
+	slog.Debug("called sender request command", "args", args)
+
+	if len(args) != 1 {
+		slog.Error("exactly one sender must be provided", "args", args)
+		return fmt.Errorf("exactly one sender must be provided")
+	}
+	sender := args[0]
+
+	// check locally first, to avoid a round trip for a doomed request
+	if err := rdcom.ValidateSender(sender); err != nil {
+		slog.Error("invalid sender", "sender", sender, "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	gateways := gatewaysOf(client, cmd.Account)
+	ids := cmd.Gateways
+	if len(ids) == 0 {
+		for _, gateway := range gateways {
+			if gateway.IsDefault {
+				ids = append(ids, gateway.ID)
+			}
+		}
+		if len(ids) == 0 {
+			slog.Error("no gateway specified and no default gateway", "account", cmd.Account)
+			return fmt.Errorf("no gateway specified and account %s has no default gateway", cmd.Account)
+		}
+	} else if len(gateways) > 0 {
+		for _, id := range ids {
+			if _, ok := gateways[id]; !ok {
+				slog.Error("unknown gateway", "account", cmd.Account, "gateway", id)
+				return fmt.Errorf("gateway %d does not belong to account %s", id, cmd.Account)
+			}
+		}
+	}
+
+	reason := ""
+	if cmd.Reason != nil {
+		reason = *cmd.Reason
+	}
+
+	result, err := client.SenderService.Request(cmd.Account, sender, ids, reason)
+	if err != nil {
+		slog.Error("error performing sender request API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	printSender(result, gateways)
+	return nil
+}
@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/fatih/color"
+)
+
+// Status is the sender status command.
+type Status struct {
+	base.TokenCommand
+	// Account is the account the senders belong to.
+	Account string `short:"a" long:"account" description:"The account the senders belong to." required:"yes" env:"SMS_ACCOUNT"`
+}
+
+// Execute is the real implementation of the sender status command; senders
+// can be identified by registration ID or by name.
+func (cmd *Status) Execute(args []string) error {
+	slog.Debug("called sender status command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no sender provided")
+		return fmt.Errorf("no sender provided")
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	senders, err := client.SenderService.List(cmd.Account)
+	if err != nil {
+		slog.Error("error performing sender list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	gateways := gatewaysOf(client, cmd.Account)
+
+	missing := 0
+	for _, arg := range args {
+		found := false
+		for _, sender := range senders {
+			if sender.Sender == arg || strconv.Itoa(sender.ID) == arg {
+				printSender(&sender, gateways)
+				found = true
+			}
+		}
+		if !found {
+			slog.Warn("sender not found", "account", cmd.Account, "sender", arg)
+			fmt.Printf("sender: %s (%s)\n", color.YellowString(arg), color.RedString("not found"))
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d sender(s) not found", missing)
+	}
+	return nil
+}
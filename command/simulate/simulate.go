@@ -0,0 +1,138 @@
+package simulate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/simulator"
+	"github.com/fatih/color"
+)
+
+// Simulate is the command that runs a local simulator of the RDCom API.
+type Simulate struct {
+	base.Command
+	// Address is the address to listen on.
+	Address string `short:"l" long:"listen" description:"The address to listen on." env:"SMS_SIMULATE_LISTEN" default:"127.0.0.1:8089"`
+	// Latency is the delay added to every API response.
+	Latency time.Duration `long:"latency" description:"The delay added to every API response." env:"SMS_SIMULATE_LATENCY" default:"0s"`
+	// Jitter is the maximum random delay added on top of the latency.
+	Jitter time.Duration `long:"jitter" description:"The maximum random delay added on top of the latency." env:"SMS_SIMULATE_JITTER" default:"0s"`
+	// ErrorRate is the probability that an API call fails.
+	ErrorRate float64 `long:"error-rate" description:"The probability (0 to 1) that an API call fails before being processed." env:"SMS_SIMULATE_ERROR_RATE" default:"0"`
+	// LostRate is the probability that the response to an API call is lost.
+	LostRate float64 `long:"lost-rate" description:"The probability (0 to 1) that an API call is processed but its response is lost." env:"SMS_SIMULATE_LOST_RATE" default:"0"`
+	// ErrorStatuses are the HTTP statuses of injected errors.
+	ErrorStatuses []int `long:"error-status" description:"The HTTP status of injected errors, picked at random if repeated." env:"SMS_SIMULATE_ERROR_STATUS" env-delim:"," default:"503"`
+	// Outcomes are the relative weights of the delivery outcomes.
+	Outcomes map[string]float64 `long:"delivery" description:"The relative weight of a delivery outcome (delivered, failed or expired), as outcome:weight; can be repeated." env:"SMS_SIMULATE_DELIVERY" env-delim:"," default:"delivered:95" default:"failed:3" default:"expired:2"`
+	// DeliveryDelay is how long after submission delivery reports arrive.
+	DeliveryDelay time.Duration `long:"delivery-delay" description:"How long after submission delivery reports arrive." env:"SMS_SIMULATE_DELIVERY_DELAY" default:"3s"`
+	// SenderReview is how long after submission sender registrations are approved.
+	SenderReview time.Duration `long:"sender-review" description:"How long after submission sender registrations are approved (0 to keep them pending)." env:"SMS_SIMULATE_SENDER_REVIEW" default:"1m"`
+	// Tokens are the tokens accepted from the start.
+	Tokens []string `long:"accept-token" description:"A token accepted from the start; can be repeated." env:"SMS_SIMULATE_TOKENS" env-delim:","`
+	// Accounts are the accounts available from the start.
+	Accounts []string `short:"a" long:"account" description:"The code of an account available from the start; can be repeated." env:"SMS_SIMULATE_ACCOUNTS" env-delim:"," default:"simulated"`
+	// Credit is the initial SMS credit of each account.
+	Credit float64 `long:"credit" description:"The initial SMS credit of each account (negative for unlimited credit)." env:"SMS_SIMULATE_CREDIT" default:"100"`
+	// Strict sets whether unknown tokens and accounts are refused.
+	Strict bool `long:"strict" description:"Whether to refuse unknown tokens and accounts instead of registering them on first use." optional:"yes" env:"SMS_SIMULATE_STRICT"`
+	// Seed is the seed of the random generator.
+	Seed int64 `long:"seed" description:"The seed of the random generator, for reproducible runs (default: random)." env:"SMS_SIMULATE_SEED" default:"0"`
+}
+
+// Execute is the real implementation of the simulate command.
+func (cmd *Simulate) Execute(args []string) error {
+	slog.Debug("called simulate command", "address", cmd.Address, "latency", cmd.Latency, "error rate", cmd.ErrorRate)
+
+	for name, rate := range map[string]float64{"error rate": cmd.ErrorRate, "lost rate": cmd.LostRate} {
+		if rate < 0 || rate > 1 {
+			slog.Error("invalid probability", "name", name, "value", rate)
+			return fmt.Errorf("invalid %s: %g", name, rate)
+		}
+	}
+	for _, status := range cmd.ErrorStatuses {
+		if status < 400 || status > 599 {
+			slog.Error("invalid error status", "status", status)
+			return fmt.Errorf("invalid error status: %d", status)
+		}
+	}
+	outcomes := map[simulator.Outcome]float64{}
+	for name, weight := range cmd.Outcomes {
+		outcome := simulator.Outcome(name)
+		switch outcome {
+		case simulator.Delivered, simulator.Failed, simulator.Expired:
+		default:
+			slog.Error("invalid delivery outcome", "outcome", name)
+			return fmt.Errorf("invalid delivery outcome %q", name)
+		}
+		if weight < 0 {
+			slog.Error("invalid delivery outcome weight", "outcome", name, "weight", weight)
+			return fmt.Errorf("invalid weight for delivery outcome %s: %g", name, weight)
+		}
+		outcomes[outcome] = weight
+	}
+
+	handler := simulator.New(simulator.Config{
+		Latency:       cmd.Latency,
+		Jitter:        cmd.Jitter,
+		ErrorRate:     cmd.ErrorRate,
+		LostRate:      cmd.LostRate,
+		ErrorStatuses: cmd.ErrorStatuses,
+		Outcomes:      outcomes,
+		DeliveryDelay: cmd.DeliveryDelay,
+		SenderReview:  cmd.SenderReview,
+		Tokens:        cmd.Tokens,
+		Accounts:      cmd.Accounts,
+		Credit:        cmd.Credit,
+		Strict:        cmd.Strict,
+		Seed:          cmd.Seed,
+	})
+
+	// listen first, so that the actual address is known (e.g. with port 0)
+	listener, err := net.Listen("tcp", cmd.Address)
+	if err != nil {
+		slog.Error("error listening", "address", cmd.Address, "error", err)
+		return err
+	}
+	endpoint := "http://" + listener.Addr().String()
+	fmt.Printf("simulator: %s\n", color.GreenString(endpoint))
+	fmt.Printf(" - usage                  : %s\n", color.YellowString("SMS_ENDPOINT=%s sms ...", endpoint))
+	fmt.Printf(" - inspection             : %s\n", color.YellowString(endpoint+simulator.InspectionPath))
+	fmt.Printf(" - accounts               : %s\n", color.YellowString("%v", cmd.Accounts))
+	if cmd.Strict {
+		fmt.Printf(" - tokens                 : %s\n", color.YellowString("%d accepted", len(cmd.Tokens)))
+	} else {
+		fmt.Printf(" - tokens                 : %s\n", color.YellowString("any, registered on first use"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	slog.Info("serving simulated API", "address", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("error serving simulated API", "error", err)
+		return err
+	}
+	slog.Debug("simulator stopped")
+	return nil
+}
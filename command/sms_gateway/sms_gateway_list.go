@@ -6,7 +6,6 @@ import (
 
 	"github.com/dihedron/sms/command/base"
 	"github.com/dihedron/sms/format"
-	"github.com/dihedron/sms/rdcom"
 	"github.com/fatih/color"
 )
 
@@ -21,24 +20,7 @@ type List struct {
 func (cmd *List) Execute(args []string) error {
 	slog.Debug("called SMS gateway list command")
 
-	options := []rdcom.Option{
-		rdcom.WithBaseURL(cmd.Endpoint),
-		rdcom.WithUserAgent("bancaditalia/0.1"),
-	}
-	if cmd.SkipVerifyTLS {
-		options = append(options, rdcom.WithSkipTLSVerify(true))
-	}
-	if cmd.EnableDebug {
-		options = append(options, rdcom.WithDebug())
-	}
-	if cmd.EnableTrace {
-		options = append(options, rdcom.WithTrace())
-	}
-	if cmd.Token != nil {
-		options = append(options, rdcom.WithAuthToken(*cmd.Token))
-	}
-
-	client, err := rdcom.New(options...)
+	client, err := cmd.NewClient()
 	if err != nil {
 		slog.Error("error initialising API client", "error", err)
 		return err
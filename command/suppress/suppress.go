@@ -0,0 +1,23 @@
+package suppress
+
+type Suppress struct {
+	// Add is the command to add numbers to the suppression list.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Add Add `command:"add" alias:"a" description:"Add numbers to the suppression list."`
+
+	// Import is the command to import numbers from files.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Import Import `command:"import" alias:"imp" alias:"i" description:"Import numbers into the suppression list from files (e.g. CRM do-not-contact exports)."`
+
+	// List is the command to list suppressed numbers.
+	//lint:ignore SA5008 commands can have multiple aliases
+	List List `command:"list" alias:"ls" alias:"l" description:"List suppressed numbers."`
+
+	// Remove is the command to remove numbers from the suppression list.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Remove Remove `command:"remove" alias:"rm" alias:"r" description:"Remove numbers from the suppression list."`
+
+	// Sync is the command to synchronise with the platform blacklist.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Sync Sync `command:"sync" alias:"s" description:"Synchronise the suppression list with the platform blacklist."`
+}
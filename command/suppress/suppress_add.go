@@ -0,0 +1,63 @@
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/suppression"
+	"github.com/fatih/color"
+)
+
+// Add is the suppress add command.
+type Add struct {
+	base.Suppression
+	base.Recipients
+	// Reason is why the numbers are suppressed.
+	Reason *string `short:"r" long:"reason" description:"Why the numbers are suppressed."`
+	// Source is where the suppression comes from.
+	Source string `short:"s" long:"source" description:"Where the suppression comes from (e.g. stop, crm)." default:"manual"`
+}
+
+// Execute is the real implementation of the suppress add command.
+func (cmd *Add) Execute(args []string) error {
+	slog.Debug("called suppress add command", "args", args)
+
+	numbers, invalid, err := cmd.Recipients.Load(args)
+	if err != nil {
+		return err
+	}
+	for _, number := range invalid {
+		fmt.Printf("invalid: %s\n", color.RedString(number))
+	}
+	if len(numbers) == 0 {
+		slog.Error("no valid numbers provided")
+		return errors.New("no valid numbers provided")
+	}
+	return add(&cmd.Suppression, numbers, cmd.Reason, cmd.Source)
+}
+
+// add adds the numbers to the suppression list and prints a summary.
+func add(flags *base.Suppression, numbers []string, reason *string, source string) error {
+	list, err := flags.Open()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	entries := make([]suppression.Entry, 0, len(numbers))
+	for _, number := range numbers {
+		entry := suppression.Entry{Number: number, Source: source}
+		if reason != nil {
+			entry.Reason = *reason
+		}
+		entries = append(entries, entry)
+	}
+	added, err := list.Add(entries...)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	fmt.Printf("suppressed: %s (%s already in list)\n", color.YellowString(fmt.Sprintf("%d", added)), color.YellowString(fmt.Sprintf("%d", len(numbers)-added)))
+	return nil
+}
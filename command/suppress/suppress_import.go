@@ -0,0 +1,60 @@
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/fatih/color"
+)
+
+// Import is the suppress import command.
+type Import struct {
+	base.Suppression
+	base.Prefix
+	// Reason is why the numbers are suppressed.
+	Reason *string `short:"r" long:"reason" description:"Why the numbers are suppressed."`
+	// Source is where the suppression comes from.
+	Source string `short:"s" long:"source" description:"Where the suppression comes from (e.g. crm)." default:"import"`
+}
+
+// Execute is the real implementation of the suppress import command; files
+// hold one number per line, optionally followed by other comma or semicolon
+// separated columns, which are ignored.
+func (cmd *Import) Execute(args []string) error {
+	slog.Debug("called suppress import command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no files provided")
+		return errors.New("no files provided")
+	}
+
+	numbers := []string{}
+	seen := map[string]bool{}
+	invalid := 0
+	for _, arg := range args {
+		recipients := &base.Recipients{RecipientsFile: &arg, Prefix: cmd.Prefix}
+		valid, rejected, err := recipients.Load(nil)
+		if err != nil {
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			return err
+		}
+		for _, number := range rejected {
+			fmt.Printf("invalid: %s (%s)\n", color.RedString(number), arg)
+		}
+		invalid += len(rejected)
+		for _, number := range valid {
+			if !seen[number] {
+				seen[number] = true
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	slog.Info("numbers imported", "valid", len(numbers), "invalid", invalid)
+	if len(numbers) == 0 {
+		slog.Error("no valid numbers found")
+		return errors.New("no valid numbers found")
+	}
+	return add(&cmd.Suppression, numbers, cmd.Reason, cmd.Source)
+}
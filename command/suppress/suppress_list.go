@@ -0,0 +1,41 @@
+package suppress
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/fatih/color"
+)
+
+// List is the suppress list command.
+type List struct {
+	base.Suppression
+	// Source is the (optional) source to filter entries by.
+	Source *string `short:"s" long:"source" description:"Only list numbers from the given source."`
+}
+
+// Execute is the real implementation of the suppress list command.
+func (cmd *List) Execute(args []string) error {
+	slog.Debug("called suppress list command")
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	for _, entry := range list.Entries() {
+		if cmd.Source != nil && entry.Source != *cmd.Source {
+			continue
+		}
+		fmt.Printf("number: %s\n", color.YellowString(pii.PhoneOf(entry.Number)))
+		fmt.Printf(" - source                 : %s\n", color.YellowString(entry.Source))
+		if entry.Reason != "" {
+			fmt.Printf(" - reason                 : %s\n", color.YellowString(entry.Reason))
+		}
+		fmt.Printf(" - added                  : %s\n", color.YellowString(entry.Added.Format(base.DefaultDateFormat)))
+	}
+	return nil
+}
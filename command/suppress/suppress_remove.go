@@ -0,0 +1,57 @@
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
+	"github.com/fatih/color"
+)
+
+// Remove is the suppress remove command.
+type Remove struct {
+	base.Suppression
+	base.Prefix
+	base.Confirmation
+}
+
+// Execute is the real implementation of the suppress remove command.
+func (cmd *Remove) Execute(args []string) error {
+	slog.Debug("called suppress remove command", "args", args)
+
+	if len(args) == 0 {
+		slog.Error("no numbers provided")
+		return errors.New("no numbers provided")
+	}
+
+	numbers := make([]string, 0, len(args))
+	for _, arg := range args {
+		number, err := cmd.Normalise(arg)
+		if err != nil {
+			slog.Error("invalid number", "value", arg, "error", err)
+			return err
+		}
+		numbers = append(numbers, number)
+	}
+
+	// removed numbers can be messaged again
+	if ok, err := cmd.Confirm(fmt.Sprintf("remove %d number(s) from the suppression list?", len(numbers)), "numbers                : "+color.YellowString(strings.Join(pii.PhonesOf(numbers), ", "))); !ok {
+		return err
+	}
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	removed, err := list.Remove(numbers...)
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	fmt.Printf("removed: %s (%s not in list)\n", color.YellowString(fmt.Sprintf("%d", removed)), color.YellowString(fmt.Sprintf("%d", len(numbers)-removed)))
+	return nil
+}
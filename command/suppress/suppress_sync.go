@@ -0,0 +1,85 @@
+package suppress
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/dihedron/sms/suppression"
+	"github.com/fatih/color"
+)
+
+// Sync is the suppress sync command.
+type Sync struct {
+	base.TokenCommand
+	// Account is the account whose platform blacklist to synchronise with.
+	Account string `short:"a" long:"account" description:"The account whose platform blacklist to synchronise with." required:"yes" env:"SMS_ACCOUNT"`
+	// Push sets whether to also upload local entries to the platform.
+	Push bool `long:"push" description:"Also add the locally suppressed numbers to the platform blacklist." optional:"yes"`
+}
+
+// Execute is the real implementation of the suppress sync command; numbers
+// in the platform blacklist are always added to the local list, while local
+// numbers are only uploaded on request.
+func (cmd *Sync) Execute(args []string) error {
+	slog.Debug("called suppress sync command", "account", cmd.Account, "push", cmd.Push)
+
+	list, err := cmd.Suppression.Open()
+	if err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	blacklist, err := client.BlacklistService.List(cmd.Account)
+	if err != nil {
+		slog.Error("error performing blacklist list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	remote := map[string]bool{}
+	entries := make([]suppression.Entry, 0, len(blacklist))
+	for _, entry := range blacklist {
+		remote[entry.Number] = true
+		entries = append(entries, suppression.Entry{Number: entry.Number, Reason: entry.Reason, Source: "platform", Added: entry.Created})
+	}
+	pulled := 0
+	if cmd.DryRun {
+		// the local list is left untouched, only count what would be added
+		for _, entry := range entries {
+			if !list.Suppressed(entry.Number) {
+				pulled++
+			}
+		}
+	} else if pulled, err = list.Add(entries...); err != nil {
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return err
+	}
+	fmt.Printf("pulled: %s\n", color.YellowString(fmt.Sprintf("%d", pulled)))
+
+	if cmd.Push {
+		pushed := 0
+		for _, entry := range list.Entries() {
+			if remote[entry.Number] {
+				continue
+			}
+			if _, err := client.BlacklistService.Add(cmd.Account, &rdcom.BlacklistEntry{Number: entry.Number, Reason: entry.Reason}); err != nil {
+				slog.Error("error performing blacklist add API call", "number", entry.Number, "error", err)
+				fmt.Printf("error: %s\n", color.RedString(err.Error()))
+				return fmt.Errorf("error performing API call: %w", err)
+			}
+			pushed++
+		}
+		fmt.Printf("pushed: %s\n", color.YellowString(fmt.Sprintf("%d", pushed)))
+	}
+	return nil
+}
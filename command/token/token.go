@@ -1,5 +1,13 @@
 package token
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
 type Token struct {
 	// Create is the command to create a new token.
 	//lint:ignore SA5008 commands can have multiple aliases
@@ -12,4 +20,35 @@ type Token struct {
 	// List is the command to list tokens.
 	//lint:ignore SA5008 commands can have multiple aliases
 	List List `command:"list" alias:"ls" alias:"l" description:"List existing tokens."`
+
+	// Prune is the command to delete expired tokens.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Prune Prune `command:"prune" alias:"pr" alias:"p" description:"Delete the tokens that are expired or about to expire."`
+
+	// Rotate is the command to replace the token in use.
+	//lint:ignore SA5008 commands can have multiple aliases
+	Rotate Rotate `command:"rotate" alias:"rot" alias:"r" description:"Replace the token in use with a new one, updating the active profile."`
+}
+
+// describe returns the description of a token in a confirmation summary,
+// highlighting the one used for authentication.
+func describe(token *rdcom.Token, current *string) string {
+	description := "token                  : " + color.YellowString(token.Token)
+	if !token.ExpiryDate.IsZero() {
+		description += " (expires on " + token.ExpiryDate.Format(time.RFC3339) + ")"
+	}
+	if current != nil && token.Token == *current {
+		description += " " + color.RedString("(in use)")
+	}
+	return description
+}
+
+// printToken prints a token and its expiration date.
+func printToken(token *rdcom.Token) {
+	expiry := token.ExpiryDate
+	if expiry.IsZero() {
+		fmt.Printf("token: %s (no expiration)\n", color.YellowString(token.Token))
+	} else {
+		fmt.Printf("token: %s (expires on %s)\n", color.YellowString(token.Token), color.YellowString(expiry.Format(time.RFC3339)))
+	}
 }
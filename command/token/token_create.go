@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/dihedron/sms/command/base"
-	"github.com/dihedron/sms/rdcom"
 	"github.com/fatih/color"
 )
 
@@ -19,28 +18,7 @@ type Create struct {
 func (cmd *Create) Execute(args []string) error {
 	slog.Debug("called token create command")
 
-	options := []rdcom.Option{
-		rdcom.WithBaseURL(cmd.Endpoint),
-		rdcom.WithUserAgent("bancaditalia/0.1"),
-	}
-	if cmd.SkipVerifyTLS {
-		options = append(options, rdcom.WithSkipTLSVerify(true))
-	}
-	if cmd.EnableDebug {
-		options = append(options, rdcom.WithDebug())
-	}
-	if cmd.EnableTrace {
-		options = append(options, rdcom.WithTrace())
-	}
-	if cmd.Token != nil {
-		options = append(options, rdcom.WithAuthToken(*cmd.Token))
-	}
-
-	// if cmd.Username != "" && cmd.Password != "" {
-	// 	options = append(options, rdcom.WithUserCredentials(cmd.Username, cmd.Password))
-	// }
-
-	client, err := rdcom.New(options...)
+	client, err := cmd.NewClient()
 	if err != nil {
 		slog.Error("error initialising API client", "error", err)
 		return err
@@ -12,6 +12,7 @@ import (
 
 type Delete struct {
 	base.TokenCommand
+	base.Confirmation
 }
 
 // Execute is the real implementation of the token delete command.
@@ -23,29 +24,15 @@ func (cmd *Delete) Execute(args []string) error {
 		return fmt.Errorf("no token ID provided")
 	}
 
-	options := []rdcom.Option{
-		rdcom.WithBaseURL(cmd.Endpoint),
-		rdcom.WithUserAgent("bancaditalia/0.1"),
-	}
-	if cmd.SkipVerifyTLS {
-		options = append(options, rdcom.WithSkipTLSVerify(true))
-	}
-	if cmd.EnableDebug {
-		options = append(options, rdcom.WithDebug())
-	}
-	if cmd.EnableTrace {
-		options = append(options, rdcom.WithTrace())
+	summary := make([]string, 0, len(args))
+	for _, arg := range args {
+		summary = append(summary, describe(&rdcom.Token{Token: arg}, cmd.Token))
 	}
-
-	if cmd.Token != nil {
-		options = append(options, rdcom.WithAuthToken(*cmd.Token))
+	if ok, err := cmd.Confirm(fmt.Sprintf("delete %d token(s)?", len(args)), summary...); !ok {
+		return err
 	}
 
-	// if cmd.Username != "" && cmd.Password != "" {
-	// 	options = append(options, rdcom.WithUserCredentials(cmd.Username, cmd.Password))
-	// }
-
-	client, err := rdcom.New(options...)
+	client, err := cmd.NewClient()
 	if err != nil {
 		slog.Error("error initialising API client", "error", err)
 		return err
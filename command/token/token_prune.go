@@ -0,0 +1,98 @@
+package token
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// Prune is the token prune command.
+type Prune struct {
+	base.TokenCommand
+	base.Confirmation
+	// Within is the number of days within which expiring tokens are pruned.
+	Within int `short:"w" long:"within" description:"Also prune the tokens expiring within the given number of days." default:"0"`
+	// KeepCurrent sets whether the token in use is never pruned.
+	KeepCurrent bool `short:"k" long:"keep-current" description:"Whether to keep the token used for authentication, even if it expires." optional:"yes"`
+}
+
+// Execute is the real implementation of the token prune command; tokens
+// without an expiration date are never pruned.
+func (cmd *Prune) Execute(args []string) error {
+	slog.Debug("called token prune command", "within", cmd.Within, "keep current", cmd.KeepCurrent)
+
+	if cmd.Within < 0 {
+		slog.Error("invalid number of days", "within", cmd.Within)
+		return fmt.Errorf("invalid number of days: %d", cmd.Within)
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	tokens, err := client.TokenService.List()
+	if err != nil {
+		slog.Error("error performing token list API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+
+	threshold := time.Now().AddDate(0, 0, cmd.Within)
+	candidates := []rdcom.Token{}
+	kept := 0
+	for _, token := range tokens {
+		switch {
+		case token.ExpiryDate.IsZero() || token.ExpiryDate.After(threshold):
+			kept++
+		case cmd.KeepCurrent && cmd.Token != nil && token.Token == *cmd.Token:
+			slog.Debug("keeping current token")
+			fmt.Printf("token: %s (current, kept)\n", color.GreenString(token.Token))
+			kept++
+		default:
+			candidates = append(candidates, token)
+		}
+	}
+
+	if len(candidates) == 0 {
+		fmt.Printf("no tokens to prune (%s kept)\n", color.YellowString(fmt.Sprintf("%d", kept)))
+		return nil
+	}
+	summary := make([]string, 0, len(candidates))
+	for _, token := range candidates {
+		summary = append(summary, describe(&token, cmd.Token))
+	}
+	if ok, err := cmd.Confirm(fmt.Sprintf("delete %d token(s)?", len(candidates)), summary...); !ok {
+		return err
+	}
+
+	deleted, failed := 0, 0
+	for _, token := range candidates {
+		if cmd.Token != nil && token.Token == *cmd.Token {
+			slog.Warn("deleting the token used for authentication")
+		}
+		if _, err := client.TokenService.Delete(token.Token); err != nil {
+			slog.Error("error performing token delete API call", "error", err)
+			fmt.Printf("token: %s (%s)\n", color.RedString(token.Token), color.RedString(err.Error()))
+			failed++
+			continue
+		}
+		deleted++
+	}
+
+	fmt.Printf("summary:\n")
+	fmt.Printf(" - deleted                : %s\n", color.GreenString(fmt.Sprintf("%d", deleted)))
+	fmt.Printf(" - failed                 : %s\n", color.RedString(fmt.Sprintf("%d", failed)))
+	fmt.Printf(" - kept                   : %s\n", color.YellowString(fmt.Sprintf("%d", kept)))
+	if failed > 0 {
+		return fmt.Errorf("%d token(s) could not be deleted", failed)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package token
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/metadata"
+	"github.com/dihedron/sms/rdcom"
+	"github.com/fatih/color"
+)
+
+// TokenVarName is the name of the variable holding the token in profiles.
+const TokenVarName = "SMS_TOKEN"
+
+// Rotate is the token rotate command.
+type Rotate struct {
+	base.TokenCommand
+	base.Confirmation
+	// Profile is the path to the profile (.env file) to update.
+	Profile *string `short:"p" long:"profile" description:"The path to the profile (.env file) to update with the new token (default: the active profile)."`
+	// KeepOld sets whether the old token is kept after rotation.
+	KeepOld bool `short:"k" long:"keep-old" description:"Whether to keep the old token instead of deleting it." optional:"yes"`
+}
+
+// Execute is the real implementation of the token rotate command: a new
+// token is created and probed, the profile is updated and the old token is
+// deleted; if any step before the profile update fails, the new token is
+// deleted and the old one is left in place.
+func (cmd *Rotate) Execute(args []string) error {
+	slog.Debug("called token rotate command", "keep old", cmd.KeepOld)
+
+	if err := cmd.RefuseDryRun("token rotate"); err != nil {
+		return err
+	}
+	if cmd.Token == nil || *cmd.Token == "" {
+		slog.Error("no token to rotate")
+		return errors.New("no token to rotate")
+	}
+	old := *cmd.Token
+
+	profile := ""
+	if cmd.Profile != nil {
+		profile = *cmd.Profile
+	} else if metadata.DotEnvVarName != "" {
+		profile = os.Getenv(metadata.DotEnvVarName)
+	}
+	if profile == "" {
+		slog.Warn("no active profile, the new token will only be printed")
+	}
+
+	if ok, err := cmd.Confirm(fmt.Sprintf("rotate the current token%s?", profileHint(profile))); !ok {
+		return err
+	}
+
+	client, err := cmd.NewClient()
+	if err != nil {
+		slog.Error("error initialising API client", "error", err)
+		return err
+	}
+
+	defer client.Close()
+
+	token, err := client.TokenService.Create()
+	if err != nil {
+		slog.Error("error performing token create API call", "error", err)
+		fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		return fmt.Errorf("error performing API call: %w", err)
+	}
+	fmt.Printf("created: %s\n", color.GreenString(token.Token))
+
+	// probe the new token before relying on it
+	rotated, err := cmd.NewClient(rdcom.WithAuthToken(token.Token))
+	if err == nil {
+		defer rotated.Close()
+		_, err = rotated.TokenService.List()
+	}
+	if err != nil {
+		slog.Error("error probing the new token", "error", err)
+		fmt.Printf("probe: %s\n", color.RedString("KO"))
+		if _, err := client.TokenService.Delete(token.Token); err != nil {
+			slog.Error("error deleting the new token", "error", err)
+		}
+		return fmt.Errorf("error probing the new token: %w", err)
+	}
+	fmt.Printf("probe: %s\n", color.GreenString("OK"))
+
+	if profile != "" {
+		if err := updateProfile(profile, token.Token); err != nil {
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+			if _, err := rotated.TokenService.Delete(token.Token); err != nil {
+				slog.Error("error deleting the new token", "error", err)
+			}
+			return fmt.Errorf("error updating profile: %w", err)
+		}
+		fmt.Printf("profile: %s\n", color.GreenString(profile))
+	}
+
+	deleted := false
+	if !cmd.KeepOld {
+		// the old token is deleted with the new one, which is known to work
+		if _, err := rotated.TokenService.Delete(old); err != nil {
+			slog.Error("error deleting the old token", "error", err)
+			fmt.Printf("error: %s\n", color.RedString(err.Error()))
+		} else {
+			deleted = true
+		}
+	}
+
+	fmt.Printf("summary:\n")
+	fmt.Printf(" - new token              : %s\n", color.GreenString(token.Token))
+	if !token.ExpiryDate.IsZero() {
+		fmt.Printf(" - expires on             : %s\n", color.YellowString(token.ExpiryDate.Local().Format(base.DefaultDateFormat)))
+	}
+	if profile != "" {
+		fmt.Printf(" - profile                : %s\n", color.YellowString(profile))
+	} else {
+		fmt.Printf(" - profile                : %s\n", color.RedString("none, set %s manually", TokenVarName))
+	}
+	switch {
+	case deleted:
+		fmt.Printf(" - old token              : %s\n", color.GreenString("deleted"))
+	case cmd.KeepOld:
+		fmt.Printf(" - old token              : %s\n", color.YellowString("kept"))
+	default:
+		fmt.Printf(" - old token              : %s\n", color.RedString("not deleted"))
+		return errors.New("error deleting the old token")
+	}
+	return nil
+}
+
+// profileHint returns the description of the profile to update, if any.
+func profileHint(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return " and update " + profile
+}
+
+// updateProfile replaces the token in a profile (.env file), leaving all the
+// other lines untouched; if the profile has no token, one is appended. The
+// file is replaced atomically.
+func updateProfile(path string, token string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("error reading profile", "path", path, "error", err)
+		return err
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	updated := &bytes.Buffer{}
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if ok && strings.TrimSpace(name) == TokenVarName {
+			prefix := line[:strings.Index(line, "=")+1]
+			line, found = prefix+token, true
+		}
+		updated.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("error parsing profile", "path", path, "error", err)
+		return err
+	}
+	if !found {
+		fmt.Fprintf(updated, "%s=%s\n", TokenVarName, token)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		slog.Error("error creating temporary profile", "path", path, "error", err)
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err = temp.Write(updated.Bytes()); err == nil {
+		err = temp.Chmod(mode)
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		slog.Error("error writing profile", "path", path, "error", err)
+		return err
+	}
+	slog.Debug("profile updated", "path", path)
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/dihedron/sms/pii"
 	"github.com/fatih/color"
 	"github.com/goccy/go-json"
 
@@ -19,21 +20,24 @@ func TypeAsString(v any) string {
 	return fmt.Sprintf("%T", v)
 }
 
-// ToJSON outputs the given object as a JSON string.
+// ToJSON outputs the given object as a JSON string; PII is masked unless
+// explicitly shown.
 func ToJSON(v any) string {
-	d, _ := json.Marshal(v)
+	d, _ := json.Marshal(pii.Redact(v))
 	return string(d)
 }
 
-// ToPrettyJSON outputs the given object as a formatted JSON string.
+// ToPrettyJSON outputs the given object as a formatted JSON string; PII is
+// masked unless explicitly shown.
 func ToPrettyJSON(v any) string {
-	d, _ := json.MarshalIndent(v, "", "  ")
+	d, _ := json.MarshalIndent(pii.Redact(v), "", "  ")
 	return string(d)
 }
 
-// ToYAML output the given object as a formatted YAML string.
+// ToYAML output the given object as a formatted YAML string; PII is masked
+// unless explicitly shown.
 func ToYAML(v any) string {
-	d, _ := yaml.Marshal(v)
+	d, _ := yaml.Marshal(pii.Redact(v))
 	return string(d)
 }
 
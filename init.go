@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/dihedron/sms/metadata"
+	"github.com/dihedron/sms/pii"
 	"github.com/joho/godotenv"
 )
 
@@ -21,8 +22,9 @@ func init() {
 	const LevelNone = slog.Level(1000)
 
 	options := &slog.HandlerOptions{
-		Level:     LevelNone,
-		AddSource: true,
+		Level:       LevelNone,
+		AddSource:   true,
+		ReplaceAttr: pii.ReplaceAttr,
 	}
 
 	// my-app -> MY_APP_LOG_LEVEL
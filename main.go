@@ -16,15 +16,31 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/dihedron/sms/command"
+	"github.com/dihedron/sms/command/base"
+	"github.com/dihedron/sms/pii"
 	"github.com/jessevdk/go-flags"
 )
 
 func main() {
 	options := command.Commands{}
-	if _, err := flags.NewParser(&options, flags.Default).Parse(); err != nil {
+	parser := flags.NewParser(&options, flags.Default)
+	// global options are applied once parsed, before the command runs
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		pii.Show(options.ShowPII)
+		if command == nil {
+			return nil
+		}
+		return command.Execute(args)
+	}
+	if _, err := parser.Parse(); err != nil {
+		var exit *base.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
 		switch flagsErr := err.(type) {
 		case flags.ErrorType:
 			if flagsErr == flags.ErrHelp {
@@ -120,3 +120,9 @@ func PrintFull(writer io.Writer) {
 	fmt.Printf("  - Build Commit              : %s\n", GitCommit)
 	fmt.Printf("  - Variable Name (.env)      : %s\n", DotEnvVarName)
 }
+
+// UserAgent returns the user agent the application identifies itself with
+// in HTTP requests (e.g. "sms/1.0.3 (linux/amd64)").
+func UserAgent() string {
+	return fmt.Sprintf("%s/%s.%s.%s (%s/%s)", Name, VersionMajor, VersionMinor, VersionPatch, GoOS, GoArch)
+}
@@ -0,0 +1,337 @@
+package phone
+
+import (
+	"strings"
+)
+
+// Country represents a country (or territory) in the international
+// numbering plan.
+type Country struct {
+	// Code is the ISO 3166-1 alpha-2 code of the country (e.g. "IT").
+	Code string `json:"code" yaml:"code"`
+	// Name is the English short name of the country.
+	Name string `json:"name" yaml:"name"`
+	// Prefix is the international calling code, without the leading
+	// "+" (e.g. "39"); it may include the area code for territories
+	// sharing a calling code (e.g. "1787" for Puerto Rico).
+	Prefix string `json:"prefix" yaml:"prefix"`
+}
+
+// CountryOf returns the country the given E.164 phone number belongs to,
+// using a longest-prefix match on the calling code; numbers in the North
+// American Numbering Plan that do not match any specific area code are
+// attributed to the United States.
+func CountryOf(number string) (Country, bool) {
+	digits := strings.TrimPrefix(number, "+")
+	for length := 4; length > 0; length-- {
+		if len(digits) < length {
+			continue
+		}
+		if country, ok := prefixes[digits[:length]]; ok {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+// CountryByCode returns the country with the given ISO 3166-1 alpha-2 code.
+func CountryByCode(code string) (Country, bool) {
+	code = strings.ToUpper(code)
+	for _, country := range countries {
+		if country.Code == code {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+// prefixes indexes the countries by their calling code.
+var prefixes = func() map[string]Country {
+	index := make(map[string]Country, len(countries))
+	for _, country := range countries {
+		if _, ok := index[country.Prefix]; !ok {
+			index[country.Prefix] = country
+		}
+	}
+	return index
+}()
+
+// countries is the list of known countries; where several countries share
+// the same calling code, the first one listed wins the plain prefix lookup.
+var countries = []Country{
+	// North American Numbering Plan
+	{Code: "US", Name: "United States", Prefix: "1"},
+	{Code: "CA", Name: "Canada", Prefix: "1204"},
+	{Code: "CA", Name: "Canada", Prefix: "1226"},
+	{Code: "CA", Name: "Canada", Prefix: "1236"},
+	{Code: "CA", Name: "Canada", Prefix: "1249"},
+	{Code: "CA", Name: "Canada", Prefix: "1250"},
+	{Code: "CA", Name: "Canada", Prefix: "1289"},
+	{Code: "CA", Name: "Canada", Prefix: "1306"},
+	{Code: "CA", Name: "Canada", Prefix: "1343"},
+	{Code: "CA", Name: "Canada", Prefix: "1365"},
+	{Code: "CA", Name: "Canada", Prefix: "1403"},
+	{Code: "CA", Name: "Canada", Prefix: "1416"},
+	{Code: "CA", Name: "Canada", Prefix: "1418"},
+	{Code: "CA", Name: "Canada", Prefix: "1431"},
+	{Code: "CA", Name: "Canada", Prefix: "1437"},
+	{Code: "CA", Name: "Canada", Prefix: "1438"},
+	{Code: "CA", Name: "Canada", Prefix: "1450"},
+	{Code: "CA", Name: "Canada", Prefix: "1506"},
+	{Code: "CA", Name: "Canada", Prefix: "1514"},
+	{Code: "CA", Name: "Canada", Prefix: "1519"},
+	{Code: "CA", Name: "Canada", Prefix: "1548"},
+	{Code: "CA", Name: "Canada", Prefix: "1579"},
+	{Code: "CA", Name: "Canada", Prefix: "1581"},
+	{Code: "CA", Name: "Canada", Prefix: "1587"},
+	{Code: "CA", Name: "Canada", Prefix: "1604"},
+	{Code: "CA", Name: "Canada", Prefix: "1613"},
+	{Code: "CA", Name: "Canada", Prefix: "1639"},
+	{Code: "CA", Name: "Canada", Prefix: "1647"},
+	{Code: "CA", Name: "Canada", Prefix: "1705"},
+	{Code: "CA", Name: "Canada", Prefix: "1709"},
+	{Code: "CA", Name: "Canada", Prefix: "1778"},
+	{Code: "CA", Name: "Canada", Prefix: "1780"},
+	{Code: "CA", Name: "Canada", Prefix: "1782"},
+	{Code: "CA", Name: "Canada", Prefix: "1807"},
+	{Code: "CA", Name: "Canada", Prefix: "1819"},
+	{Code: "CA", Name: "Canada", Prefix: "1825"},
+	{Code: "CA", Name: "Canada", Prefix: "1867"},
+	{Code: "CA", Name: "Canada", Prefix: "1873"},
+	{Code: "CA", Name: "Canada", Prefix: "1902"},
+	{Code: "CA", Name: "Canada", Prefix: "1905"},
+	{Code: "AG", Name: "Antigua and Barbuda", Prefix: "1268"},
+	{Code: "AI", Name: "Anguilla", Prefix: "1264"},
+	{Code: "AS", Name: "American Samoa", Prefix: "1684"},
+	{Code: "BB", Name: "Barbados", Prefix: "1246"},
+	{Code: "BM", Name: "Bermuda", Prefix: "1441"},
+	{Code: "BS", Name: "Bahamas", Prefix: "1242"},
+	{Code: "DM", Name: "Dominica", Prefix: "1767"},
+	{Code: "DO", Name: "Dominican Republic", Prefix: "1809"},
+	{Code: "DO", Name: "Dominican Republic", Prefix: "1829"},
+	{Code: "DO", Name: "Dominican Republic", Prefix: "1849"},
+	{Code: "GD", Name: "Grenada", Prefix: "1473"},
+	{Code: "GU", Name: "Guam", Prefix: "1671"},
+	{Code: "JM", Name: "Jamaica", Prefix: "1876"},
+	{Code: "KN", Name: "Saint Kitts and Nevis", Prefix: "1869"},
+	{Code: "KY", Name: "Cayman Islands", Prefix: "1345"},
+	{Code: "LC", Name: "Saint Lucia", Prefix: "1758"},
+	{Code: "MP", Name: "Northern Mariana Islands", Prefix: "1670"},
+	{Code: "MS", Name: "Montserrat", Prefix: "1664"},
+	{Code: "PR", Name: "Puerto Rico", Prefix: "1787"},
+	{Code: "PR", Name: "Puerto Rico", Prefix: "1939"},
+	{Code: "SX", Name: "Sint Maarten", Prefix: "1721"},
+	{Code: "TC", Name: "Turks and Caicos Islands", Prefix: "1649"},
+	{Code: "TT", Name: "Trinidad and Tobago", Prefix: "1868"},
+	{Code: "VC", Name: "Saint Vincent and the Grenadines", Prefix: "1784"},
+	{Code: "VG", Name: "British Virgin Islands", Prefix: "1284"},
+	{Code: "VI", Name: "U.S. Virgin Islands", Prefix: "1340"},
+	// zone 2
+	{Code: "EG", Name: "Egypt", Prefix: "20"},
+	{Code: "SS", Name: "South Sudan", Prefix: "211"},
+	{Code: "MA", Name: "Morocco", Prefix: "212"},
+	{Code: "DZ", Name: "Algeria", Prefix: "213"},
+	{Code: "TN", Name: "Tunisia", Prefix: "216"},
+	{Code: "LY", Name: "Libya", Prefix: "218"},
+	{Code: "GM", Name: "Gambia", Prefix: "220"},
+	{Code: "SN", Name: "Senegal", Prefix: "221"},
+	{Code: "MR", Name: "Mauritania", Prefix: "222"},
+	{Code: "ML", Name: "Mali", Prefix: "223"},
+	{Code: "GN", Name: "Guinea", Prefix: "224"},
+	{Code: "CI", Name: "Côte d'Ivoire", Prefix: "225"},
+	{Code: "BF", Name: "Burkina Faso", Prefix: "226"},
+	{Code: "NE", Name: "Niger", Prefix: "227"},
+	{Code: "TG", Name: "Togo", Prefix: "228"},
+	{Code: "BJ", Name: "Benin", Prefix: "229"},
+	{Code: "MU", Name: "Mauritius", Prefix: "230"},
+	{Code: "LR", Name: "Liberia", Prefix: "231"},
+	{Code: "SL", Name: "Sierra Leone", Prefix: "232"},
+	{Code: "GH", Name: "Ghana", Prefix: "233"},
+	{Code: "NG", Name: "Nigeria", Prefix: "234"},
+	{Code: "TD", Name: "Chad", Prefix: "235"},
+	{Code: "CF", Name: "Central African Republic", Prefix: "236"},
+	{Code: "CM", Name: "Cameroon", Prefix: "237"},
+	{Code: "CV", Name: "Cape Verde", Prefix: "238"},
+	{Code: "ST", Name: "São Tomé and Príncipe", Prefix: "239"},
+	{Code: "GQ", Name: "Equatorial Guinea", Prefix: "240"},
+	{Code: "GA", Name: "Gabon", Prefix: "241"},
+	{Code: "CG", Name: "Congo", Prefix: "242"},
+	{Code: "CD", Name: "Democratic Republic of the Congo", Prefix: "243"},
+	{Code: "AO", Name: "Angola", Prefix: "244"},
+	{Code: "GW", Name: "Guinea-Bissau", Prefix: "245"},
+	{Code: "SC", Name: "Seychelles", Prefix: "248"},
+	{Code: "SD", Name: "Sudan", Prefix: "249"},
+	{Code: "RW", Name: "Rwanda", Prefix: "250"},
+	{Code: "ET", Name: "Ethiopia", Prefix: "251"},
+	{Code: "SO", Name: "Somalia", Prefix: "252"},
+	{Code: "DJ", Name: "Djibouti", Prefix: "253"},
+	{Code: "KE", Name: "Kenya", Prefix: "254"},
+	{Code: "TZ", Name: "Tanzania", Prefix: "255"},
+	{Code: "UG", Name: "Uganda", Prefix: "256"},
+	{Code: "BI", Name: "Burundi", Prefix: "257"},
+	{Code: "MZ", Name: "Mozambique", Prefix: "258"},
+	{Code: "ZM", Name: "Zambia", Prefix: "260"},
+	{Code: "MG", Name: "Madagascar", Prefix: "261"},
+	{Code: "RE", Name: "Réunion", Prefix: "262"},
+	{Code: "ZW", Name: "Zimbabwe", Prefix: "263"},
+	{Code: "NA", Name: "Namibia", Prefix: "264"},
+	{Code: "MW", Name: "Malawi", Prefix: "265"},
+	{Code: "LS", Name: "Lesotho", Prefix: "266"},
+	{Code: "BW", Name: "Botswana", Prefix: "267"},
+	{Code: "SZ", Name: "Eswatini", Prefix: "268"},
+	{Code: "KM", Name: "Comoros", Prefix: "269"},
+	{Code: "ZA", Name: "South Africa", Prefix: "27"},
+	{Code: "ER", Name: "Eritrea", Prefix: "291"},
+	{Code: "AW", Name: "Aruba", Prefix: "297"},
+	{Code: "FO", Name: "Faroe Islands", Prefix: "298"},
+	{Code: "GL", Name: "Greenland", Prefix: "299"},
+	// zone 3
+	{Code: "GR", Name: "Greece", Prefix: "30"},
+	{Code: "NL", Name: "Netherlands", Prefix: "31"},
+	{Code: "BE", Name: "Belgium", Prefix: "32"},
+	{Code: "FR", Name: "France", Prefix: "33"},
+	{Code: "ES", Name: "Spain", Prefix: "34"},
+	{Code: "GI", Name: "Gibraltar", Prefix: "350"},
+	{Code: "PT", Name: "Portugal", Prefix: "351"},
+	{Code: "LU", Name: "Luxembourg", Prefix: "352"},
+	{Code: "IE", Name: "Ireland", Prefix: "353"},
+	{Code: "IS", Name: "Iceland", Prefix: "354"},
+	{Code: "AL", Name: "Albania", Prefix: "355"},
+	{Code: "MT", Name: "Malta", Prefix: "356"},
+	{Code: "CY", Name: "Cyprus", Prefix: "357"},
+	{Code: "FI", Name: "Finland", Prefix: "358"},
+	{Code: "BG", Name: "Bulgaria", Prefix: "359"},
+	{Code: "HU", Name: "Hungary", Prefix: "36"},
+	{Code: "LT", Name: "Lithuania", Prefix: "370"},
+	{Code: "LV", Name: "Latvia", Prefix: "371"},
+	{Code: "EE", Name: "Estonia", Prefix: "372"},
+	{Code: "MD", Name: "Moldova", Prefix: "373"},
+	{Code: "AM", Name: "Armenia", Prefix: "374"},
+	{Code: "BY", Name: "Belarus", Prefix: "375"},
+	{Code: "AD", Name: "Andorra", Prefix: "376"},
+	{Code: "MC", Name: "Monaco", Prefix: "377"},
+	{Code: "SM", Name: "San Marino", Prefix: "378"},
+	{Code: "VA", Name: "Vatican City", Prefix: "379"},
+	{Code: "UA", Name: "Ukraine", Prefix: "380"},
+	{Code: "RS", Name: "Serbia", Prefix: "381"},
+	{Code: "ME", Name: "Montenegro", Prefix: "382"},
+	{Code: "XK", Name: "Kosovo", Prefix: "383"},
+	{Code: "HR", Name: "Croatia", Prefix: "385"},
+	{Code: "SI", Name: "Slovenia", Prefix: "386"},
+	{Code: "BA", Name: "Bosnia and Herzegovina", Prefix: "387"},
+	{Code: "MK", Name: "North Macedonia", Prefix: "389"},
+	{Code: "IT", Name: "Italy", Prefix: "39"},
+	// zone 4
+	{Code: "RO", Name: "Romania", Prefix: "40"},
+	{Code: "CH", Name: "Switzerland", Prefix: "41"},
+	{Code: "CZ", Name: "Czechia", Prefix: "420"},
+	{Code: "SK", Name: "Slovakia", Prefix: "421"},
+	{Code: "LI", Name: "Liechtenstein", Prefix: "423"},
+	{Code: "AT", Name: "Austria", Prefix: "43"},
+	{Code: "GB", Name: "United Kingdom", Prefix: "44"},
+	{Code: "DK", Name: "Denmark", Prefix: "45"},
+	{Code: "SE", Name: "Sweden", Prefix: "46"},
+	{Code: "NO", Name: "Norway", Prefix: "47"},
+	{Code: "PL", Name: "Poland", Prefix: "48"},
+	{Code: "DE", Name: "Germany", Prefix: "49"},
+	// zone 5
+	{Code: "FK", Name: "Falkland Islands", Prefix: "500"},
+	{Code: "BZ", Name: "Belize", Prefix: "501"},
+	{Code: "GT", Name: "Guatemala", Prefix: "502"},
+	{Code: "SV", Name: "El Salvador", Prefix: "503"},
+	{Code: "HN", Name: "Honduras", Prefix: "504"},
+	{Code: "NI", Name: "Nicaragua", Prefix: "505"},
+	{Code: "CR", Name: "Costa Rica", Prefix: "506"},
+	{Code: "PA", Name: "Panama", Prefix: "507"},
+	{Code: "PM", Name: "Saint Pierre and Miquelon", Prefix: "508"},
+	{Code: "HT", Name: "Haiti", Prefix: "509"},
+	{Code: "PE", Name: "Peru", Prefix: "51"},
+	{Code: "MX", Name: "Mexico", Prefix: "52"},
+	{Code: "CU", Name: "Cuba", Prefix: "53"},
+	{Code: "AR", Name: "Argentina", Prefix: "54"},
+	{Code: "BR", Name: "Brazil", Prefix: "55"},
+	{Code: "CL", Name: "Chile", Prefix: "56"},
+	{Code: "CO", Name: "Colombia", Prefix: "57"},
+	{Code: "VE", Name: "Venezuela", Prefix: "58"},
+	{Code: "GP", Name: "Guadeloupe", Prefix: "590"},
+	{Code: "BO", Name: "Bolivia", Prefix: "591"},
+	{Code: "GY", Name: "Guyana", Prefix: "592"},
+	{Code: "EC", Name: "Ecuador", Prefix: "593"},
+	{Code: "GF", Name: "French Guiana", Prefix: "594"},
+	{Code: "PY", Name: "Paraguay", Prefix: "595"},
+	{Code: "MQ", Name: "Martinique", Prefix: "596"},
+	{Code: "SR", Name: "Suriname", Prefix: "597"},
+	{Code: "UY", Name: "Uruguay", Prefix: "598"},
+	{Code: "CW", Name: "Curaçao", Prefix: "5999"},
+	// zone 6
+	{Code: "MY", Name: "Malaysia", Prefix: "60"},
+	{Code: "AU", Name: "Australia", Prefix: "61"},
+	{Code: "ID", Name: "Indonesia", Prefix: "62"},
+	{Code: "PH", Name: "Philippines", Prefix: "63"},
+	{Code: "NZ", Name: "New Zealand", Prefix: "64"},
+	{Code: "SG", Name: "Singapore", Prefix: "65"},
+	{Code: "TH", Name: "Thailand", Prefix: "66"},
+	{Code: "TL", Name: "Timor-Leste", Prefix: "670"},
+	{Code: "BN", Name: "Brunei", Prefix: "673"},
+	{Code: "NR", Name: "Nauru", Prefix: "674"},
+	{Code: "PG", Name: "Papua New Guinea", Prefix: "675"},
+	{Code: "TO", Name: "Tonga", Prefix: "676"},
+	{Code: "SB", Name: "Solomon Islands", Prefix: "677"},
+	{Code: "VU", Name: "Vanuatu", Prefix: "678"},
+	{Code: "FJ", Name: "Fiji", Prefix: "679"},
+	{Code: "PW", Name: "Palau", Prefix: "680"},
+	{Code: "WS", Name: "Samoa", Prefix: "685"},
+	{Code: "KI", Name: "Kiribati", Prefix: "686"},
+	{Code: "NC", Name: "New Caledonia", Prefix: "687"},
+	{Code: "TV", Name: "Tuvalu", Prefix: "688"},
+	{Code: "PF", Name: "French Polynesia", Prefix: "689"},
+	{Code: "FM", Name: "Micronesia", Prefix: "691"},
+	{Code: "MH", Name: "Marshall Islands", Prefix: "692"},
+	// zone 7
+	{Code: "RU", Name: "Russia", Prefix: "7"},
+	{Code: "KZ", Name: "Kazakhstan", Prefix: "76"},
+	{Code: "KZ", Name: "Kazakhstan", Prefix: "77"},
+	// zone 8
+	{Code: "JP", Name: "Japan", Prefix: "81"},
+	{Code: "KR", Name: "South Korea", Prefix: "82"},
+	{Code: "VN", Name: "Vietnam", Prefix: "84"},
+	{Code: "KP", Name: "North Korea", Prefix: "850"},
+	{Code: "HK", Name: "Hong Kong", Prefix: "852"},
+	{Code: "MO", Name: "Macao", Prefix: "853"},
+	{Code: "KH", Name: "Cambodia", Prefix: "855"},
+	{Code: "LA", Name: "Laos", Prefix: "856"},
+	{Code: "CN", Name: "China", Prefix: "86"},
+	{Code: "BD", Name: "Bangladesh", Prefix: "880"},
+	{Code: "TW", Name: "Taiwan", Prefix: "886"},
+	// zone 9
+	{Code: "TR", Name: "Turkey", Prefix: "90"},
+	{Code: "IN", Name: "India", Prefix: "91"},
+	{Code: "PK", Name: "Pakistan", Prefix: "92"},
+	{Code: "AF", Name: "Afghanistan", Prefix: "93"},
+	{Code: "LK", Name: "Sri Lanka", Prefix: "94"},
+	{Code: "MM", Name: "Myanmar", Prefix: "95"},
+	{Code: "MV", Name: "Maldives", Prefix: "960"},
+	{Code: "LB", Name: "Lebanon", Prefix: "961"},
+	{Code: "JO", Name: "Jordan", Prefix: "962"},
+	{Code: "SY", Name: "Syria", Prefix: "963"},
+	{Code: "IQ", Name: "Iraq", Prefix: "964"},
+	{Code: "KW", Name: "Kuwait", Prefix: "965"},
+	{Code: "SA", Name: "Saudi Arabia", Prefix: "966"},
+	{Code: "YE", Name: "Yemen", Prefix: "967"},
+	{Code: "OM", Name: "Oman", Prefix: "968"},
+	{Code: "PS", Name: "Palestine", Prefix: "970"},
+	{Code: "AE", Name: "United Arab Emirates", Prefix: "971"},
+	{Code: "IL", Name: "Israel", Prefix: "972"},
+	{Code: "BH", Name: "Bahrain", Prefix: "973"},
+	{Code: "QA", Name: "Qatar", Prefix: "974"},
+	{Code: "BT", Name: "Bhutan", Prefix: "975"},
+	{Code: "MN", Name: "Mongolia", Prefix: "976"},
+	{Code: "NP", Name: "Nepal", Prefix: "977"},
+	{Code: "IR", Name: "Iran", Prefix: "98"},
+	{Code: "TJ", Name: "Tajikistan", Prefix: "992"},
+	{Code: "TM", Name: "Turkmenistan", Prefix: "993"},
+	{Code: "AZ", Name: "Azerbaijan", Prefix: "994"},
+	{Code: "GE", Name: "Georgia", Prefix: "995"},
+	{Code: "KG", Name: "Kyrgyzstan", Prefix: "996"},
+	{Code: "UZ", Name: "Uzbekistan", Prefix: "998"},
+}
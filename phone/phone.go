@@ -0,0 +1,85 @@
+package phone
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidNumber is returned when a string cannot be interpreted as
+// a phone number.
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+// Normalise converts the given phone number into E.164 format (e.g.
+// "+393331234567"); separators such as spaces, dots, dashes, slashes and
+// parentheses are dropped and the international "00" prefix is replaced
+// with "+". Numbers without an international prefix are assumed to be
+// local to the country identified by the given calling code (e.g. "39");
+// if the calling code is empty, such numbers are rejected.
+func Normalise(number string, prefix string) (string, error) {
+	var digits strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case r == '+' && i == 0:
+			international = true
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '/' || r == '(' || r == ')':
+			// separator, skip
+		default:
+			slog.Debug("invalid character in phone number", "number", number, "character", string(r))
+			return "", fmt.Errorf("%w: unexpected character %q in %q", ErrInvalidNumber, r, number)
+		}
+	}
+
+	value := digits.String()
+	if !international && strings.HasPrefix(value, "00") {
+		international = true
+		value = strings.TrimPrefix(value, "00")
+	}
+	if !international {
+		prefix = strings.TrimPrefix(strings.TrimPrefix(prefix, "+"), "00")
+		if prefix == "" {
+			return "", fmt.Errorf("%w: %q has no international prefix", ErrInvalidNumber, number)
+		}
+		value = prefix + value
+	}
+
+	// E.164 numbers have at most 15 digits; anything shorter than 6 is
+	// not a valid subscriber number in any numbering plan
+	if len(value) < 6 || len(value) > 15 {
+		return "", fmt.Errorf("%w: %q has an invalid length", ErrInvalidNumber, number)
+	}
+	return "+" + value, nil
+}
+
+// ReadList reads a list of phone numbers from the given reader, one per
+// line; empty lines and lines starting with '#' are ignored, and so is
+// anything following the first comma or semicolon, so that simple CSV
+// exports where the number comes first can be used as-is.
+func ReadList(reader io.Reader) ([]string, error) {
+	numbers := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if index := strings.IndexAny(line, ",;"); index >= 0 {
+			line = strings.TrimSpace(line[:index])
+		}
+		if line != "" {
+			numbers = append(numbers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("error reading list of phone numbers", "error", err)
+		return nil, err
+	}
+	return numbers, nil
+}
@@ -0,0 +1,139 @@
+// Package pii handles personally identifiable information (phone numbers,
+// message bodies, contact details) so that it does not end up in clear in
+// logs and outputs: struct fields are annotated with a "pii" tag stating
+// their sensitivity, and their values are masked, redacted or hashed unless
+// showing PII is explicitly enabled.
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Kind is the sensitivity of a value, as given in the "pii" struct tag.
+type Kind string
+
+// List of sensitivity kinds.
+const (
+	// Phone numbers are masked, keeping the country and network prefix and
+	// the last digits (e.g. +39333****567).
+	Phone Kind = "phone"
+	// Text (e.g. message bodies) is redacted, keeping only its length.
+	Text Kind = "text"
+	// Personal data (e.g. names, e-mail addresses, fiscal codes) is replaced
+	// by a short hash, so that equal values can still be correlated.
+	Personal Kind = "personal"
+)
+
+// shown is whether PII is shown in clear.
+var shown atomic.Bool
+
+// Show sets whether PII is shown in clear; by default it is not.
+func Show(show bool) {
+	shown.Store(show)
+}
+
+// Shown reports whether PII is shown in clear.
+func Shown() bool {
+	return shown.Load()
+}
+
+// Mask returns the value as appropriate for its kind, unless PII is shown.
+func Mask(kind Kind, value string) string {
+	if Shown() {
+		return value
+	}
+	return conceal(kind, value)
+}
+
+// conceal returns the value as appropriate for its kind, whether PII is
+// shown or not.
+func conceal(kind Kind, value string) string {
+	if value == "" {
+		return value
+	}
+	switch kind {
+	case Phone:
+		return MaskPhone(value)
+	case Text:
+		return RedactText(value)
+	case Personal:
+		return HashPersonal(value)
+	}
+	return value
+}
+
+// MaskPhone masks the middle digits of a phone number, e.g. +393331234567
+// becomes +39333****567, whether PII is shown or not; separators (e.g.
+// spaces or dashes) are kept, while values with letters (e.g. hashes or
+// alphanumeric senders) are not phone numbers and are returned as they are.
+// Short numbers keep a third of their digits at each end.
+func MaskPhone(number string) string {
+	if strings.IndexFunc(number, unicode.IsLetter) >= 0 {
+		return number
+	}
+	count := 0
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			count++
+		}
+	}
+	if count == 0 {
+		return number
+	}
+	keep, tail := 5, 3
+	if count <= keep+tail {
+		keep, tail = count/3, count/3
+	}
+	masked := strings.Builder{}
+	index := 0
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			if index >= keep && index < count-tail {
+				r = '*'
+			}
+			index++
+		}
+		masked.WriteRune(r)
+	}
+	return masked.String()
+}
+
+// RedactText replaces a text with a placeholder stating its length.
+func RedactText(text string) string {
+	return fmt.Sprintf("[redacted, %d chars]", len([]rune(text)))
+}
+
+// HashPersonal replaces a personal datum with a short hash.
+func HashPersonal(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "[redacted:" + hex.EncodeToString(sum[:])[:8] + "]"
+}
+
+// PhoneOf masks a phone number, unless PII is shown.
+func PhoneOf(number string) string {
+	return Mask(Phone, number)
+}
+
+// PhonesOf masks a list of phone numbers, unless PII is shown.
+func PhonesOf(numbers []string) []string {
+	masked := make([]string, len(numbers))
+	for i, number := range numbers {
+		masked[i] = Mask(Phone, number)
+	}
+	return masked
+}
+
+// TextOf redacts a text, unless PII is shown.
+func TextOf(text string) string {
+	return Mask(Text, text)
+}
+
+// PersonalOf hashes a personal datum, unless PII is shown.
+func PersonalOf(value string) string {
+	return Mask(Personal, value)
+}
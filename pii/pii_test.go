@@ -0,0 +1,316 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// show shows PII in clear until the end of the test.
+func show(t *testing.T) {
+	t.Helper()
+	Show(true)
+	t.Cleanup(func() { Show(false) })
+}
+
+func TestMaskPhone(t *testing.T) {
+	tests := []struct {
+		number   string
+		expected string
+	}{
+		{"+393331234567", "+39333****567"},
+		{"393331234567", "39333****567"},
+		{"+14155552671", "+14155***671"},
+		{"+39 333 123 4567", "+39 333 *** *567"},
+		{"+39-333-1234567", "+39-333-****567"},
+		{"+3933312", "+39***12"},
+		{"12345", "1***5"},
+		{"112", "1*2"},
+		{"12", "**"},
+		{"+", "+"},
+		{"", ""},
+		{"ACME", "ACME"},
+		{"hmac-sha256:0123456789abcdef", "hmac-sha256:0123456789abcdef"},
+		{"[redacted:01234567]", "[redacted:01234567]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.number, func(t *testing.T) {
+			if actual := MaskPhone(test.number); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		kind     Kind
+		value    string
+		expected string
+	}{
+		{Phone, "+393331234567", "+39333****567"},
+		{Text, "hello, world", "[redacted, 12 chars]"},
+		{Text, "città", "[redacted, 5 chars]"},
+		{Personal, "mario.rossi@example.com", HashPersonal("mario.rossi@example.com")},
+		{Kind("unknown"), "value", "value"},
+		{Text, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.kind)+" "+test.value, func(t *testing.T) {
+			if actual := Mask(test.kind, test.value); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("shown", func(t *testing.T) {
+		show(t)
+		for _, test := range tests {
+			if actual := Mask(test.kind, test.value); actual != test.value {
+				t.Errorf("expected %q in clear, got %q", test.value, actual)
+			}
+		}
+	})
+
+	if hash := HashPersonal("value"); hash != HashPersonal("value") || hash == HashPersonal("other") || !strings.HasPrefix(hash, "[redacted:") {
+		t.Errorf("expected a stable short hash, got %q", hash)
+	}
+}
+
+// contact is a tagged struct.
+type contact struct {
+	Name    string   `pii:"personal"`
+	Number  *string  `pii:"phone"`
+	Numbers []string `pii:"phone"`
+	Notes   string   `pii:"text"`
+	Public  string
+	private string `pii:"phone"`
+}
+
+// directory nests tagged values in all the supported containers.
+type directory struct {
+	Owner    contact
+	Deputy   *contact
+	Contacts []contact
+	Pinned   [1]contact
+	ByName   map[string]*contact
+	Any      any
+	Count    int
+}
+
+// node is a recursive type whose tagged field follows the recursive one.
+type node struct {
+	Children []node
+	Number   string `pii:"phone"`
+}
+
+// plain has no tagged fields.
+type plain struct {
+	Number string
+}
+
+func TestRedact(t *testing.T) {
+	number := "+393331234567"
+	person := func() contact {
+		return contact{Name: "Mario", Number: &number, Numbers: []string{"+393331234568"}, Notes: "call me", Public: "+393331234569", private: "+393331234560"}
+	}
+	masked := contact{Name: HashPersonal("Mario"), Numbers: []string{"+39333****568"}, Notes: "[redacted, 7 chars]", Public: "+393331234569", private: "+393331234560"}
+	check := func(t *testing.T, actual contact) {
+		t.Helper()
+		if actual.Number == nil || *actual.Number != "+39333****567" {
+			t.Errorf("expected the number pointer masked, got %v", actual.Number)
+		}
+		actual.Number = nil
+		if !reflect.DeepEqual(actual, masked) {
+			t.Errorf("expected %+v, got %+v", masked, actual)
+		}
+	}
+
+	t.Run("struct", func(t *testing.T) {
+		original := person()
+		check(t, Redact(original))
+		if *original.Number != number || original.Numbers[0] != "+393331234568" || original.Name != "Mario" {
+			t.Errorf("expected the original left unchanged, got %+v", original)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		deputy := person()
+		original := directory{
+			Owner:    person(),
+			Deputy:   &deputy,
+			Contacts: []contact{person(), person()},
+			Pinned:   [1]contact{person()},
+			ByName:   map[string]*contact{"mario": &deputy},
+			Any:      person(),
+			Count:    3,
+		}
+		redacted := Redact(original)
+		check(t, redacted.Owner)
+		check(t, *redacted.Deputy)
+		check(t, redacted.Contacts[0])
+		check(t, redacted.Contacts[1])
+		check(t, redacted.Pinned[0])
+		check(t, *redacted.ByName["mario"])
+		check(t, redacted.Any.(contact))
+		if redacted.Count != 3 {
+			t.Errorf("expected untagged fields kept, got %d", redacted.Count)
+		}
+		if *deputy.Number != number || original.Contacts[0].Name != "Mario" || original.Any.(contact).Name != "Mario" {
+			t.Errorf("expected the original left unchanged")
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		original := person()
+		check(t, *Redact(&original))
+		if original.Name != "Mario" {
+			t.Errorf("expected the original left unchanged, got %+v", original)
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		check(t, Redact[any](person()).(contact))
+	})
+
+	t.Run("nil values", func(t *testing.T) {
+		redacted := Redact(directory{})
+		if redacted.Deputy != nil || redacted.Contacts != nil || redacted.ByName != nil || redacted.Any != nil {
+			t.Errorf("expected nil values kept, got %+v", redacted)
+		}
+		if Redact[*contact](nil) != nil {
+			t.Errorf("expected a nil pointer kept")
+		}
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		tree := node{Number: "+393331234567", Children: []node{{Number: "+393331234568", Children: []node{{Number: "+393331234569"}}}}}
+		redacted := Redact(tree)
+		if redacted.Number != "+39333****567" || redacted.Children[0].Number != "+39333****568" || redacted.Children[0].Children[0].Number != "+39333****569" {
+			t.Errorf("expected all the numbers masked, got %+v", redacted)
+		}
+		if children := Redact(tree.Children); children[0].Number != "+39333****568" {
+			t.Errorf("expected the numbers of a slice masked, got %+v", children)
+		}
+	})
+
+	t.Run("untagged", func(t *testing.T) {
+		if redacted := Redact(plain{Number: number}); redacted.Number != number {
+			t.Errorf("expected untagged values kept, got %+v", redacted)
+		}
+	})
+
+	t.Run("shown", func(t *testing.T) {
+		show(t)
+		if redacted := Redact(person()); redacted.Name != "Mario" || *redacted.Number != number {
+			t.Errorf("expected values in clear, got %+v", redacted)
+		}
+	})
+}
+
+func TestReplaceAttr(t *testing.T) {
+	number := "+393331234567"
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected string
+	}{
+		{"phone key", slog.String("number", number), "number=+39333****567"},
+		{"phone list key", slog.Any("recipients", []string{number, "+393331234568"}), "recipients=\"[+39333****567 +39333****568]\""},
+		{"text key", slog.String("text", "hello"), "text=\"[redacted, 5 chars]\""},
+		{"other key", slog.String("account", "acme"), "account=acme"},
+		{"tagged value", slog.Any("contact", contact{Name: "Mario", Public: "public"}), "contact=\"{Name:" + HashPersonal("Mario") + " Number:<nil> Numbers:[] Notes: Public:public private:}\""},
+		{"untagged value", slog.Any("value", plain{Number: number}), "value={Number:" + number + "}"},
+		{"error", slog.Any("error", errors.New("number "+number+" rejected")), "error=\"number " + number + " rejected\""},
+		{"group", slog.Group("message", slog.String("to", number)), "message.to=+39333****567"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{ReplaceAttr: ReplaceAttr}))
+			logger.Info("test", test.attr)
+			if actual := buffer.String(); !strings.Contains(actual, " "+test.expected+"\n") {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("shown", func(t *testing.T) {
+		show(t)
+		buffer := &bytes.Buffer{}
+		logger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{ReplaceAttr: ReplaceAttr}))
+		logger.Info("test", "number", number)
+		if !strings.Contains(buffer.String(), "number="+number) {
+			t.Errorf("expected the number in clear, got %s", buffer.String())
+		}
+	})
+}
+
+func TestParams(t *testing.T) {
+	params := map[string]string{"from": "+393331234567", "search": "hello", "account": "acme", "token": "secret"}
+	expected := map[string]string{"from": "+39333****567", "search": "[redacted, 5 chars]", "account": "acme", "token": "secret"}
+	if actual := Params(params); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if params["from"] != "+393331234567" {
+		t.Errorf("expected the original left unchanged, got %v", params)
+	}
+	if actual := ScrubParam("token", "secret", "token"); actual != HashPersonal("secret") {
+		t.Errorf("expected the secret hashed, got %s", actual)
+	}
+
+	show(t)
+	if actual := Params(params); !reflect.DeepEqual(actual, params) {
+		t.Errorf("expected values in clear, got %v", actual)
+	}
+	if actual := ScrubParam("from", "+393331234567"); actual != "+39333****567" {
+		t.Errorf("expected the value scrubbed even if PII is shown, got %s", actual)
+	}
+}
+
+func TestScrubJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		secrets  []string
+		expected string
+	}{
+		{"empty", "", nil, ""},
+		{"invalid", "{\"text\": \"hello", nil, "[redacted, 15 chars]"},
+		{"fields", `{"recipients":["+393331234567"],"text":"hello","gateway":1}`, nil, `{"gateway":1,"recipients":["+39333****567"],"text":"[redacted, 5 chars]"}`},
+		{"nested", `{"results":[{"from":"+393331234567","email":"a@b.c","id":"1"}],"count":1}`, nil, `{"count":1,"results":[{"email":"` + HashPersonal("a@b.c") + `","from":"+39333****567","id":"1"}]}`},
+		{"secrets", `{"token":"secret","account":"acme"}`, []string{"token"}, `{"account":"acme","token":"` + HashPersonal("secret") + `"}`},
+		{"non-string values", `{"number":12345,"text":null}`, nil, `{"number":12345,"text":null}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ScrubJSON(test.data, test.secrets...)
+			if compacted := (&bytes.Buffer{}); json.Compact(compacted, []byte(actual)) == nil {
+				actual = compacted.String()
+			}
+			if actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+
+	data := `{"text":"hello"}`
+	if actual := RedactJSON(data); !strings.Contains(actual, "[redacted, 5 chars]") {
+		t.Errorf("expected the text redacted, got %s", actual)
+	}
+	show(t)
+	if actual := RedactJSON(data); actual != data {
+		t.Errorf("expected the document in clear, got %s", actual)
+	}
+	if actual := ScrubJSON(data); !strings.Contains(actual, "[redacted, 5 chars]") {
+		t.Errorf("expected the text scrubbed even if PII is shown, got %s", actual)
+	}
+}
@@ -0,0 +1,321 @@
+package pii
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Redact returns a copy of the value where the fields tagged with "pii" are
+// masked, redacted or hashed according to their kind, in nested structs,
+// pointers, slices and maps too; the value itself is never modified, and it
+// is returned as it is if PII is shown or if it has no tagged fields.
+func Redact[T any](value T) T {
+	if Shown() {
+		return value
+	}
+	v := reflect.ValueOf(&value).Elem()
+	if !sensitive(v.Type()) {
+		return value
+	}
+	return redact(v).Interface().(T)
+}
+
+// redact returns a redacted copy of the value.
+func redact(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() || !sensitive(v.Elem().Type()) {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(redact(v.Elem()))
+		return out
+	case reflect.Pointer:
+		if v.IsNil() || !sensitive(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(redact(v.Elem()))
+		return out
+	case reflect.Struct:
+		if !sensitive(v.Type()) {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if kind := field.Tag.Get("pii"); kind != "" {
+				mask(out.Field(i), Kind(kind))
+			} else if sensitive(field.Type) {
+				out.Field(i).Set(redact(v.Field(i)))
+			}
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if (v.Kind() == reflect.Slice && v.IsNil()) || !sensitive(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		if v.Kind() == reflect.Slice {
+			out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		}
+		for i := range v.Len() {
+			out.Index(i).Set(redact(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || !sensitive(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iterator := v.MapRange(); iterator.Next(); {
+			out.SetMapIndex(iterator.Key(), redact(iterator.Value()))
+		}
+		return out
+	}
+	return v
+}
+
+// mask masks a tagged field in place; the field must belong to a copy.
+func mask(field reflect.Value, kind Kind) {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(Mask(kind, field.String()))
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.String:
+		if !field.IsNil() {
+			masked := reflect.New(field.Type().Elem())
+			masked.Elem().SetString(Mask(kind, field.Elem().String()))
+			field.Set(masked)
+		}
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		if !field.IsNil() {
+			masked := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+			for i := range field.Len() {
+				masked.Index(i).SetString(Mask(kind, field.Index(i).String()))
+			}
+			field.Set(masked)
+		}
+	}
+}
+
+// types caches whether types hold tagged fields.
+var types sync.Map
+
+// sensitive reports whether values of the given type hold tagged fields,
+// directly or in nested values.
+func sensitive(t reflect.Type) bool {
+	if cached, ok := types.Load(t); ok {
+		return cached.(bool)
+	}
+	result := holds(t, map[reflect.Type]bool{})
+	types.Store(t, result)
+	return result
+}
+
+// holds reports whether values of the given type hold tagged fields, or
+// may hold them (i.e. interfaces, whose dynamic values are checked when
+// redacting); types already visited are skipped, to stop on recursive types.
+// Only the result for the type the visit starts from is complete, so only
+// that is cached.
+func holds(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if cached, ok := types.Load(t); ok {
+		return cached.(bool)
+	}
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holds(t.Elem(), visited)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if field.IsExported() && (field.Tag.Get("pii") != "" || holds(field.Type, visited)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keys maps the keys of log attributes that commonly hold PII to the kind
+// of their values.
+var keys = map[string]Kind{
+	"number":      Phone,
+	"numbers":     Phone,
+	"recipient":   Phone,
+	"recipients":  Phone,
+	"suppressed":  Phone,
+	"counterpart": Phone,
+	"from":        Phone,
+	"to":          Phone,
+	"text":        Text,
+	"body":        Text,
+}
+
+// ReplaceAttr is a function for slog.HandlerOptions that keeps PII out of
+// logs, unless PII is shown: values of tagged types are redacted, and string
+// values of attributes with keys that commonly hold PII (e.g. "number" or
+// "text") are masked.
+func ReplaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if Shown() {
+		return attr
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		if kind, ok := keys[attr.Key]; ok {
+			return slog.String(attr.Key, Mask(kind, value.String()))
+		}
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case []string:
+			if kind, ok := keys[attr.Key]; ok {
+				masked := make([]string, len(v))
+				for i, s := range v {
+					masked[i] = Mask(kind, s)
+				}
+				return slog.Any(attr.Key, masked)
+			}
+		case error:
+		default:
+			if v != nil && sensitive(reflect.TypeOf(v)) {
+				return slog.Any(attr.Key, Redact(v))
+			}
+		}
+	}
+	return attr
+}
+
+// fields maps the names of the JSON fields that hold PII in API payloads to
+// the kind of their values.
+var fields = map[string]Kind{
+	"recipients":           Phone,
+	"sender":               Phone,
+	"from":                 Phone,
+	"to":                   Phone,
+	"number":               Phone,
+	"phone":                Phone,
+	"phone_number":         Phone,
+	"main_contact_cell":    Phone,
+	"repr_callme":          Phone,
+	"text":                 Text,
+	"fallback_text":        Text,
+	"email":                Personal,
+	"main_contact_name":    Personal,
+	"main_contact_surname": Personal,
+	"main_contact_email":   Personal,
+	"repr_name":            Personal,
+	"repr_surname":         Personal,
+	"repr_email":           Personal,
+	"repr_fiscal_code":     Personal,
+	"address":              Personal,
+}
+
+// parameters maps the names of the query and path parameters that hold PII,
+// besides those named like the payload fields, to the kind of their values.
+var parameters = map[string]Kind{
+	"search": Text,
+}
+
+// Param returns the value of a query or path parameter, masked if the
+// parameter holds PII, unless PII is shown.
+func Param(name string, value string) string {
+	if Shown() {
+		return value
+	}
+	return ScrubParam(name, value)
+}
+
+// ScrubParam masks the value of a query or path parameter that holds PII
+// whether PII is shown or not, e.g. for values stored on disk; the values of
+// the given secret parameters (e.g. "token") are replaced with a short hash.
+func ScrubParam(name string, value string, secrets ...string) string {
+	if slices.Contains(secrets, name) {
+		return HashPersonal(value)
+	}
+	kind, ok := parameters[name]
+	if !ok {
+		kind, ok = fields[name]
+	}
+	if !ok {
+		return value
+	}
+	return conceal(kind, value)
+}
+
+// Params returns a copy of the query or path parameters, with the values of
+// those that hold PII masked unless PII is shown, e.g. to log them.
+func Params(params map[string]string) map[string]string {
+	if params == nil || Shown() {
+		return params
+	}
+	result := make(map[string]string, len(params))
+	for name, value := range params {
+		result[name] = Param(name, value)
+	}
+	return result
+}
+
+// RedactJSON masks the values of the fields that hold PII in a JSON
+// document (e.g. the body of an API request), unless PII is shown; data that
+// is not valid JSON is redacted altogether.
+func RedactJSON(data string) string {
+	if Shown() {
+		return data
+	}
+	return ScrubJSON(data)
+}
+
+// ScrubJSON masks the values of the fields that hold PII in a JSON document
+// whether PII is shown or not, e.g. for documents stored on disk; the values
+// of the given secret fields (e.g. "token") are replaced with a short hash.
+// Data that is not valid JSON is redacted altogether.
+func ScrubJSON(data string, secrets ...string) string {
+	if data == "" {
+		return data
+	}
+	var document any
+	if err := json.Unmarshal([]byte(data), &document); err != nil {
+		return RedactText(data)
+	}
+	redacted, err := json.MarshalIndent(redactJSON(document, "", secrets), "", "   ")
+	if err != nil {
+		return RedactText(data)
+	}
+	return string(redacted)
+}
+
+// redactJSON masks the values of the fields that hold PII or secrets in a
+// decoded JSON value; name is the name of the field holding the value, if
+// any.
+func redactJSON(value any, name string, secrets []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = redactJSON(item, key, secrets)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item, name, secrets)
+		}
+	case string:
+		if slices.Contains(secrets, name) {
+			return HashPersonal(v)
+		}
+		if kind, ok := fields[name]; ok {
+			return conceal(kind, v)
+		}
+	}
+	return value
+}
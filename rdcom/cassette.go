@@ -0,0 +1,426 @@
+package rdcom
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dihedron/sms/pii"
+)
+
+// ErrNotRecorded is returned in replay mode when a request has no recorded
+// response in the cassette.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// CassetteHeader is set in replayed responses to the name of the file they
+// were read from.
+const CassetteHeader = "X-Cassette"
+
+// secrets are the JSON fields and query parameters holding secrets in API
+// calls, which are never stored in cassettes.
+var secrets = []string{"token", "password", "secret"}
+
+// volatile are the query parameters whose values change from one call to
+// the next (e.g. the current time), which are ignored when matching
+// requests to the recorded ones if no recording has the same values.
+var volatile = []string{"received_after", "received_before"}
+
+// unsafe are the request and response headers that are never stored in
+// cassettes.
+var unsafe = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Interaction is an API exchange stored in a cassette.
+type Interaction struct {
+	// Recorded is the time the exchange was recorded at.
+	Recorded time.Time `json:"recorded" yaml:"recorded"`
+	// Request is the request, with secrets and PII redacted.
+	Request RecordedRequest `json:"request" yaml:"request"`
+	// Response is the response, with secrets and PII redacted.
+	Response RecordedResponse `json:"response" yaml:"response"`
+}
+
+// RecordedRequest is a request stored in a cassette.
+type RecordedRequest struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// Stable is the hash of the path and query without the volatile query
+	// parameters, if the query has any.
+	Stable string `json:"stable,omitempty" yaml:"stable,omitempty"`
+}
+
+// RecordedResponse is a response stored in a cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Status     string      `json:"status" yaml:"status"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// WithRecord records all the API exchanges as cassette files in the given
+// directory, one file per exchange, with secrets and PII redacted; files
+// recorded earlier for the same requests are overwritten.
+func WithRecord(dir string) Option {
+	return func(c *Client) {
+		if c.cassette != nil {
+			c.fail(errors.New("cannot both record and replay API exchanges"))
+			return
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			slog.Error("error creating cassette directory", "dir", dir, "error", err)
+			c.fail(err)
+			return
+		}
+		slog.Debug("recording API exchanges", "dir", dir)
+		c.cassette = &cassette{dir: filepath.Clean(dir), seen: map[string]int{}}
+	}
+}
+
+// WithReplay serves all the API calls from the cassette files in the given
+// directory, without accessing the network: repeated requests get the
+// responses recorded for them in order, and then the last one again;
+// requests whose query was not recorded get a response recorded for a query
+// differing only in the volatile parameters (e.g. holding the current
+// time), if any, or fail with ErrNotRecorded.
+func WithReplay(dir string) Option {
+	return func(c *Client) {
+		if c.cassette != nil {
+			c.fail(errors.New("cannot both record and replay API exchanges"))
+			return
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			slog.Error("invalid cassette directory", "dir", dir, "error", err)
+			c.fail(fmt.Errorf("invalid cassette directory %s", dir))
+			return
+		}
+		slog.Debug("replaying API exchanges", "dir", dir)
+		c.cassette = &cassette{dir: filepath.Clean(dir), replay: true, seen: map[string]int{}}
+	}
+}
+
+// cassette is a directory of recorded API exchanges.
+type cassette struct {
+	dir    string
+	replay bool
+	lock   sync.Mutex
+	// seen counts the times each request was made.
+	seen map[string]int
+}
+
+// wrap returns a transport that records the exchanges through the given one,
+// or replays them.
+func (c *cassette) wrap(next http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{next: next, cassette: c}
+}
+
+// cassetteTransport is an HTTP transport that records or replays API
+// exchanges.
+type cassetteTransport struct {
+	next     http.RoundTripper
+	cassette *cassette
+}
+
+// RoundTrip records or replays the exchange.
+func (t *cassetteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if t.cassette.replay {
+		return t.cassette.play(request)
+	}
+	return t.cassette.record(t.next, request)
+}
+
+// record performs the request and stores the exchange.
+func (c *cassette) record(next http.RoundTripper, request *http.Request) (*http.Response, error) {
+	body, err := readBody(&request.Body)
+	if err != nil {
+		slog.Error("error reading request body", "error", err)
+		return nil, err
+	}
+	response, err := next.RoundTrip(request)
+	if err != nil {
+		return response, err
+	}
+	data, err := readBody(&response.Body)
+	if err != nil {
+		slog.Error("error reading response body", "error", err)
+		return nil, err
+	}
+	// the response is returned as it is, but stored decoded
+	header := scrubHeader(response.Header)
+	if encoding := header.Get("Content-Encoding"); encoding != "" {
+		if data, err = decode(encoding, data); err != nil {
+			slog.Error("error decoding response body", "encoding", encoding, "error", err)
+			return nil, err
+		}
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+	}
+
+	interaction := &Interaction{
+		Recorded: time.Now(),
+		Request: RecordedRequest{
+			Method: request.Method,
+			URL:    scrubURI(request.URL),
+			Header: scrubHeader(request.Header),
+			Body:   pii.ScrubJSON(string(body), secrets...),
+			Stable: stableKey(request.URL),
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Status:     response.Status,
+			Header:     header,
+			Body:       pii.ScrubJSON(string(data), secrets...),
+		},
+	}
+	key := requestKey(request)
+	c.lock.Lock()
+	n := c.seen[key]
+	c.seen[key]++
+	c.lock.Unlock()
+
+	path := filepath.Join(c.dir, fmt.Sprintf("%s-%d.json", key, n))
+	stored, err := json.MarshalIndent(interaction, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, stored, 0600)
+	}
+	if err != nil {
+		// the exchange did happen, so the response is returned anyway
+		slog.Error("error writing cassette", "path", path, "error", err)
+	} else {
+		slog.Debug("API exchange recorded", "path", path)
+	}
+	return response, nil
+}
+
+// play returns the recorded response to the request.
+func (c *cassette) play(request *http.Request) (*http.Response, error) {
+	if _, err := readBody(&request.Body); err != nil {
+		return nil, err
+	}
+	key := requestKey(request)
+	c.lock.Lock()
+	n := c.seen[key]
+	c.seen[key]++
+	c.lock.Unlock()
+
+	path, err := c.find(key, n)
+	if err != nil {
+		return nil, err
+	}
+	if stable := stableKey(request.URL); path == "" && stable != "" {
+		if path, err = c.similar(request, stable); err != nil {
+			return nil, err
+		}
+		if path != "" {
+			slog.Warn("replaying a response recorded with other volatile query parameters", "method", request.Method, "path", request.URL.Path, "cassette", path)
+		}
+	}
+	if path == "" {
+		slog.Error("no recorded response", "method", request.Method, "url", scrubURI(request.URL))
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, request.Method, scrubURI(request.URL))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("error reading cassette", "path", path, "error", err)
+		return nil, err
+	}
+	interaction := &Interaction{}
+	if err := json.Unmarshal(data, interaction); err != nil {
+		slog.Error("error parsing cassette", "path", path, "error", err)
+		return nil, err
+	}
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(CassetteHeader, filepath.Base(path))
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	slog.Debug("API exchange replayed", "path", path)
+	return &http.Response{
+		Status:        interaction.Response.Status,
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       request,
+	}, nil
+}
+
+// find returns the path of the n-th recording of a request or, if the
+// request was made more times than recorded, of the last one; an empty path
+// is returned if the request was never recorded.
+func (c *cassette) find(key string, n int) (string, error) {
+	for ; n >= 0; n-- {
+		path := filepath.Join(c.dir, fmt.Sprintf("%s-%d.json", key, n))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("error accessing cassette", "path", path, "error", err)
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// similar returns the path of the recording of a request on the same path
+// whose query differs only in the volatile parameters: the recordings are
+// replayed in the order they were recorded, and then the last one again; an
+// empty path is returned if there is none.
+func (c *cassette) similar(request *http.Request, stable string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, requestPrefix(request)+"-*.json"))
+	if err != nil {
+		return "", err
+	}
+	type recording struct {
+		path     string
+		recorded time.Time
+	}
+	matches := []recording{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Error("error reading cassette", "path", path, "error", err)
+			return "", err
+		}
+		interaction := &Interaction{}
+		if err := json.Unmarshal(data, interaction); err != nil {
+			slog.Error("error parsing cassette", "path", path, "error", err)
+			return "", err
+		}
+		if interaction.Request.Stable == stable && interaction.Request.Method == request.Method {
+			matches = append(matches, recording{path: path, recorded: interaction.Recorded})
+		}
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].recorded.Before(matches[j].recorded) })
+	c.lock.Lock()
+	n := c.seen[stable]
+	c.seen[stable]++
+	c.lock.Unlock()
+	return matches[min(n, len(matches)-1)].path, nil
+}
+
+// unsafeChars matches the characters not allowed in cassette file names.
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// requestKey returns the base name of the cassette files of a request, made
+// of the method, the path and a hash of the path and query, so that the
+// recordings do not depend on the endpoint.
+func requestKey(request *http.Request) string {
+	return requestPrefix(request) + "-" + hash(request.URL.RequestURI())
+}
+
+// requestPrefix returns the part of the cassette file names that depends on
+// the method and the path of a request only.
+func requestPrefix(request *http.Request) string {
+	path := strings.Trim(unsafeChars.ReplaceAllString(request.URL.Path, "_"), "_")
+	if len(path) > 80 {
+		path = path[len(path)-80:]
+	}
+	return strings.ToLower(request.Method) + "-" + path
+}
+
+// stableKey returns the hash of the path and query of a URL without the
+// volatile query parameters, or an empty string if there is none.
+func stableKey(u *url.URL) string {
+	query := u.Query()
+	found := false
+	for _, name := range volatile {
+		if query.Has(name) {
+			query.Del(name)
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	clone := *u
+	clone.RawQuery = query.Encode()
+	return "stable-" + hash(clone.RequestURI())
+}
+
+// scrubURI returns the path and query of a URL, with the values of the query
+// parameters holding secrets or PII masked; requests are matched by the
+// hash of the original in the file name, not by the stored URL.
+func scrubURI(u *url.URL) string {
+	clone := *u
+	query := clone.Query()
+	for name, values := range query {
+		for i, value := range values {
+			values[i] = pii.ScrubParam(name, value, secrets...)
+		}
+	}
+	clone.RawQuery = query.Encode()
+	return clone.RequestURI()
+}
+
+// hash returns a short hash of a string.
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// readBody reads a request or response body, replacing it with a copy so
+// that it can be read again.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// decode decodes a gzip or deflate encoded body.
+func decode(encoding string, data []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	switch strings.ToLower(encoding) {
+	case "gzip":
+		var err error
+		if reader, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	case "deflate":
+		reader = flate.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// scrubHeader returns a copy of the header without credentials.
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, name := range unsafe {
+		scrubbed.Del(name)
+	}
+	return scrubbed
+}
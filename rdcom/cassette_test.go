@@ -0,0 +1,111 @@
+package rdcom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// call places a raw GET call with the given query and returns the JSON body
+// of the response.
+func call(t *testing.T, client *Client, query map[string]string) (string, error) {
+	t.Helper()
+	response, err := Raw(client, http.MethodGet, &RawOptions{
+		Options: Options{EntityPath: "/api/v2/acme/cds/sms/inbound/", QueryParams: query},
+	})
+	if err != nil {
+		return "", err
+	}
+	// bodies are stored indented
+	compacted := &bytes.Buffer{}
+	if err := json.Compact(compacted, response.Body); err != nil {
+		t.Fatal(err)
+	}
+	return compacted.String(), nil
+}
+
+func TestCassette(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"after":%q,"gateway":%q,"call":%d}`, r.URL.Query().Get("received_after"), r.URL.Query().Get("gateway"), calls)
+	}))
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+
+	recorder, err := New(WithBaseURL(server.URL), WithAuthToken("token"), WithoutSuppression(), WithRecord(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []map[string]string{
+		{"received_after": "09:00", "gateway": "1"},
+		{"received_after": "09:00", "gateway": "1"},
+		{"received_after": "10:00", "gateway": "1"},
+		{"gateway": "2"},
+	} {
+		if _, err := call(t, recorder, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder.Close()
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 4 {
+		t.Fatalf("expected 4 recordings, got %d", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "token") {
+			t.Errorf("expected the credentials not stored, got %s", data)
+		}
+	}
+
+	player, err := New(WithBaseURL("http://invalid.example.com"), WithAuthToken("token"), WithoutSuppression(), WithReplay(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { player.Close() })
+	tests := []struct {
+		name     string
+		query    map[string]string
+		expected string
+	}{
+		{"recorded", map[string]string{"received_after": "09:00", "gateway": "1"}, `{"after":"09:00","call":1,"gateway":"1"}`},
+		{"recorded again", map[string]string{"received_after": "09:00", "gateway": "1"}, `{"after":"09:00","call":2,"gateway":"1"}`},
+		{"recorded fewer times", map[string]string{"received_after": "09:00", "gateway": "1"}, `{"after":"09:00","call":2,"gateway":"1"}`},
+		{"other volatile values", map[string]string{"received_after": "11:00", "gateway": "1"}, `{"after":"09:00","call":1,"gateway":"1"}`},
+		{"other volatile values again", map[string]string{"received_after": "12:00", "gateway": "1"}, `{"after":"09:00","call":2,"gateway":"1"}`},
+		{"no volatile values", map[string]string{"gateway": "2"}, `{"after":"","call":4,"gateway":"2"}`},
+		{"other values", map[string]string{"gateway": "3"}, ""},
+		{"other values and volatile ones", map[string]string{"received_after": "11:00", "gateway": "2"}, ""},
+		{"no query", nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := call(t, player, test.query)
+			if test.expected == "" {
+				if !errors.Is(err, ErrNotRecorded) {
+					t.Errorf("expected ErrNotRecorded, got %q (error: %v)", body, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, body)
+			}
+		})
+	}
+	if calls != 4 {
+		t.Errorf("expected no calls when replaying, got %d", calls-4)
+	}
+}
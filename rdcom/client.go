@@ -1,10 +1,16 @@
 package rdcom
 
 import (
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/dihedron/sms/pii"
 	"github.com/go-playground/validator/v10"
 	"resty.dev/v3"
 )
@@ -32,14 +38,38 @@ type Client struct {
 	password string
 	// token is the authentication token to use in requests.
 	token string
-	// account is the ID of the account to use ito scope requests.
-	account string `validate:"required"`
+	// account is the (optional) default account of account-scoped requests,
+	// used for the {account} path parameter when none is given.
+	account string
+	// parent is the client this is a scoped view of, if any.
+	parent *Client
+	// hooks are invoked around each API call attempt.
+	hooks []Hooks
+	// planned is invoked with the mutating calls planned in dry-run mode;
+	// if nil, the client is not in dry-run mode.
+	planned func(*PlannedCall)
+	// cassette records or replays the API exchanges, if set.
+	cassette *cassette
+	// errs are the errors found applying the options.
+	errs []error
+	// suppressor is the suppression list consulted before sending.
+	suppressor Suppressor
+	// unsuppressed is set when the client opted out of the suppression list.
+	unsuppressed bool
 	// TokenService is the Token service.
 	TokenService *TokenService `validate:"required"`
 	// AccountService is the Account service.
 	AccountService *AccountService `validate:"required"`
 	// SMSGatewayService is the Account service.
 	SMSGatewayService *SMSGatewayService `validate:"required"`
+	// MessageService is the SMS message sending service.
+	MessageService *MessageService `validate:"required"`
+	// InboundService is the inbound SMS message service.
+	InboundService *InboundService `validate:"required"`
+	// BlacklistService is the platform blacklist service.
+	BlacklistService *BlacklistService `validate:"required"`
+	// SenderService is the sender registration service.
+	SenderService *SenderService `validate:"required"`
 }
 
 // Service represents an API service.
@@ -68,7 +98,9 @@ func WithBaseURL(endpoint string) Option {
 	}
 }
 
-// WithAccount sets the account option.
+// WithAccount sets the default account of account-scoped requests: it is
+// used for the {account} path parameter whenever it is not given or empty,
+// e.g. when account-scoped services are called with an empty account.
 func WithAccount(account string) Option {
 	return func(c *Client) {
 		slog.Debug("setting account", "account", account)
@@ -80,18 +112,43 @@ func WithAccount(account string) Option {
 func WithSkipTLSVerify(insecure bool) Option {
 	return func(c *Client) {
 		slog.Debug("setting skip TLS verification", "insecure", insecure)
-		c.api.SetTLSClientConfig(&tls.Config{
-			InsecureSkipVerify: insecure,
-		})
+		if config := c.tlsConfig(); config != nil {
+			config.InsecureSkipVerify = insecure
+		}
 		c.api.SetDisableWarn(true)
 	}
 }
 
+// WithContext sets the context used by all API calls, e.g. to carry the
+// parent span when tracing or to cancel pending calls.
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) {
+		slog.Debug("setting context")
+		c.api.SetContext(ctx)
+	}
+}
+
 // WithDebug sets the debug option.
 func WithDebug() Option {
 	return func(c *Client) {
 		slog.Debug("enabling debug")
 		c.api.EnableDebug()
+		c.api.OnDebugLog(func(log *resty.DebugLog) {
+			// keep PII out of debug logs, unless explicitly shown; resty
+			// placeholders (e.g. "***** NO CONTENT *****") are kept
+			redact := func(body string) string {
+				if strings.HasPrefix(body, "***** ") {
+					return body
+				}
+				return pii.RedactJSON(body)
+			}
+			if log.Request != nil {
+				log.Request.Body = redact(log.Request.Body)
+			}
+			if log.Response != nil {
+				log.Response.Body = redact(log.Response.Body)
+			}
+		})
 	}
 }
 
@@ -103,10 +160,32 @@ func WithTrace() Option {
 	}
 }
 
+// WithTimeout sets the maximum total duration of each API call attempt,
+// reading the response body included; zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		slog.Debug("setting timeout", "timeout", timeout)
+		c.api.SetTimeout(timeout)
+	}
+}
+
+// WithRetries sets the number of times API calls failing with a transient
+// error (e.g. a network error, or a 429 or 5xx response) are retried, with
+// an exponential backoff between the given bounds; calls that are not
+// idempotent (e.g. sending a message) are never retried.
+func WithRetries(count int, wait time.Duration, maxWait time.Duration) Option {
+	return func(c *Client) {
+		slog.Debug("setting retries", "count", count, "wait", wait, "max wait", maxWait)
+		c.api.SetRetryCount(count)
+		c.api.SetRetryWaitTime(wait)
+		c.api.SetRetryMaxWaitTime(maxWait)
+	}
+}
+
 // WithUserCredentials sets the basic authentication credentials for the user.
 func WithUserCredentials(username string, password string) Option {
 	return func(c *Client) {
-		slog.Debug("setting user credentials", "username", username, "password", password)
+		slog.Debug("setting user credentials", "username", username)
 		c.username = username
 		c.password = password
 		c.api.SetBasicAuth(username, password)
@@ -116,7 +195,7 @@ func WithUserCredentials(username string, password string) Option {
 // WithAuthToken sets the authentication token for the user.
 func WithAuthToken(token string) Option {
 	return func(c *Client) {
-		slog.Debug("setting authentication token", "token", token)
+		slog.Debug("setting authentication token")
 		c.token = token
 		c.api.SetAuthToken(token)
 	}
@@ -131,10 +210,12 @@ func New(options ...Option) (*Client, error) {
 	for _, option := range options {
 		option(c)
 	}
-	c.TokenService = &TokenService{Service{client: c}}
-	c.AccountService = &AccountService{Service{client: c}}
-	c.SMSGatewayService = &SMSGatewayService{Service{client: c}}
-	// TODO: initialise more services here...
+	if err := errors.Join(c.errs...); err != nil {
+		slog.Error("invalid API client options", "error", err)
+		return nil, err
+	}
+	c.installTransports()
+	c.initialiseServices()
 
 	// perform struct level validation
 	validate := validator.New()
@@ -147,8 +228,58 @@ func New(options ...Option) (*Client, error) {
 	return c, nil
 }
 
-// Close frees the API client resources.
+// initialiseServices binds the services to the client.
+func (c *Client) initialiseServices() {
+	c.TokenService = &TokenService{Service{client: c}}
+	c.AccountService = &AccountService{Service{client: c}}
+	c.SMSGatewayService = &SMSGatewayService{Service{client: c}}
+	c.MessageService = &MessageService{Service{client: c}}
+	c.InboundService = &InboundService{Service{client: c}}
+	c.BlacklistService = &BlacklistService{Service{client: c}}
+	c.SenderService = &SenderService{Service{client: c}}
+	// TODO: initialise more services here...
+}
+
+// Account returns the default account of account-scoped requests, if any.
+func (c *Client) Account() string {
+	return c.account
+}
+
+// ForAccount returns a view of the client whose default account is the
+// given one; the view shares the client transport, authentication, hooks and
+// connections, so it is cheap to create, e.g. for each job of a worker
+// serving many accounts. Closing the view is a no-op: close the client.
+func (c *Client) ForAccount(account string) *Client {
+	slog.Debug("scoping client to account", "account", account)
+	view := *c
+	view.account = account
+	if c.parent != nil {
+		view.parent = c.parent
+	} else {
+		view.parent = c
+	}
+	view.initialiseServices()
+	return &view
+}
+
+// pathParams returns the path parameters of a request, with the default
+// account for the {account} parameter if not given or empty.
+func (c *Client) pathParams(params map[string]string) map[string]string {
+	if c.account == "" || params["account"] != "" {
+		return params
+	}
+	result := make(map[string]string, len(params)+1)
+	maps.Copy(result, params)
+	result["account"] = c.account
+	return result
+}
+
+// Close frees the API client resources; closing a view created by
+// ForAccount does nothing, as the resources belong to the client.
 func (c *Client) Close() error {
+	if c.parent != nil {
+		return nil
+	}
 	return c.api.Close()
 }
 
@@ -158,6 +289,7 @@ type Options struct {
 	EntityPath  string            `json:"entity_path" yaml:"entity_path" validate:"required"`
 	PathParams  map[string]string `json:"path_params" validate:"required"`
 	QueryParams map[string]string `json:"query_params" validate:"required"`
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 }
 
 type GetOptions Options
@@ -167,13 +299,13 @@ func Get[T any](client *Client, options *GetOptions) (*T, error) {
 	request := client.api.R()
 
 	if options.QueryParams != nil {
-		slog.Debug("setting query params", "values", options.QueryParams)
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
 		request.SetQueryParams(options.QueryParams)
 	}
 
-	if options.PathParams != nil {
-		slog.Debug("setting path params", "values", options.PathParams)
-		request.SetPathParams(options.PathParams)
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
 	}
 
 	result := new(T)
@@ -185,7 +317,7 @@ func Get[T any](client *Client, options *GetOptions) (*T, error) {
 	}
 	if response.IsError() {
 		slog.Error("request failed", "error", response.Error())
-		return nil, fmt.Errorf("HTTP error: %d (%s)", response.StatusCode(), response.Status())
+		return nil, newHTTPError(response)
 	}
 
 	slog.Debug("GET API options successful", "path", options.EntityPath)
@@ -198,13 +330,13 @@ func List[T any](client *Client, options *ListOptions) ([]T, error) {
 	request := client.api.R()
 
 	if options.QueryParams != nil {
-		slog.Debug("setting query params", "values", options.QueryParams)
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
 		request.SetQueryParams(options.QueryParams)
 	}
 
-	if options.PathParams != nil {
-		slog.Debug("setting path params", "values", options.PathParams)
-		request.SetPathParams(options.PathParams)
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
 	}
 
 	result := new([]T)
@@ -216,7 +348,7 @@ func List[T any](client *Client, options *ListOptions) ([]T, error) {
 	}
 	if response.IsError() {
 		slog.Error("request failed", "error", response.Error())
-		return nil, fmt.Errorf("HTTP error: %d (%s)", response.StatusCode(), response.Status())
+		return nil, newHTTPError(response)
 	}
 
 	slog.Debug("GET API options successful", "path", options.EntityPath)
@@ -233,13 +365,18 @@ func PaginatedList[T any](client *Client, options *PaginatedListOptions) ([]T, e
 	request := client.api.R()
 
 	if options.QueryParams != nil {
-		slog.Debug("setting query params", "values", options.QueryParams)
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
 		request.SetQueryParams(options.QueryParams)
 	}
 
-	if options.PathParams != nil {
-		slog.Debug("setting path params", "values", options.PathParams)
-		request.SetPathParams(options.PathParams)
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
 	}
 
 	type payload[T any] struct {
@@ -256,9 +393,10 @@ func PaginatedList[T any](client *Client, options *PaginatedListOptions) ([]T, e
 	}
 
 	results := make([]T, 0)
-	page := &payload[T]{}
 	offset := 0
 	for {
+		// decode each page afresh, so results never share memory across pages
+		page := &payload[T]{}
 		if options.PageSize != nil {
 			slog.Debug("enabling pagination", "page size", *options.PageSize)
 			request.SetQueryParam("paginated-view", "true")
@@ -276,7 +414,7 @@ func PaginatedList[T any](client *Client, options *PaginatedListOptions) ([]T, e
 		}
 		if response.IsError() {
 			slog.Error("request failed", "error", response.Error())
-			return nil, fmt.Errorf("HTTP error: %d (%s)", response.StatusCode(), response.Status())
+			return nil, newHTTPError(response)
 		}
 		slog.Debug("API call successful", "count", len(page.Results))
 		results = append(results, page.Results...)
@@ -295,6 +433,21 @@ type CreateOptions Options
 func Create[T any](client *Client, entity *T, options *CreateOptions) (*T, error) {
 	request := client.api.R()
 
+	if options.QueryParams != nil {
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
+		request.SetQueryParams(options.QueryParams)
+	}
+
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
+	}
+
 	if entity != nil {
 		slog.Debug("setting entity", "type", fmt.Sprintf("%T", entity), "value", *entity)
 		request.SetBody(entity)
@@ -312,7 +465,7 @@ func Create[T any](client *Client, entity *T, options *CreateOptions) (*T, error
 	}
 	if response.IsError() {
 		slog.Error("request failed", "error", response.Error())
-		return nil, fmt.Errorf("HTTP error: %d (%s)", response.StatusCode(), response.Status())
+		return nil, newHTTPError(response)
 	}
 
 	slog.Debug("API call success", "result", result)
@@ -325,11 +478,27 @@ type DeleteOptions Options
 func Delete[T any](client *Client, entity *T, options *DeleteOptions) (*T, error) {
 	request := client.api.R()
 
+	if options.QueryParams != nil {
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
+		request.SetQueryParams(options.QueryParams)
+	}
+
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
+	}
+
 	if entity != nil {
 		slog.Debug("setting entity", "type", fmt.Sprintf("%T", entity), "value", *entity)
-		request.SetBody(entity)
+		// Resty drops DELETE payloads unless explicitly allowed
+		request.SetBody(entity).SetAllowMethodDeletePayload(true)
 	} else {
-		slog.Warn("no entity provided?")
+		slog.Debug("no entity provided, deleting by path")
 	}
 
 	result := new(T)
@@ -342,7 +511,7 @@ func Delete[T any](client *Client, entity *T, options *DeleteOptions) (*T, error
 	}
 	if response.IsError() {
 		slog.Error("request failed", "error", response.Error())
-		return nil, fmt.Errorf("HTTP error: %d (%s)", response.StatusCode(), response.Status())
+		return nil, newHTTPError(response)
 	}
 
 	slog.Debug("API call success", "result", result)
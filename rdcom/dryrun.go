@@ -0,0 +1,169 @@
+package rdcom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/dihedron/sms/phone"
+	"github.com/dihedron/sms/pii"
+)
+
+// ErrInvalidRequest is returned in dry-run mode when a planned request
+// would be rejected (e.g. it has unresolved path parameters).
+var ErrInvalidRequest = errors.New("invalid request")
+
+// DryRunHeader is set in the synthetic responses to planned requests.
+const DryRunHeader = "X-Dry-Run"
+
+// PlannedCall is a mutating API call that was built and validated, but not
+// performed, in dry-run mode.
+type PlannedCall struct {
+	// Call holds the HTTP method and the entity path template.
+	Call `yaml:",inline"`
+	// URL is the resolved request URL.
+	URL string `json:"url" yaml:"url"`
+	// Header is the request header, with the credentials redacted.
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	// Body is the serialised request body, if any.
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
+	// Quotation is the expected cost of the call, for message submissions.
+	Quotation *Quotation `json:"quotation,omitempty" yaml:"quotation,omitempty"`
+}
+
+// WithDryRun sets the client in dry-run mode: read-only calls (GET, HEAD and
+// OPTIONS) are performed as usual, whereas mutating calls are built and
+// validated, passed to the given callback and answered locally with a
+// successful response echoing the request body, without reaching the API.
+func WithDryRun(planned func(*PlannedCall)) Option {
+	return func(c *Client) {
+		slog.Debug("enabling dry-run mode")
+		c.planned = planned
+	}
+}
+
+// dryRunTransport is an HTTP transport that only lets read-only requests
+// through, and plans all the others.
+type dryRunTransport struct {
+	next    http.RoundTripper
+	client  *Client
+	planned func(*PlannedCall)
+}
+
+// RoundTrip sends read-only requests through the wrapped transport, and
+// validates and plans the others.
+func (t *dryRunTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.next.RoundTrip(request)
+	}
+
+	call := &PlannedCall{
+		Call:   Call{Method: request.Method, Path: request.URL.Path},
+		URL:    request.URL.String(),
+		Header: request.Header.Clone(),
+	}
+	for _, name := range []string{"Authorization", "Proxy-Authorization"} {
+		if call.Header.Get(name) != "" {
+			call.Header.Set(name, "[redacted]")
+		}
+	}
+	var (
+		entity any
+		params map[string]string
+	)
+	if info, ok := request.Context().Value(callKey{}).(*callInfo); ok {
+		call.Call = info.call
+		entity = info.entity
+		params = info.params
+	}
+
+	body := []byte("{}")
+	if request.Body != nil && request.Body != http.NoBody {
+		data, err := io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			slog.Error("error reading planned request body", "error", err)
+			return nil, err
+		}
+		if len(data) > 0 {
+			body = data
+			call.Body = string(data)
+		}
+	}
+
+	if err := t.validate(request, entity, params); err != nil {
+		slog.Error("invalid planned request", "method", call.Method, "path", call.Path, "error", err)
+		return nil, err
+	}
+	if message, ok := entity.(*Message); ok {
+		call.Quotation = t.quote(params["account"], message)
+	}
+
+	slog.Info("API call planned in dry-run mode", "method", call.Method, "path", call.Path)
+	if t.planned != nil {
+		t.planned(call)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}, DryRunHeader: {"true"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+// validate checks that the request would be accepted by the API as far as
+// it can be told locally.
+func (t *dryRunTransport) validate(request *http.Request, entity any, params map[string]string) error {
+	if path := request.URL.EscapedPath(); strings.Contains(path, "{") || strings.Contains(strings.ToUpper(path), "%7B") {
+		return fmt.Errorf("%w: unresolved path parameters in %s", ErrInvalidRequest, request.URL.Path)
+	}
+	for name, value := range params {
+		if value == "" {
+			return fmt.Errorf("%w: empty path parameter %q", ErrInvalidRequest, name)
+		}
+	}
+	switch entity := entity.(type) {
+	case *Message:
+		if entity.Gateway == 0 {
+			return fmt.Errorf("%w: no SMS gateway", ErrInvalidRequest)
+		}
+		for _, recipient := range entity.Recipients {
+			if normalised, err := phone.Normalise(recipient, ""); err != nil || normalised != recipient {
+				return fmt.Errorf("%w: recipient %s is not in E.164 format", ErrInvalidRequest, pii.PhoneOf(recipient))
+			}
+		}
+	case interface{ Validate() error }:
+		if err := entity.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+	return nil
+}
+
+// quote computes the expected cost of a message from the prices of its
+// gateway; nil is returned if the prices cannot be retrieved.
+func (t *dryRunTransport) quote(account string, message *Message) *Quotation {
+	gateways, err := t.client.SMSGatewayService.List(account)
+	if err != nil {
+		slog.Warn("error retrieving SMS gateways, no cost estimate", "account", account, "error", err)
+		return nil
+	}
+	for _, gateway := range gateways {
+		if gateway.ID == message.Gateway {
+			return Quote(&gateway, message.Recipients, message.Text)
+		}
+	}
+	slog.Warn("SMS gateway not found, no cost estimate", "account", account, "gateway", message.Gateway)
+	return nil
+}
@@ -0,0 +1,108 @@
+package rdcom
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"resty.dev/v3"
+)
+
+// HTTPError is returned when the API responds with an HTTP error status.
+type HTTPError struct {
+	// StatusCode is the HTTP status code (e.g. 401).
+	StatusCode int
+	// Status is the HTTP status line (e.g. "401 Unauthorized").
+	Status string
+}
+
+// Error returns a string representation of the HTTP error.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP error: %d (%s)", e.StatusCode, e.Status)
+}
+
+// ConflictError is returned when the API rejects a request as conflicting
+// with the current state of the entity, either because the request clashes
+// with it (HTTP 409) or because the entity changed since the version the
+// request was based on (HTTP 412, see UpdateOptions.IfMatch).
+type ConflictError struct {
+	HTTPError
+	// ETag is the current version of the entity, if the API provides it.
+	ETag string
+}
+
+// Unwrap returns the underlying HTTP error.
+func (e *ConflictError) Unwrap() error {
+	return &e.HTTPError
+}
+
+// Stale reports whether the entity changed since the version the request
+// was based on.
+func (e *ConflictError) Stale() bool {
+	return e.StatusCode == http.StatusPreconditionFailed
+}
+
+// newHTTPError creates a new HTTP error from the given response; conflicts
+// are returned as a *ConflictError.
+func newHTTPError(response *resty.Response) error {
+	e := HTTPError{
+		StatusCode: response.StatusCode(),
+		Status:     response.Status(),
+	}
+	switch e.StatusCode {
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return &ConflictError{
+			HTTPError: e,
+			ETag:      response.Header().Get("ETag"),
+		}
+	}
+	return &e
+}
+
+// IsUnauthorized reports whether the error is due to the API rejecting the
+// credentials (HTTP 401 or 403).
+func IsUnauthorized(err error) bool {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e.StatusCode == 401 || e.StatusCode == 403
+	}
+	return false
+}
+
+// IsConflict reports whether the error is due to the API rejecting the
+// request as conflicting with the current state (HTTP 409), e.g. because a
+// message with the same client reference was already accepted.
+func IsConflict(err error) bool {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e.StatusCode == 409
+	}
+	return false
+}
+
+// IsPermanent reports whether the error is a rejection that retrying cannot
+// fix, i.e. an HTTP client error other than a timeout (HTTP 408), rate
+// limiting (HTTP 429) or a rejection of the credentials (HTTP 401 or 403),
+// which go away once the token is renewed or rotated.
+func IsPermanent(err error) bool {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		switch e.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return false
+		}
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	}
+	return false
+}
+
+// IsStale reports whether the error is due to the API rejecting an update
+// because the entity changed since it was read (HTTP 412); unlike other
+// conflicts, the update can be retried on the current version.
+func IsStale(err error) bool {
+	var e *ConflictError
+	if errors.As(err, &e) {
+		return e.Stale()
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package rdcom
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&HTTPError{StatusCode: 400}, true},
+		{&HTTPError{StatusCode: 401}, false},
+		{&HTTPError{StatusCode: 403}, false},
+		{&HTTPError{StatusCode: 404}, true},
+		{&HTTPError{StatusCode: 408}, false},
+		{&ConflictError{HTTPError: HTTPError{StatusCode: 409}}, true},
+		{&HTTPError{StatusCode: 422}, true},
+		{&HTTPError{StatusCode: 429}, false},
+		{&HTTPError{StatusCode: 500}, false},
+		{&HTTPError{StatusCode: 503}, false},
+		{fmt.Errorf("wrapped: %w", &HTTPError{StatusCode: 400}), true},
+		{errors.New("connection refused"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			if actual := IsPermanent(test.err); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package rdcom
+
+// The models and services of the endpoints described in openapi.yaml, a
+// hand-maintained description of the RDCom API (RDCom publishes none), are
+// generated into models_gen.go and services_gen.go; the generic helpers
+// (Get, List, PaginatedList, Create and Delete) do the actual work. The
+// methods that do more than place the call (Send, SendRCS, the inbound List
+// and Request) are hand-written on top of the generated models and paths.
+
+//go:generate go run ./internal/gen --spec openapi.yaml --output .
@@ -0,0 +1,183 @@
+package rdcom
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// Event describes a single attempt at an API call; the same event is passed
+// to the BeforeRequest hooks and then to the AfterResponse or OnError hooks
+// of the attempt, so hooks can carry state across phases in its Context.
+type Event struct {
+	// Context is the request context; BeforeRequest hooks may replace it,
+	// and the new context is used to send the request.
+	Context context.Context
+	// Call holds the HTTP method and the entity path before path parameters
+	// are substituted (e.g. "/api/v2/{account}/cds/sms/").
+	Call
+	// URL is the resolved request URL.
+	URL *url.URL
+	// Header is the request header; BeforeRequest hooks may add to it (e.g.
+	// trace propagation headers).
+	Header http.Header
+	// Entity is the entity sent in the request body, if any, before it is
+	// serialised.
+	Entity any
+	// Attempt is the attempt number, starting at 1; it is greater than 1
+	// when the call is being retried.
+	Attempt int
+	// Start is the time at which the attempt started.
+	Start time.Time
+	// StatusCode is the HTTP status code of the response, or 0 if none was
+	// received.
+	StatusCode int
+	// Latency is the time spent waiting for the response.
+	Latency time.Duration
+	// Err is the transport error, or an *HTTPError if the API responded with
+	// an HTTP error status.
+	Err error
+}
+
+// Hooks is a set of callbacks invoked around each API call attempt; any of
+// them can be nil. BeforeRequest is invoked before the request is sent;
+// AfterResponse is invoked whenever a response is received, whatever its
+// status; OnError is invoked when the request could not be sent or no
+// response was received, and also after AfterResponse when the response has
+// an HTTP error status.
+type Hooks struct {
+	BeforeRequest func(*Event)
+	AfterResponse func(*Event)
+	OnError       func(*Event)
+}
+
+// WithHooks adds hooks to be invoked around each API call attempt; hooks
+// are invoked in the order they are added.
+func WithHooks(hooks ...Hooks) Option {
+	return func(c *Client) {
+		slog.Debug("adding API call hooks", "count", len(hooks))
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// installTransports wires the cassette, the hooks and the dry-run mode into
+// the underlying API client; it must be called once, after all options have
+// been applied, so that the wrapping transports wrap the fully configured
+// one. Planned calls never reach the hooks, so they are not audited.
+func (c *Client) installTransports() {
+	if c.cassette == nil && len(c.hooks) == 0 && c.planned == nil {
+		return
+	}
+	transport := c.api.Transport()
+	if c.cassette != nil {
+		transport = c.cassette.wrap(transport)
+	}
+	if len(c.hooks) > 0 || c.planned != nil {
+		c.api.AddRequestMiddleware(func(_ *resty.Client, request *resty.Request) error {
+			// the URL still holds the entity path template at this stage
+			request.SetContext(context.WithValue(request.Context(), callKey{}, &callInfo{
+				call:    Call{Method: request.Method, Path: request.URL},
+				entity:  request.Body,
+				params:  request.PathParams,
+				attempt: request.Attempt,
+			}))
+			return nil
+		})
+	}
+	if len(c.hooks) > 0 {
+		transport = &hookedTransport{
+			next:  transport,
+			hooks: c.hooks,
+		}
+	}
+	if c.planned != nil {
+		transport = &dryRunTransport{
+			next:    transport,
+			client:  c,
+			planned: c.planned,
+		}
+	}
+	c.api.SetTransport(transport)
+}
+
+// callKey is the key under which call information is stored in the request
+// context.
+type callKey struct{}
+
+// callInfo is the information about an API call that is only available
+// before the request is prepared.
+type callInfo struct {
+	call    Call
+	entity  any
+	params  map[string]string
+	attempt int
+}
+
+// hookedTransport is an HTTP transport that invokes hooks around each
+// request it sends.
+type hookedTransport struct {
+	next  http.RoundTripper
+	hooks []Hooks
+}
+
+// RoundTrip sends the request through the wrapped transport, invoking the
+// hooks before and after.
+func (t *hookedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// requests must not be modified by transports, and hooks may add headers
+	request = request.Clone(request.Context())
+
+	event := &Event{
+		Context: request.Context(),
+		Call:    Call{Method: request.Method, Path: request.URL.Path},
+		URL:     request.URL,
+		Header:  request.Header,
+		Attempt: 1,
+		Start:   time.Now(),
+	}
+	if info, ok := request.Context().Value(callKey{}).(*callInfo); ok {
+		event.Call = info.call
+		event.Entity = info.entity
+		event.Attempt = info.attempt
+	}
+
+	for _, hook := range t.hooks {
+		if hook.BeforeRequest != nil {
+			hook.BeforeRequest(event)
+		}
+	}
+	if event.Context != request.Context() {
+		request = request.WithContext(event.Context)
+	}
+
+	response, err := t.next.RoundTrip(request)
+	event.Latency = time.Since(event.Start)
+	if err != nil {
+		event.Err = err
+		for _, hook := range t.hooks {
+			if hook.OnError != nil {
+				hook.OnError(event)
+			}
+		}
+		return response, err
+	}
+
+	event.StatusCode = response.StatusCode
+	for _, hook := range t.hooks {
+		if hook.AfterResponse != nil {
+			hook.AfterResponse(event)
+		}
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		event.Err = &HTTPError{StatusCode: response.StatusCode, Status: response.Status}
+		for _, hook := range t.hooks {
+			if hook.OnError != nil {
+				hook.OnError(event)
+			}
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,157 @@
+package rdcom
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/pointer"
+)
+
+// InboundFilter is the set of (optional) criteria inbound messages are
+// selected by; zero values match everything.
+type InboundFilter struct {
+	// From and To delimit the time range messages were received in.
+	From time.Time
+	To   time.Time
+	// Sender is the number of the sender.
+	Sender string
+	// Keyword is a word the message text must contain (case insensitive).
+	Keyword string
+	// Gateway is the ID of the SMS gateway messages were received on.
+	Gateway *int
+}
+
+// Matches reports whether the given message satisfies the filter.
+func (f *InboundFilter) Matches(message *InboundMessage) bool {
+	if f == nil {
+		return true
+	}
+	if !f.From.IsZero() && message.Received.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !message.Received.Before(f.To) {
+		return false
+	}
+	if f.Sender != "" && message.From != f.Sender {
+		return false
+	}
+	if f.Keyword != "" && !strings.Contains(strings.ToLower(message.Text), strings.ToLower(f.Keyword)) {
+		return false
+	}
+	if f.Gateway != nil && message.Gateway != *f.Gateway {
+		return false
+	}
+	return true
+}
+
+// queryParams converts the filter into API query parameters.
+func (f *InboundFilter) queryParams() map[string]string {
+	params := map[string]string{}
+	if f == nil {
+		return params
+	}
+	if !f.From.IsZero() {
+		params["received_after"] = f.From.Format(time.RFC3339)
+	}
+	if !f.To.IsZero() {
+		params["received_before"] = f.To.Format(time.RFC3339)
+	}
+	if f.Sender != "" {
+		params["from"] = f.Sender
+	}
+	if f.Keyword != "" {
+		params["search"] = f.Keyword
+	}
+	if f.Gateway != nil {
+		params["gateway"] = strconv.Itoa(*f.Gateway)
+	}
+	return params
+}
+
+// List returns the inbound messages of the account matching the filter,
+// oldest first; the filter is also applied client-side, so that results
+// are consistent even where the platform ignores some criteria.
+func (i *InboundService) List(account string, filter *InboundFilter) ([]InboundMessage, error) {
+	if i.client.token == "" {
+		slog.Error("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	options := &PaginatedListOptions{
+		Options: Options{
+			EntityPath: inboundPath,
+			PathParams: map[string]string{
+				"account": account,
+			},
+			QueryParams: filter.queryParams(),
+		},
+		PageSize: pointer.To(100),
+	}
+
+	messages, err := PaginatedList[InboundMessage](i.client, options)
+	if err != nil {
+		slog.Error("error placing API call", "error", err)
+		return nil, err
+	}
+
+	result := make([]InboundMessage, 0, len(messages))
+	for _, message := range messages {
+		if filter.Matches(&message) {
+			result = append(result, message)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Received.Before(result[j].Received)
+	})
+	slog.Debug("API call success", "received", len(messages), "matching", len(result))
+	return result, nil
+}
+
+// Thread is a conversation with a single counterpart.
+type Thread struct {
+	// Counterpart is the number of the counterpart; it identifies the thread.
+	Counterpart string `json:"counterpart" yaml:"counterpart" pii:"phone"`
+	// Gateway is the ID of the SMS gateway the last message was received on,
+	// i.e. the one replies should be sent through.
+	Gateway int `json:"gateway" yaml:"gateway"`
+	// Number is the number (or sender) the last message was addressed to,
+	// i.e. the one replies should be sent from.
+	Number string `json:"number" yaml:"number" pii:"phone"`
+	// Messages holds the messages in the thread, oldest first.
+	Messages []InboundMessage `json:"messages" yaml:"messages"`
+}
+
+// Last returns the most recent message in the thread.
+func (t *Thread) Last() *InboundMessage {
+	return &t.Messages[len(t.Messages)-1]
+}
+
+// Threads groups inbound messages into threads by counterpart; threads are
+// sorted by most recent activity first.
+func Threads(messages []InboundMessage) []Thread {
+	sorted := append([]InboundMessage(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Received.Before(sorted[j].Received)
+	})
+	index := map[string]int{}
+	threads := []Thread{}
+	for _, message := range sorted {
+		i, ok := index[message.From]
+		if !ok {
+			i = len(threads)
+			index[message.From] = i
+			threads = append(threads, Thread{Counterpart: message.From})
+		}
+		threads[i].Messages = append(threads[i].Messages, message)
+		threads[i].Gateway = message.Gateway
+		threads[i].Number = message.To
+	}
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].Last().Received.After(threads[j].Last().Received)
+	})
+	return threads
+}
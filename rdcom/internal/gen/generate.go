@@ -0,0 +1,606 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// header is the first line of generated files, as recognised by Go tools.
+const header = "// Code generated by rdcom/internal/gen from %s; DO NOT EDIT.\n\n"
+
+// initialisms are the words written in upper case in Go identifiers.
+var initialisms = []string{"api", "id", "url"}
+
+// identifier converts a snake or kebab case name into a Go identifier.
+func identifier(name string) string {
+	var result strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		if slices.Contains(initialisms, strings.ToLower(word)) {
+			result.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		result.WriteString(string(runes))
+	}
+	return result.String()
+}
+
+// variable converts a name into an unexported Go identifier.
+func variable(name string) string {
+	id := []rune(identifier(name))
+	for i := 0; i < len(id) && unicode.IsUpper(id[i]); i++ {
+		if i > 0 && i+1 < len(id) && unicode.IsLower(id[i+1]) {
+			break
+		}
+		id[i] = unicode.ToLower(id[i])
+	}
+	return string(id)
+}
+
+// sentence turns a description into the rest of a doc comment sentence
+// following the documented identifier, e.g. "Returns the list." into
+// "returns the list."; descriptions are folded into a single line.
+func sentence(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		return ""
+	}
+	runes := []rune(description)
+	if len(runes) > 1 && !unicode.IsUpper(runes[1]) {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+	result := string(runes)
+	if !strings.HasSuffix(result, ".") {
+		result += "."
+	}
+	return result
+}
+
+// comment formats a doc comment, wrapping it at 80 columns (including the
+// given indentation, in tabs).
+func comment(indent int, format string, args ...any) string {
+	var result strings.Builder
+	line := ""
+	for _, word := range strings.Fields(fmt.Sprintf(format, args...)) {
+		if line != "" && 4*indent+len(line)+1+len(word) > 80 {
+			result.WriteString(line + "\n")
+			line = ""
+		}
+		if line == "" {
+			line = "//"
+		}
+		line += " " + word
+	}
+	if line != "" {
+		result.WriteString(line + "\n")
+	}
+	return result.String()
+}
+
+// file is a generated Go source file.
+type file struct {
+	body    bytes.Buffer
+	imports []string
+}
+
+func (f *file) printf(format string, args ...any) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+func (f *file) use(path string) {
+	if !slices.Contains(f.imports, path) {
+		f.imports = append(f.imports, path)
+	}
+}
+
+// source returns the formatted source of the file.
+func (f *file) source(spec string) ([]byte, error) {
+	var source bytes.Buffer
+	fmt.Fprintf(&source, header, spec)
+	source.WriteString("package rdcom\n\n")
+	if len(f.imports) > 0 {
+		slices.SortFunc(f.imports, func(a, b string) int {
+			// standard library first, then the others
+			if std := strings.Contains(b, ".") && !strings.Contains(a, "."); std {
+				return -1
+			}
+			if std := strings.Contains(a, ".") && !strings.Contains(b, "."); std {
+				return 1
+			}
+			return strings.Compare(a, b)
+		})
+		source.WriteString("import (\n")
+		for i, path := range f.imports {
+			if i > 0 && strings.Contains(path, ".") && !strings.Contains(f.imports[i-1], ".") {
+				source.WriteString("\n")
+			}
+			fmt.Fprintf(&source, "\t%q\n", path)
+		}
+		source.WriteString(")\n\n")
+	}
+	source.Write(f.body.Bytes())
+	return format.Source(source.Bytes())
+}
+
+// goType returns the Go type of a schema.
+func (g *generator) goType(f *file, schema *Schema) (string, error) {
+	name, resolved, err := g.spec.schema(schema)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		return g.typeName(name, resolved), nil
+	}
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date-time" {
+			f.use("time")
+			return "time.Time", nil
+		}
+		if schema.Nullable {
+			return "*string", nil
+		}
+		return "string", nil
+	case "integer":
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if schema.Items == nil {
+			return "", errors.New("array without items")
+		}
+		item, err := g.goType(f, schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if schema.AdditionalProperties != nil && len(schema.Properties) == 0 {
+			value, err := g.goType(f, schema.AdditionalProperties)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		return "", errors.New("inline object schemas are not supported, move them to the component schemas")
+	}
+	return "", fmt.Errorf("unsupported schema type %q", schema.Type)
+}
+
+// typeName returns the name of the Go type of a component schema.
+func (g *generator) typeName(name string, schema *Schema) string {
+	if schema.GoName != "" {
+		return schema.GoName
+	}
+	return name
+}
+
+// generator generates the models and services from an OpenAPI description.
+type generator struct {
+	spec *Spec
+	// path is the path of the description, as recorded in the header.
+	path string
+}
+
+// Models generates the Go types of the component schemas.
+func (g *generator) Models() ([]byte, error) {
+	f := &file{}
+	for _, entry := range g.spec.Components.Schemas {
+		schema := entry.Value
+		items, err := g.spec.envelope(schema)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", entry.Key, err)
+		}
+		if items != nil {
+			// paginated list envelopes are handled by PaginatedList
+			continue
+		}
+		if schema.Type != "object" || len(schema.Properties) == 0 {
+			return nil, fmt.Errorf("schema %s: only object schemas with properties are supported", entry.Key)
+		}
+		name := g.typeName(entry.Key, schema)
+		if description := sentence(schema.Description); description != "" {
+			f.printf("%s", comment(0, "%s represents %s", name, description))
+		}
+		f.printf("type %s struct {\n", name)
+		for _, property := range schema.Properties {
+			field := property.Value.GoName
+			if field == "" {
+				field = identifier(property.Key)
+			}
+			kind := property.Value.GoType
+			if kind == "" {
+				var err error
+				if kind, err = g.goType(f, property.Value); err != nil {
+					return nil, fmt.Errorf("schema %s, property %s: %w", entry.Key, property.Key, err)
+				}
+			}
+			tag, yamlTag := property.Key, property.Key
+			if schema.GoOmitZero || property.Value.GoOmitZero {
+				// omitzero would keep empty (non-nil) slices and maps
+				if strings.HasPrefix(kind, "[]") || strings.HasPrefix(kind, "map[") || strings.HasPrefix(kind, "*") {
+					tag += ",omitempty"
+				} else {
+					tag += ",omitzero"
+				}
+				yamlTag += ",omitempty"
+			}
+			if property.Value.GoLocal {
+				tag = "-"
+			}
+			tags := fmt.Sprintf("json:%q", tag)
+			if schema.GoYAML {
+				tags += fmt.Sprintf(" yaml:%q", yamlTag)
+			}
+			if property.Value.PII != "" {
+				tags += fmt.Sprintf(" pii:%q", property.Value.PII)
+			}
+			if description := sentence(property.Value.Description); description != "" {
+				f.printf("%s", comment(1, "%s is %s", field, description))
+			}
+			f.printf("%s %s `%s`\n", field, kind, tags)
+		}
+		f.printf("}\n\n")
+	}
+	return f.source(g.path)
+}
+
+// placeholders matches the path parameters in a path.
+var placeholders = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// method is a service method to generate.
+type method struct {
+	service string
+	name    string
+	summary string
+	path    string
+	// constant is the constant holding the path, if any.
+	constant  string
+	helper    string
+	entity    string
+	pageSize  int
+	secured   bool
+	arguments []string
+	// params maps path parameters to the method arguments.
+	params [][2]string
+	// body is the request body: "" if none, "entity" if the whole entity
+	// is an argument, or the composite literal built from the arguments.
+	body string
+	// checks are the arguments that must not be empty, with their
+	// descriptions.
+	checks [][2]string
+}
+
+// Services generates the service types and methods of the tagged
+// operations.
+func (g *generator) Services() ([]byte, error) {
+	f := &file{}
+	f.use("errors")
+	f.use("log/slog")
+	services := map[string][]*method{}
+	for _, path := range g.spec.Paths {
+		if path.Value.GoConst != "" {
+			if summary := sentence(path.Value.Summary); summary != "" {
+				f.printf("%s", comment(0, "%s is the entity path of %s", path.Value.GoConst, summary))
+			}
+			f.printf("const %s = %q\n\n", path.Value.GoConst, path.Key)
+		}
+		for _, entry := range path.Value.Operations() {
+			verb, operation := entry.Key, entry.Value
+			if len(operation.Tags) != 1 {
+				return nil, fmt.Errorf("%s %s: operations must have exactly one tag", verb, path.Key)
+			}
+			if operation.GoCustom {
+				// hand-written, but the service must still be declared
+				services[operation.Tags[0]] = append(services[operation.Tags[0]], nil)
+				continue
+			}
+			m, err := g.method(path.Key, verb, operation)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", verb, path.Key, err)
+			}
+			m.constant = path.Value.GoConst
+			services[m.service] = append(services[m.service], m)
+		}
+	}
+
+	for _, tag := range g.spec.Tags {
+		service := tag.Name + "Service"
+		if description := sentence(tag.Description); description != "" {
+			f.printf("%s", comment(0, "%s provides %s", service, description))
+		}
+		f.printf("type %s struct {\n\tService\n}\n\n", service)
+		for _, m := range services[tag.Name] {
+			if m != nil {
+				g.service(f, m)
+			}
+		}
+		delete(services, tag.Name)
+	}
+	for name := range services {
+		return nil, fmt.Errorf("operations tagged %s, which is not declared", name)
+	}
+	return f.source(g.path)
+}
+
+// method collects what is needed to generate the method of an operation.
+func (g *generator) method(path string, verb string, operation *Operation) (*method, error) {
+	m := &method{
+		service:  operation.Tags[0],
+		name:     operation.GoMethod,
+		summary:  operation.Summary,
+		path:     path,
+		pageSize: operation.GoPageSize,
+		secured:  len(g.spec.Security) > 0,
+	}
+	if m.name == "" {
+		m.name = identifier(operation.OperationID)
+	}
+	if m.name == "" {
+		return nil, errors.New("no x-go-method nor operationId")
+	}
+	if operation.Security != nil {
+		m.secured = len(*operation.Security) > 0
+	}
+
+	// path parameters become arguments, in the order they appear in the path
+	parameters := map[string]*Parameter{}
+	for _, parameter := range operation.Parameters {
+		resolved, err := g.spec.parameter(parameter)
+		if err != nil {
+			return nil, err
+		}
+		switch resolved.In {
+		case "path":
+			parameters[resolved.Name] = resolved
+		case "query":
+			switch resolved.Name {
+			case "paginated-view", "limit", "offset":
+				// set by PaginatedList
+			default:
+				return nil, fmt.Errorf("query parameter %s is not supported", resolved.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%s parameter %s is not supported", resolved.In, resolved.Name)
+		}
+	}
+	for _, match := range placeholders.FindAllStringSubmatch(path, -1) {
+		if _, ok := parameters[match[1]]; !ok {
+			return nil, fmt.Errorf("path parameter %s is not declared", match[1])
+		}
+		argument := variable(match[1])
+		switch schema := parameters[match[1]].Schema; {
+		case schema == nil || schema.Type == "string":
+			m.arguments = append(m.arguments, argument+" string")
+			m.params = append(m.params, [2]string{match[1], argument})
+		case schema.Type == "integer":
+			m.arguments = append(m.arguments, argument+" int")
+			m.params = append(m.params, [2]string{match[1], "strconv.Itoa(" + argument + ")"})
+		default:
+			return nil, fmt.Errorf("path parameter %s of type %s is not supported", match[1], schema.Type)
+		}
+		delete(parameters, match[1])
+	}
+	for name := range parameters {
+		return nil, fmt.Errorf("path parameter %s is not in the path", name)
+	}
+
+	// the response determines the helper to use and the entity type
+	var response *Schema
+	for _, entry := range operation.Responses {
+		status, err := strconv.Atoi(entry.Key)
+		if err != nil || status < 200 || status > 299 {
+			continue
+		}
+		if content, ok := entry.Value.Content["application/json"]; ok && content.Schema != nil {
+			response = content.Schema
+			break
+		}
+	}
+	if response == nil {
+		return nil, errors.New("no JSON success response")
+	}
+	_, resolved, err := g.spec.schema(response)
+	if err != nil {
+		return nil, err
+	}
+	items, err := g.spec.envelope(resolved)
+	if err != nil {
+		return nil, err
+	}
+	f := &file{}
+	switch {
+	case verb == "GET" && items != nil:
+		m.helper = "PaginatedList"
+		if m.entity, err = g.goType(f, items); err != nil {
+			return nil, err
+		}
+		if m.pageSize <= 0 {
+			m.pageSize = 100
+		}
+	case verb == "GET" && resolved.Type == "array":
+		m.helper = "List"
+		if m.entity, err = g.goType(f, resolved.Items); err != nil {
+			return nil, err
+		}
+	case verb == "GET":
+		m.helper = "Get"
+	case verb == "POST":
+		m.helper = "Create"
+	case verb == "PUT":
+		m.helper = "Update"
+	case verb == "PATCH":
+		m.helper = "Patch"
+	case verb == "DELETE":
+		m.helper = "Delete"
+	default:
+		return nil, fmt.Errorf("method %s is not supported", verb)
+	}
+	if m.entity == "" {
+		if m.entity, err = g.goType(f, response); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.imports) > 0 || strings.HasPrefix(m.entity, "[]") || strings.HasPrefix(m.entity, "map[") {
+		return nil, fmt.Errorf("response entity must be a component schema, not %s", m.entity)
+	}
+
+	// updates take the entity (or, for patches, any partial body) and the
+	// version they are based on
+	switch m.helper {
+	case "Update", "Patch":
+		if operation.RequestBody == nil {
+			return nil, errors.New("updates need a request body")
+		}
+		if len(operation.RequestBody.GoArguments) > 0 {
+			return nil, errors.New("updates do not support x-go-arguments")
+		}
+		if m.helper == "Update" {
+			m.arguments = append(m.arguments, "entity *"+m.entity)
+			m.body = "entity"
+		} else {
+			m.arguments = append(m.arguments, "patch any")
+			m.body = "patch"
+		}
+		m.arguments = append(m.arguments, "ifMatch string")
+	}
+
+	// the request body, if any, must be an entity of the same type
+	if operation.RequestBody != nil && m.helper != "Patch" {
+		if m.helper != "Create" && m.helper != "Update" && m.helper != "Delete" {
+			return nil, errors.New("request body is only supported when creating, updating and deleting")
+		}
+		content, ok := operation.RequestBody.Content["application/json"]
+		if !ok || content.Schema == nil {
+			return nil, errors.New("request body is not JSON")
+		}
+		name, schema, err := g.spec.schema(content.Schema)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" || g.typeName(name, schema) != m.entity {
+			return nil, fmt.Errorf("request body must be a %s", m.entity)
+		}
+		switch {
+		case m.helper == "Update":
+			// the entity is already among the arguments
+		case len(operation.RequestBody.GoArguments) == 0:
+			m.arguments = append(m.arguments, "entity *"+m.entity)
+			m.body = "entity"
+			if operation.RequestBody.Required {
+				m.checks = append(m.checks, [2]string{"entity == nil", "entity"})
+			}
+		default:
+			fields := []string{}
+			for _, argument := range operation.RequestBody.GoArguments {
+				property, ok := schema.Properties.Get(argument.Property)
+				if !ok {
+					return nil, fmt.Errorf("unknown request body property %s", argument.Property)
+				}
+				if property.Type != "string" || property.Format != "" {
+					return nil, fmt.Errorf("request body property %s is not a plain string", argument.Property)
+				}
+				field := property.GoName
+				if field == "" {
+					field = identifier(argument.Property)
+				}
+				name := argument.Name
+				if name == "" {
+					name = variable(argument.Property)
+				}
+				description := argument.Description
+				if description == "" {
+					description = strings.ReplaceAll(argument.Property, "_", " ")
+				}
+				m.arguments = append(m.arguments, name+" string")
+				fields = append(fields, fmt.Sprintf("%s: %s", field, name))
+				m.checks = append(m.checks, [2]string{name + ` == ""`, description})
+			}
+			m.body = fmt.Sprintf("&%s{%s}", m.entity, strings.Join(fields, ", "))
+		}
+	}
+	return m, nil
+}
+
+// service generates a service method.
+func (g *generator) service(f *file, m *method) {
+	result := "*" + m.entity
+	if m.helper == "List" || m.helper == "PaginatedList" {
+		result = "[]" + m.entity
+	}
+	if summary := sentence(m.summary); summary != "" {
+		f.printf("%s", comment(0, "%s %s", m.name, summary))
+	}
+	for _, param := range m.params {
+		if param[0] == "account" {
+			f.printf("// An empty %s selects the client default account (see WithAccount).\n", param[1])
+		}
+		if strings.HasPrefix(param[1], "strconv.") {
+			f.use("strconv")
+		}
+	}
+	f.printf("func (s *%sService) %s(%s) (%s, error) {\n", m.service, m.name, strings.Join(m.arguments, ", "), result)
+	for _, check := range m.checks {
+		f.printf("if %s {\n", check[0])
+		f.printf("slog.Error(%q)\n", "invalid "+check[1])
+		f.printf("return nil, errors.New(%q)\n", "invalid "+check[1])
+		f.printf("}\n\n")
+	}
+	if m.secured {
+		f.printf("if s.client.token == \"\" {\n")
+		f.printf("slog.Error(\"invalid token\")\n")
+		f.printf("return nil, errors.New(\"invalid token\")\n")
+		f.printf("}\n\n")
+	}
+
+	var options bytes.Buffer
+	if m.constant != "" {
+		fmt.Fprintf(&options, "EntityPath: %s,\n", m.constant)
+	} else {
+		fmt.Fprintf(&options, "EntityPath: %q,\n", m.path)
+	}
+	if len(m.params) > 0 {
+		options.WriteString("PathParams: map[string]string{\n")
+		for _, param := range m.params {
+			fmt.Fprintf(&options, "%q: %s,\n", param[0], param[1])
+		}
+		options.WriteString("},\n")
+	}
+
+	switch m.helper {
+	case "PaginatedList":
+		f.use("github.com/dihedron/sms/pointer")
+		f.printf("options := &PaginatedListOptions{\nOptions: Options{\n%s},\nPageSize: pointer.To(%d),\n}\n\n", options.String(), m.pageSize)
+		f.printf("result, err := PaginatedList[%s](s.client, options)\n", m.entity)
+	case "List", "Get":
+		f.printf("options := &%sOptions{\n%s}\n\n", m.helper, options.String())
+		f.printf("result, err := %s[%s](s.client, options)\n", m.helper, m.entity)
+	case "Update":
+		f.printf("result, err := Update[%s](s.client, entity, &UpdateOptions{\nOptions: Options{\n%s},\nIfMatch: ifMatch,\n})\n", m.entity, options.String())
+	case "Patch":
+		f.printf("result, err := Patch[%s](s.client, patch, &PatchOptions{\nUpdateOptions: UpdateOptions{\nOptions: Options{\n%s},\nIfMatch: ifMatch,\n},\n})\n", m.entity, options.String())
+	case "Create", "Delete":
+		body := m.body
+		if body == "" {
+			body = "nil"
+		}
+		f.printf("result, err := %s[%s](s.client, %s, &%sOptions{\n%s})\n", m.helper, m.entity, body, m.helper, options.String())
+	}
+	f.printf("if err != nil {\n")
+	f.printf("slog.Error(\"error placing API call\", \"error\", err)\n")
+	f.printf("return nil, err\n")
+	f.printf("}\n")
+	f.printf("slog.Debug(\"API call success\")\n")
+	f.printf("return result, nil\n")
+	f.printf("}\n\n")
+}
@@ -0,0 +1,84 @@
+// Command gen generates the rdcom models and services from the description
+// of the RDCom API in rdcom/openapi.yaml, which is maintained by hand in
+// OpenAPI 3 format; it is run by go generate in the rdcom package and, with
+// --check, verifies that the generated code is up to date with the
+// description without writing anything.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// Options are the command line options of the generator.
+type Options struct {
+	// Spec is the path to the OpenAPI description.
+	Spec string `short:"s" long:"spec" description:"The path to the OpenAPI description." default:"openapi.yaml"`
+	// Output is the directory of the generated files.
+	Output string `short:"o" long:"output" description:"The directory of the generated files." default:"."`
+	// Check sets whether to only check that the generated files are current.
+	Check bool `short:"c" long:"check" description:"Check that the generated files are up to date, without writing them." optional:"yes"`
+}
+
+func main() {
+	options := &Options{}
+	if _, err := flags.Parse(options); err != nil {
+		os.Exit(2)
+	}
+	if err := run(options); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(options *Options) error {
+	spec, err := Load(options.Spec)
+	if err != nil {
+		return err
+	}
+	g := &generator{spec: spec, path: filepath.ToSlash(filepath.Base(options.Spec))}
+
+	files := []struct {
+		name     string
+		generate func() ([]byte, error)
+	}{
+		{"models_gen.go", g.Models},
+		{"services_gen.go", g.Services},
+	}
+
+	stale := 0
+	for _, file := range files {
+		source, err := file.generate()
+		if err != nil {
+			slog.Error("error generating code", "file", file.name, "error", err)
+			return fmt.Errorf("error generating %s: %w", file.name, err)
+		}
+		path := filepath.Join(options.Output, file.name)
+		if options.Check {
+			current, err := os.ReadFile(filepath.Clean(path))
+			if err != nil && !os.IsNotExist(err) {
+				slog.Error("error reading generated file", "path", path, "error", err)
+				return err
+			}
+			if !bytes.Equal(current, source) {
+				fmt.Fprintf(os.Stderr, "%s is out of date with %s\n", path, options.Spec)
+				stale++
+			}
+			continue
+		}
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			slog.Error("error writing generated file", "path", path, "error", err)
+			return err
+		}
+		slog.Debug("generated file written", "path", path)
+	}
+	if stale > 0 {
+		return fmt.Errorf("%d generated file(s) out of date, run go generate ./rdcom", stale)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the subset of an OpenAPI 3 description used by the generator.
+type Spec struct {
+	OpenAPI    string                `yaml:"openapi"`
+	Security   []map[string][]string `yaml:"security"`
+	Tags       []Tag                 `yaml:"tags"`
+	Paths      Ordered[*PathItem]    `yaml:"paths"`
+	Components Components            `yaml:"components"`
+}
+
+// Tag groups operations; each tag becomes a service.
+type Tag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// Components holds the reusable parts of the description.
+type Components struct {
+	Schemas    Ordered[*Schema]      `yaml:"schemas"`
+	Parameters map[string]*Parameter `yaml:"parameters"`
+}
+
+// PathItem holds the operations on a path.
+type PathItem struct {
+	Summary string     `yaml:"summary"`
+	Get     *Operation `yaml:"get"`
+	Post    *Operation `yaml:"post"`
+	Put     *Operation `yaml:"put"`
+	Patch   *Operation `yaml:"patch"`
+	Delete  *Operation `yaml:"delete"`
+	// GoConst is the name of the generated constant holding the path, for
+	// the hand-written methods to use.
+	GoConst string `yaml:"x-go-const"`
+}
+
+// Operations returns the operations on the path, by HTTP method, in a
+// stable order.
+func (p *PathItem) Operations() Ordered[*Operation] {
+	all := Ordered[*Operation]{
+		{"GET", p.Get},
+		{"POST", p.Post},
+		{"PUT", p.Put},
+		{"PATCH", p.Patch},
+		{"DELETE", p.Delete},
+	}
+	result := Ordered[*Operation]{}
+	for _, entry := range all {
+		if entry.Value != nil {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// Operation is an API operation.
+type Operation struct {
+	OperationID string                 `yaml:"operationId"`
+	Summary     string                 `yaml:"summary"`
+	Tags        []string               `yaml:"tags"`
+	Security    *[]map[string][]string `yaml:"security"`
+	Parameters  []*Parameter           `yaml:"parameters"`
+	RequestBody *RequestBody           `yaml:"requestBody"`
+	Responses   Ordered[*Response]     `yaml:"responses"`
+	// GoMethod is the name of the generated service method.
+	GoMethod string `yaml:"x-go-method"`
+	// GoPageSize is the page size used to follow paginated lists.
+	GoPageSize int `yaml:"x-go-page-size"`
+	// GoCustom is set when the method is hand-written, e.g. because it
+	// validates the request first; the operation is only described.
+	GoCustom bool `yaml:"x-go-custom"`
+}
+
+// Parameter is an operation parameter, or a reference to one.
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Required    bool    `yaml:"required"`
+	Description string  `yaml:"description"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// RequestBody is the body of an operation request.
+type RequestBody struct {
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+	// GoArguments are the body properties that become method arguments,
+	// instead of the whole entity.
+	GoArguments []Argument `yaml:"x-go-arguments"`
+}
+
+// Argument maps a request body property to a method argument.
+type Argument struct {
+	Property    string `yaml:"property"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// Response is an operation response.
+type Response struct {
+	Description string                `yaml:"description"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType is the content of a request or response.
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Schema is a data type, or a reference to one.
+type Schema struct {
+	Ref                  string           `yaml:"$ref"`
+	Type                 string           `yaml:"type"`
+	Format               string           `yaml:"format"`
+	Description          string           `yaml:"description"`
+	Nullable             bool             `yaml:"nullable"`
+	AllOf                []*Schema        `yaml:"allOf"`
+	Items                *Schema          `yaml:"items"`
+	Properties           Ordered[*Schema] `yaml:"properties"`
+	AdditionalProperties *Schema          `yaml:"additionalProperties"`
+	// GoName overrides the name of the generated type or field.
+	GoName string `yaml:"x-go-name"`
+	// GoType overrides the Go type of a property, e.g. to use a
+	// hand-written named type.
+	GoType string `yaml:"x-go-type"`
+	// GoOmitZero sets whether zero values are omitted when marshalling; it
+	// applies to all the properties of a schema, or to a single property.
+	GoOmitZero bool `yaml:"x-go-omitzero"`
+	// GoYAML sets whether the fields of a type also have YAML tags, for the
+	// types written in the command outputs.
+	GoYAML bool `yaml:"x-go-yaml"`
+	// GoLocal is set on the properties that are filled in by the client and
+	// never exchanged with the API.
+	GoLocal bool `yaml:"x-go-local"`
+	// PII is the kind of personal data, masked in outputs and logs.
+	PII string `yaml:"x-pii"`
+}
+
+// Entry is a key-value pair of an ordered mapping.
+type Entry[T any] struct {
+	Key   string
+	Value T
+}
+
+// Ordered is a YAML mapping that keeps the order of its keys, so that the
+// generated code follows the order of the description.
+type Ordered[T any] []Entry[T]
+
+// UnmarshalYAML decodes a YAML mapping preserving the order of its keys.
+func (o *Ordered[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		*o = append(*o, Entry[T]{Key: node.Content[i].Value, Value: value})
+	}
+	return nil
+}
+
+// Get returns the value with the given key.
+func (o Ordered[T]) Get(key string) (T, bool) {
+	for _, entry := range o {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Load reads and parses an OpenAPI description.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		slog.Error("error reading OpenAPI description", "path", path, "error", err)
+		return nil, err
+	}
+	spec := &Spec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		slog.Error("error parsing OpenAPI description", "path", path, "error", err)
+		return nil, fmt.Errorf("invalid OpenAPI description %s: %w", path, err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		slog.Error("unsupported OpenAPI version", "version", spec.OpenAPI)
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", spec.OpenAPI)
+	}
+	return spec, nil
+}
+
+// schema resolves a schema reference into the name and definition of the
+// referenced component schema; inline schemas have no name.
+func (s *Spec) schema(schema *Schema) (string, *Schema, error) {
+	if schema == nil || schema.Ref == "" {
+		return "", schema, nil
+	}
+	name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported schema reference %q", schema.Ref)
+	}
+	resolved, ok := s.Components.Schemas.Get(name)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown schema %q", name)
+	}
+	return name, resolved, nil
+}
+
+// parameter resolves a parameter reference.
+func (s *Spec) parameter(parameter *Parameter) (*Parameter, error) {
+	if parameter.Ref == "" {
+		return parameter, nil
+	}
+	name, ok := strings.CutPrefix(parameter.Ref, "#/components/parameters/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported parameter reference %q", parameter.Ref)
+	}
+	resolved, ok := s.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %q", name)
+	}
+	return resolved, nil
+}
+
+// properties returns the properties of a schema, merging those of the
+// schemas it is composed of (allOf).
+func (s *Spec) properties(schema *Schema) (Ordered[*Schema], error) {
+	if len(schema.AllOf) == 0 {
+		return schema.Properties, nil
+	}
+	result := Ordered[*Schema]{}
+	for _, part := range schema.AllOf {
+		_, resolved, err := s.schema(part)
+		if err != nil {
+			return nil, err
+		}
+		properties, err := s.properties(resolved)
+		if err != nil {
+			return nil, err
+		}
+		for _, property := range properties {
+			replaced := false
+			for i := range result {
+				if result[i].Key == property.Key {
+					result[i] = property
+					replaced = true
+				}
+			}
+			if !replaced {
+				result = append(result, property)
+			}
+		}
+	}
+	return result, nil
+}
+
+// envelope returns the schema of the items of a paginated list envelope,
+// i.e. of a schema with tot_pages and results properties, or nil if the
+// schema is not an envelope.
+func (s *Spec) envelope(schema *Schema) (*Schema, error) {
+	properties, err := s.properties(schema)
+	if err != nil {
+		return nil, err
+	}
+	_, paginated := properties.Get("tot_pages")
+	results, ok := properties.Get("results")
+	if !paginated || !ok {
+		return nil, nil
+	}
+	if results.Type != "array" || results.Items == nil {
+		return nil, errors.New("paginated list results must be an array")
+	}
+	return results.Items, nil
+}
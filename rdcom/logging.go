@@ -0,0 +1,145 @@
+package rdcom
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dihedron/sms/pii"
+)
+
+// DefaultRedactedNames is the list of header and query parameter names
+// whose values are always redacted by the request logger.
+var DefaultRedactedNames = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"token",
+	"password",
+	"api_key",
+}
+
+// redacted is the placeholder for redacted values.
+const redacted = "[REDACTED]"
+
+// RequestLogger logs each API call attempt as a structured log record.
+type RequestLogger struct {
+	// Logger is the logger to write to; if nil, the default logger is used.
+	Logger *slog.Logger
+	// Level is the level of successful calls; failed calls are logged at
+	// warning level, or higher if Level is higher.
+	Level slog.Level
+	// Headers sets whether the request headers are logged.
+	Headers bool
+	// Redact is the list of additional header and query parameter names
+	// (case insensitive) whose values must be redacted.
+	Redact []string
+}
+
+// WithRequestLogger enables structured logging of API call attempts to the
+// given logger (or the default one, if nil) at info level, redacting
+// credentials and the values of the given header and query parameter names.
+func WithRequestLogger(logger *slog.Logger, redact ...string) Option {
+	return WithHooks((&RequestLogger{
+		Logger: logger,
+		Level:  slog.LevelInfo,
+		Redact: redact,
+	}).Hooks())
+}
+
+// Hooks returns the hooks that log API call attempts.
+func (l *RequestLogger) Hooks() Hooks {
+	return Hooks{
+		AfterResponse: func(event *Event) {
+			if event.StatusCode < 400 {
+				l.log(event, l.Level, "API call completed")
+			}
+		},
+		OnError: func(event *Event) {
+			level := max(l.Level, slog.LevelWarn)
+			if event.StatusCode != 0 {
+				l.log(event, level, "API call failed")
+			} else {
+				l.log(event, level, "API call error")
+			}
+		},
+	}
+}
+
+// log writes the log record for the given event.
+func (l *RequestLogger) log(event *Event, level slog.Level, message string) {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	attributes := []slog.Attr{
+		slog.String("method", event.Method),
+		slog.String("path", event.Path),
+		slog.String("url", l.redactURL(event.URL)),
+		slog.Int("attempt", event.Attempt),
+		slog.Duration("latency", event.Latency),
+	}
+	if event.StatusCode != 0 {
+		attributes = append(attributes, slog.Int("status", event.StatusCode))
+	}
+	if event.Err != nil {
+		attributes = append(attributes, slog.String("error", event.Err.Error()))
+	}
+	if l.Headers {
+		attributes = append(attributes, slog.Any("headers", l.redactHeader(event.Header)))
+	}
+	logger.LogAttrs(context.Background(), level, message, attributes...)
+}
+
+// redactURL returns the URL with user info and sensitive query parameter
+// values redacted, and the values of those holding PII masked.
+func (l *RequestLogger) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clone := *u
+	query := clone.Query()
+	for name, values := range query {
+		if l.isRedacted(name) {
+			query.Set(name, redacted)
+			continue
+		}
+		for i, value := range values {
+			values[i] = pii.Param(name, value)
+		}
+	}
+	clone.RawQuery = query.Encode()
+	return clone.Redacted()
+}
+
+// redactHeader returns a copy of the header with sensitive values redacted.
+func (l *RequestLogger) redactHeader(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		if l.isRedacted(name) {
+			result[name] = redacted
+		} else {
+			result[name] = strings.Join(values, ", ")
+		}
+	}
+	return result
+}
+
+// isRedacted reports whether values of the given name must be redacted.
+func (l *RequestLogger) isRedacted(name string) bool {
+	for _, candidate := range DefaultRedactedNames {
+		if strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+	for _, candidate := range l.Redact {
+		if strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+	return false
+}
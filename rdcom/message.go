@@ -0,0 +1,77 @@
+package rdcom
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// IdempotencyKeyHeader is the HTTP header carrying the client reference of
+// a message, which the platform uses to discard duplicate submissions.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewClientRef generates a random client reference for a message, in the
+// form of a version 4 UUID.
+func NewClientRef() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer) // never returns an error, see crypto/rand
+	buffer[6] = (buffer[6] & 0x0f) | 0x40
+	buffer[8] = (buffer[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buffer[0:4], buffer[4:6], buffer[6:8], buffer[8:10], buffer[10:16])
+}
+
+// Send submits a message for delivery; recipients in the suppression list
+// are skipped and reported in the result, and if no recipient is left
+// nothing is sent and ErrAllSuppressed is returned. ErrNoSuppressor is
+// returned if the client has no suppression list.
+func (m *MessageService) Send(account string, message *Message) (*Message, error) {
+	if message == nil || len(message.Recipients) == 0 {
+		slog.Error("no recipients provided")
+		return nil, errors.New("no recipients provided")
+	}
+	if message.Text == "" {
+		slog.Error("no message text provided")
+		return nil, errors.New("no message text provided")
+	}
+
+	if m.client.token == "" {
+		slog.Error("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	allowed, suppressed, err := m.client.suppress(message.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowed) == 0 {
+		slog.Warn("all recipients are suppressed", "recipients", suppressed)
+		return nil, fmt.Errorf("%w: %s", ErrAllSuppressed, strings.Join(suppressed, ", "))
+	}
+	if len(suppressed) > 0 {
+		filtered := *message
+		filtered.Recipients = allowed
+		message = &filtered
+	}
+
+	options := &CreateOptions{
+		EntityPath: messagesPath,
+		PathParams: map[string]string{
+			"account": account,
+		},
+	}
+	if message.ClientRef != "" {
+		options.Headers = map[string]string{
+			IdempotencyKeyHeader: message.ClientRef,
+		}
+	}
+	result, err := Create(m.client, message, options)
+	if err != nil {
+		slog.Error("error placing API call", "error", err)
+		return nil, err
+	}
+	result.Suppressed = suppressed
+	slog.Debug("API call success", "id", result.ID, "suppressed", len(suppressed))
+	return result, nil
+}
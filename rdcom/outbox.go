@@ -0,0 +1,403 @@
+package rdcom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutboxStatus is the state of a message in the outbox.
+type OutboxStatus string
+
+// List of outbox message states.
+const (
+	// OutboxPending messages are waiting to be sent, or to be retried.
+	OutboxPending OutboxStatus = "pending"
+	// OutboxSent messages have been accepted by the platform.
+	OutboxSent OutboxStatus = "sent"
+	// OutboxSuppressed messages were not sent because all their recipients
+	// are in the suppression list.
+	OutboxSuppressed OutboxStatus = "suppressed"
+	// OutboxFailed messages were rejected by the platform, or ran out of
+	// attempts.
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// Defaults of the outbox retry policy.
+const (
+	DefaultOutboxMaxAttempts = 20
+	DefaultOutboxBackoff     = 5 * time.Second
+	DefaultOutboxMaxBackoff  = 10 * time.Minute
+)
+
+// ErrOutboxEntryNotFound is returned when a message is not in the outbox.
+var ErrOutboxEntryNotFound = errors.New("message not found in outbox")
+
+// OutboxEntry is a message in the outbox and its delivery state.
+type OutboxEntry struct {
+	// Ref is the client reference of the message, which identifies it
+	// both in the outbox and towards the platform.
+	Ref     string   `json:"ref" yaml:"ref"`
+	Account string   `json:"account" yaml:"account"`
+	Message *Message `json:"message" yaml:"message"`
+	// Result is the message as accepted by the platform, once sent.
+	Result *Message `json:"result,omitempty" yaml:"result,omitempty"`
+	// Suppressed is the list of recipients that were skipped because they
+	// are in the suppression list.
+	Suppressed []string     `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+	Status     OutboxStatus `json:"status" yaml:"status"`
+	Attempts   int          `json:"attempts" yaml:"attempts"`
+	// Error is the error of the last attempt, if any.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Next is the time of the next attempt, for pending messages.
+	Next    time.Time `json:"next,omitzero" yaml:"next,omitempty"`
+	Created time.Time `json:"created" yaml:"created"`
+	Updated time.Time `json:"updated" yaml:"updated"`
+}
+
+// Outbox is a durable queue of outgoing messages: each message is written
+// to disk, as one JSON file in a directory, before any attempt to send it,
+// and it is retried with exponential backoff until the platform accepts or
+// rejects it. Every message carries a client reference that is sent as
+// idempotency key, so that messages sent again after a crash or a timeout
+// (or by two workers draining the same directory) are delivered only once.
+type Outbox struct {
+	// MaxAttempts is the maximum number of attempts per message; if zero,
+	// DefaultOutboxMaxAttempts is used.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled at every further
+	// retry; if zero, DefaultOutboxBackoff is used.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between retries; if zero,
+	// DefaultOutboxMaxBackoff is used.
+	MaxBackoff time.Duration
+
+	dir    string
+	client *Client
+	// lock serialises access to the files, drain serialises drains.
+	lock  sync.Mutex
+	drain sync.Mutex
+	wake  chan struct{}
+}
+
+// OpenOutbox opens the outbox in the given directory, creating it if needed;
+// messages are sent through the given client.
+func OpenOutbox(client *Client, dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		slog.Error("error creating outbox directory", "path", dir, "error", err)
+		return nil, err
+	}
+	return &Outbox{
+		dir:    filepath.Clean(dir),
+		client: client,
+		wake:   make(chan struct{}, 1),
+	}, nil
+}
+
+// Enqueue stores the message in the outbox and returns its entry; the
+// message is sent by the next drain. If the message has no client reference
+// a new one is generated; if a message with the same reference is already in
+// the outbox, nothing is stored and the existing entry is returned, so that
+// enqueueing again after an error is safe.
+func (o *Outbox) Enqueue(account string, message *Message) (*OutboxEntry, error) {
+	if message == nil || len(message.Recipients) == 0 {
+		slog.Error("no recipients provided")
+		return nil, errors.New("no recipients provided")
+	}
+	if message.Text == "" {
+		slog.Error("no message text provided")
+		return nil, errors.New("no message text provided")
+	}
+
+	stored := *message
+	if stored.ClientRef == "" {
+		stored.ClientRef = NewClientRef()
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if entry, err := o.read(stored.ClientRef); err == nil {
+		slog.Info("message already in outbox", "ref", entry.Ref, "status", entry.Status)
+		return entry, nil
+	} else if !errors.Is(err, ErrOutboxEntryNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &OutboxEntry{
+		Ref:     stored.ClientRef,
+		Account: account,
+		Message: &stored,
+		Status:  OutboxPending,
+		Next:    now,
+		Created: now,
+	}
+	if err := o.write(entry); err != nil {
+		return nil, err
+	}
+	slog.Debug("message stored in outbox", "ref", entry.Ref)
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return entry, nil
+}
+
+// Get returns the outbox entry of the message with the given reference.
+func (o *Outbox) Get(ref string) (*OutboxEntry, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.read(ref)
+}
+
+// List returns the entries in the outbox, sorted by creation time; entries
+// that cannot be read are logged and skipped.
+func (o *Outbox) List() ([]*OutboxEntry, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		slog.Error("error reading outbox directory", "path", o.dir, "error", err)
+		return nil, err
+	}
+	entries := []*OutboxEntry{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		entry, err := o.read(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			slog.Warn("skipping unreadable outbox entry", "file", file.Name(), "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.Before(entries[j].Created)
+	})
+	return entries, nil
+}
+
+// Purge removes the entries of the messages that are no longer pending and
+// were last updated before the given time, and returns how many were removed.
+func (o *Outbox) Purge(before time.Time) (int, error) {
+	entries, err := o.List()
+	if err != nil {
+		return 0, err
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	count := 0
+	for _, entry := range entries {
+		if entry.Status == OutboxPending || !entry.Updated.Before(before) {
+			continue
+		}
+		if err := os.Remove(o.path(entry.Ref)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("error removing outbox entry", "ref", entry.Ref, "error", err)
+			return count, err
+		}
+		count++
+	}
+	slog.Debug("outbox purged", "count", count)
+	return count, nil
+}
+
+// Run drains the outbox at every interval, and whenever a message is
+// enqueued, until the context is done.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := o.Drain(ctx); err != nil {
+			slog.Error("error draining outbox", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// Drain attempts to send the pending messages that are due, and returns how
+// many were sent; failed attempts are recorded in the entries and retried by
+// later drains, so the returned error only reports outbox storage failures.
+// Each attempt is recorded before the message is sent, so that a crash in
+// between leaves the message pending, to be sent again with the same client
+// reference.
+func (o *Outbox) Drain(ctx context.Context) (int, error) {
+	o.drain.Lock()
+	defer o.drain.Unlock()
+
+	entries, err := o.List()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if entry.Status != OutboxPending || time.Now().Before(entry.Next) {
+			continue
+		}
+		ok, err := o.attempt(entry)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// attempt sends the message of the entry once, and records the outcome.
+func (o *Outbox) attempt(entry *OutboxEntry) (bool, error) {
+	entry.Attempts++
+	if err := o.save(entry); err != nil {
+		return false, err
+	}
+
+	slog.Debug("sending message from outbox", "ref", entry.Ref, "attempt", entry.Attempts)
+	result, err := o.client.MessageService.Send(entry.Account, entry.Message)
+	switch {
+	case err == nil:
+		slog.Info("message from outbox sent", "ref", entry.Ref, "id", result.ID, "attempts", entry.Attempts)
+		entry.Status = OutboxSent
+		entry.Result = result
+		entry.Suppressed = result.Suppressed
+		entry.Error = ""
+	case errors.Is(err, ErrAllSuppressed):
+		slog.Warn("message from outbox suppressed", "ref", entry.Ref)
+		entry.Status = OutboxSuppressed
+		entry.Error = err.Error()
+	case IsConflict(err):
+		// an earlier attempt (possibly by another worker) reached the
+		// platform, but its response was lost: the reference is a duplicate
+		slog.Info("message from outbox already accepted", "ref", entry.Ref, "attempts", entry.Attempts)
+		entry.Status = OutboxSent
+		entry.Error = ""
+	case IsPermanent(err):
+		slog.Error("message from outbox rejected", "ref", entry.Ref, "error", err)
+		entry.Status = OutboxFailed
+		entry.Error = err.Error()
+	case entry.Attempts >= o.maxAttempts():
+		slog.Error("message from outbox ran out of attempts", "ref", entry.Ref, "attempts", entry.Attempts, "error", err)
+		entry.Status = OutboxFailed
+		entry.Error = err.Error()
+	default:
+		entry.Next = time.Now().Add(o.backoff(entry.Attempts))
+		entry.Error = err.Error()
+		slog.Warn("error sending message from outbox, will retry", "ref", entry.Ref, "attempts", entry.Attempts, "next", entry.Next, "error", err)
+	}
+	if entry.Status != OutboxPending {
+		entry.Next = time.Time{}
+	}
+	return entry.Status == OutboxSent, o.save(entry)
+}
+
+// maxAttempts returns the maximum number of attempts per message.
+func (o *Outbox) maxAttempts() int {
+	if o.MaxAttempts > 0 {
+		return o.MaxAttempts
+	}
+	return DefaultOutboxMaxAttempts
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay, limit := o.Backoff, o.MaxBackoff
+	if delay <= 0 {
+		delay = DefaultOutboxBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultOutboxMaxBackoff
+	}
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// save writes the entry under the lock.
+func (o *Outbox) save(entry *OutboxEntry) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.write(entry)
+}
+
+// write writes the entry to disk, replacing the previous version atomically
+// and flushing it to stable storage before returning.
+func (o *Outbox) write(entry *OutboxEntry) error {
+	entry.Updated = time.Now()
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := o.path(entry.Ref)
+	temp := path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Error("error creating outbox entry", "path", temp, "error", err)
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		slog.Error("error writing outbox entry", "path", temp, "error", err)
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		slog.Error("error replacing outbox entry", "path", path, "error", err)
+		return err
+	}
+	// make the rename itself durable
+	if dir, err := os.Open(o.dir); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// read reads the entry with the given reference from disk.
+func (o *Outbox) read(ref string) (*OutboxEntry, error) {
+	if strings.ContainsAny(ref, `/\`) || ref == "" {
+		return nil, fmt.Errorf("%w: %q", ErrOutboxEntryNotFound, ref)
+	}
+	data, err := os.ReadFile(o.path(ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxEntryNotFound, ref)
+	}
+	if err != nil {
+		slog.Error("error reading outbox entry", "ref", ref, "error", err)
+		return nil, err
+	}
+	entry := &OutboxEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		slog.Error("error parsing outbox entry", "ref", ref, "error", err)
+		return nil, fmt.Errorf("invalid outbox entry %s: %w", ref, err)
+	}
+	return entry, nil
+}
+
+// path returns the path of the file of the entry with the given reference.
+func (o *Outbox) path(ref string) string {
+	return filepath.Join(o.dir, ref+".json")
+}
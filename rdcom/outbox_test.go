@@ -0,0 +1,224 @@
+package rdcom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lost is a platform response that accepts the message but is not received
+// by the client before it times out.
+const lost = -1
+
+// platform is a fake messaging platform that answers the successive
+// submissions with the given status codes (accepting the message for lost
+// and success responses), then accepts every new reference and rejects the
+// known ones as duplicates.
+type platform struct {
+	responses []int
+	lock      sync.Mutex
+	keys      []string
+	accepted  map[string]bool
+	done      chan struct{}
+}
+
+func (p *platform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	p.lock.Lock()
+	p.keys = append(p.keys, key)
+	status := 0
+	if len(p.responses) > 0 {
+		status, p.responses = p.responses[0], p.responses[1:]
+	}
+	switch {
+	case status == 0 && p.accepted[key]:
+		status = http.StatusConflict
+	case status == 0 || status == lost:
+		p.accepted[key] = true
+	}
+	p.lock.Unlock()
+
+	switch status {
+	case 0:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%q,"client_ref":%q,"gateway":1,"recipients":["+393331234567"],"text":"text","status":"accepted"}`, "id-"+key, key)
+	case lost:
+		select {
+		case <-p.done:
+		case <-time.After(time.Second):
+		}
+	default:
+		w.WriteHeader(status)
+	}
+}
+
+// outbox returns an outbox sending to the fake platform answering with the
+// given status codes, and the platform itself.
+func outbox(t *testing.T, responses ...int) (*Outbox, *platform) {
+	t.Helper()
+	p := &platform{responses: responses, accepted: map[string]bool{}, done: make(chan struct{})}
+	server := httptest.NewServer(p)
+	t.Cleanup(func() {
+		close(p.done)
+		server.Close()
+	})
+	client, err := New(WithBaseURL(server.URL), WithAuthToken("token"), WithTimeout(100*time.Millisecond), WithoutSuppression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	o, err := OpenOutbox(client, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Backoff = time.Millisecond
+	o.MaxBackoff = time.Millisecond
+	o.MaxAttempts = 3
+	return o, p
+}
+
+// drain drains the outbox the given number of times, waiting for the
+// backoff in between, and returns the number of messages sent.
+func drain(t *testing.T, o *Outbox, times int) int {
+	t.Helper()
+	sent := 0
+	for range times {
+		count, err := o.Drain(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent += count
+		time.Sleep(5 * time.Millisecond)
+	}
+	return sent
+}
+
+func TestOutboxDrain(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []int
+		drains    int
+		status    OutboxStatus
+		attempts  int
+		result    bool
+	}{
+		{"accepted", nil, 1, OutboxSent, 1, true},
+		{"server error, then accepted", []int{http.StatusServiceUnavailable}, 2, OutboxSent, 2, true},
+		{"server error, pending", []int{http.StatusInternalServerError}, 1, OutboxPending, 1, false},
+		{"server errors, out of attempts", []int{502, 502, 502, 502}, 4, OutboxFailed, 3, false},
+		{"timeout, then duplicate", []int{lost}, 2, OutboxSent, 2, false},
+		{"duplicate", []int{http.StatusConflict}, 1, OutboxSent, 1, false},
+		{"rejected", []int{http.StatusBadRequest}, 2, OutboxFailed, 1, false},
+		{"token expired, then accepted", []int{http.StatusUnauthorized}, 2, OutboxSent, 2, true},
+		{"token revoked, then accepted", []int{http.StatusForbidden}, 2, OutboxSent, 2, true},
+		{"request timeout, then accepted", []int{http.StatusRequestTimeout}, 2, OutboxSent, 2, true},
+		{"rate limited, then accepted", []int{http.StatusTooManyRequests}, 2, OutboxSent, 2, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o, p := outbox(t, test.responses...)
+			entry, err := o.Enqueue("acme", &Message{Gateway: 1, Recipients: []string{"+393331234567"}, Text: "text"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			drain(t, o, test.drains)
+
+			entry, err = o.Get(entry.Ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Status != test.status {
+				t.Errorf("expected status %s, got %s (error: %s)", test.status, entry.Status, entry.Error)
+			}
+			if entry.Attempts != test.attempts {
+				t.Errorf("expected %d attempts, got %d", test.attempts, entry.Attempts)
+			}
+			if (entry.Result != nil) != test.result {
+				t.Errorf("expected result %t, got %+v", test.result, entry.Result)
+			}
+			if entry.Status != OutboxPending && !entry.Next.IsZero() {
+				t.Errorf("expected no next attempt, got %s", entry.Next)
+			}
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			if len(p.keys) != test.attempts {
+				t.Errorf("expected %d submissions, got %d", test.attempts, len(p.keys))
+			}
+			for _, key := range p.keys {
+				if key != entry.Ref {
+					t.Errorf("expected idempotency key %s, got %s", entry.Ref, key)
+				}
+			}
+		})
+	}
+}
+
+func TestOutboxEnqueueDeduplicates(t *testing.T) {
+	o, p := outbox(t)
+	message := &Message{ClientRef: "ref-1", Gateway: 1, Recipients: []string{"+393331234567"}, Text: "text"}
+	if _, err := o.Enqueue("acme", message); err != nil {
+		t.Fatal(err)
+	}
+	if sent := drain(t, o, 1); sent != 1 {
+		t.Fatalf("expected 1 message sent, got %d", sent)
+	}
+
+	// enqueueing the same reference again, e.g. after a crash, is a no-op
+	entry, err := o.Enqueue("acme", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Status != OutboxSent {
+		t.Errorf("expected the existing entry, got status %s", entry.Status)
+	}
+	if sent := drain(t, o, 1); sent != 0 {
+		t.Errorf("expected nothing sent, got %d", sent)
+	}
+	entries, err := o.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(p.keys) != 1 {
+		t.Errorf("expected 1 entry and 1 submission, got %d and %d", len(entries), len(p.keys))
+	}
+}
+
+func TestOutboxConcurrentDrains(t *testing.T) {
+	o, p := outbox(t)
+	for range 5 {
+		if _, err := o.Enqueue("acme", &Message{Gateway: 1, Recipients: []string{"+393331234567"}, Text: "text"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wait sync.WaitGroup
+	for range 3 {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if _, err := o.Drain(t.Context()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wait.Wait()
+
+	entries, err := o.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Status != OutboxSent {
+			t.Errorf("expected entry %s sent, got %s", entry.Ref, entry.Status)
+		}
+	}
+	if len(p.keys) != 5 {
+		data, _ := json.Marshal(p.keys)
+		t.Errorf("expected 5 submissions, got %s", data)
+	}
+}
@@ -0,0 +1,116 @@
+package rdcom
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/dihedron/sms/phone"
+)
+
+// Quotation is the estimated cost of sending a message to a set of
+// recipients through a given SMS gateway.
+type Quotation struct {
+	// Gateway is the ID of the SMS gateway the quotation refers to.
+	Gateway int `json:"gateway" yaml:"gateway"`
+	// GatewayType is the type of the SMS gateway.
+	GatewayType string `json:"gateway_type" yaml:"gateway_type"`
+	// Segmentation describes how the message is split into SMS segments.
+	Segmentation Segmentation `json:"segmentation" yaml:"segmentation"`
+	// Lines is the per-country cost breakdown, sorted by country code.
+	Lines []QuotationLine `json:"lines" yaml:"lines"`
+	// Unpriced is the list of recipients whose destination has no price
+	// on the gateway, or whose country could not be determined.
+	Unpriced []string `json:"unpriced,omitempty" yaml:"unpriced,omitempty" pii:"phone"`
+	// Recipients is the number of priced recipients.
+	Recipients int `json:"recipients" yaml:"recipients"`
+	// Segments is the total number of segments across priced recipients.
+	Segments int `json:"segments" yaml:"segments"`
+	// Total is the total cost across priced recipients.
+	Total float64 `json:"total" yaml:"total"`
+}
+
+// QuotationLine is the cost of sending a message to all the recipients in
+// a given country.
+type QuotationLine struct {
+	// Country is the destination country.
+	Country phone.Country `json:"country" yaml:"country"`
+	// PriceKey is the key of the gateway price list that matched the country.
+	PriceKey string `json:"price_key" yaml:"price_key"`
+	// UnitPrice is the price of a single SMS segment.
+	UnitPrice float64 `json:"unit_price" yaml:"unit_price"`
+	// Recipients is the number of recipients in the country.
+	Recipients int `json:"recipients" yaml:"recipients"`
+	// Segments is the total number of segments sent to the country.
+	Segments int `json:"segments" yaml:"segments"`
+	// Cost is the cost of all the segments sent to the country.
+	Cost float64 `json:"cost" yaml:"cost"`
+}
+
+// PriceFor returns the price of a single SMS segment sent through the
+// gateway to the given country, along with the price list key that
+// matched; the price list may be keyed by ISO 3166-1 alpha-2 code (in
+// either case) or by international calling code (with or without "+").
+func (g *SMSGateway) PriceFor(country phone.Country) (string, float64, bool) {
+	candidates := []string{
+		country.Code,
+		strings.ToLower(country.Code),
+		country.Prefix,
+		"+" + country.Prefix,
+	}
+	for _, key := range candidates {
+		if price, ok := g.Prices[key]; ok {
+			return key, price, true
+		}
+	}
+	return "", 0, false
+}
+
+// Quote computes the cost of sending the given text to the given
+// recipients, expressed in E.164 format, through the given gateway.
+func Quote(gateway *SMSGateway, recipients []string, text string) *Quotation {
+	quotation := &Quotation{
+		Gateway:      gateway.ID,
+		GatewayType:  gateway.GatewayType,
+		Segmentation: Segments(text),
+	}
+
+	lines := map[string]*QuotationLine{}
+	for _, recipient := range recipients {
+		country, ok := phone.CountryOf(recipient)
+		if !ok {
+			slog.Debug("unknown destination country", "recipient", recipient)
+			quotation.Unpriced = append(quotation.Unpriced, recipient)
+			continue
+		}
+		key, price, ok := gateway.PriceFor(country)
+		if !ok {
+			slog.Debug("no price for destination country", "recipient", recipient, "country", country.Code, "gateway", gateway.ID)
+			quotation.Unpriced = append(quotation.Unpriced, recipient)
+			continue
+		}
+		line, ok := lines[country.Code]
+		if !ok {
+			line = &QuotationLine{
+				Country:   country,
+				PriceKey:  key,
+				UnitPrice: price,
+			}
+			lines[country.Code] = line
+		}
+		line.Recipients++
+		line.Segments += quotation.Segmentation.Segments
+		line.Cost += price * float64(quotation.Segmentation.Segments)
+	}
+
+	for _, line := range lines {
+		quotation.Lines = append(quotation.Lines, *line)
+		quotation.Recipients += line.Recipients
+		quotation.Segments += line.Segments
+		quotation.Total += line.Cost
+	}
+	sort.Slice(quotation.Lines, func(i, j int) bool {
+		return quotation.Lines[i].Country.Code < quotation.Lines[j].Country.Code
+	})
+	return quotation
+}
@@ -0,0 +1,151 @@
+package rdcom
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dihedron/sms/pii"
+)
+
+// RawMethods are the HTTP methods supported by raw API calls.
+var RawMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// ErrSendCall is returned by raw API calls that would send messages: these
+// must go through MessageService (Send and SendRCS) instead, so that the
+// suppression list, dry runs and the audit of recipients apply to them;
+// OTPs, which have no service, cannot be sent at all.
+var ErrSendCall = errors.New("messages cannot be sent with raw API calls")
+
+// otpPath is the entity path of SMS OTPs, sending a one-time password by
+// SMS when posted to.
+const otpPath = "/api/v2/{account}/cds/otp/sms/"
+
+// sendPaths are the entity paths of the API calls that send messages.
+var sendPaths = []string{messagesPath, rcsMessagesPath, otpPath}
+
+// IsSendCall reports whether an API call with the given method and entity
+// path sends messages; placeholders in the path, if any, match any value.
+func IsSendCall(method string, entity string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return false
+	}
+	entity, _, _ = strings.Cut(entity, "?")
+	segments := strings.FieldsFunc(path.Clean("/"+entity), func(r rune) bool { return r == '/' })
+	for _, template := range sendPaths {
+		expected := strings.FieldsFunc(template, func(r rune) bool { return r == '/' })
+		if len(expected) != len(segments) {
+			continue
+		}
+		matches := true
+		for i, segment := range expected {
+			if !placeholder(segment) && !placeholder(segments[i]) && !strings.EqualFold(segment, segments[i]) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder reports whether a path segment is a path parameter
+// placeholder (e.g. "{account}").
+func placeholder(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// RawOptions are the options of a raw API call, i.e. of a call to an
+// endpoint that has no service in this package.
+type RawOptions struct {
+	Options `json:",inline"`
+	// Body is the (optional) entity to send as JSON.
+	Body any `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// RawResponse is the response to a raw API call.
+type RawResponse struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body is the response body, as received.
+	Body []byte
+}
+
+// Raw performs an API call to an arbitrary endpoint, with the client
+// authentication, transport and hooks, and returns the response as it is;
+// if the API responds with an HTTP error status, the response is returned
+// along with an HTTPError, so that the error details can be shown.
+func Raw(client *Client, method string, options *RawOptions) (*RawResponse, error) {
+	method = strings.ToUpper(method)
+	if !slices.Contains(RawMethods, method) {
+		slog.Error("unsupported HTTP method", "method", method)
+		return nil, fmt.Errorf("unsupported HTTP method %q", method)
+	}
+	if options == nil || options.EntityPath == "" {
+		slog.Error("no entity path provided")
+		return nil, errors.New("no entity path provided")
+	}
+	if IsSendCall(method, options.EntityPath) {
+		slog.Error("raw API call would send messages", "method", method, "entity", options.EntityPath)
+		return nil, fmt.Errorf("%w: %s %s", ErrSendCall, method, options.EntityPath)
+	}
+
+	request := client.api.R()
+
+	if options.QueryParams != nil {
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
+		request.SetQueryParams(options.QueryParams)
+	}
+
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
+	}
+
+	if options.Body != nil {
+		slog.Debug("setting entity", "type", fmt.Sprintf("%T", options.Body))
+		request.SetBody(options.Body).SetAllowMethodDeletePayload(true)
+	}
+
+	response, err := request.Execute(method, options.EntityPath)
+	if err != nil {
+		slog.Error("error performing API request", "method", method, "entity", options.EntityPath, "error", err)
+		return nil, err
+	}
+	result := &RawResponse{
+		StatusCode: response.StatusCode(),
+		Status:     response.Status(),
+		Header:     response.Header(),
+		Body:       response.Bytes(),
+	}
+	if response.IsError() {
+		slog.Error("request failed", "method", method, "entity", options.EntityPath, "status", response.StatusCode())
+		return result, newHTTPError(response)
+	}
+
+	slog.Debug("API call success", "method", method, "entity", options.EntityPath, "status", response.StatusCode())
+	return result, nil
+}
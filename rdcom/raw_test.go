@@ -0,0 +1,69 @@
+package rdcom
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsSendCall(t *testing.T) {
+	tests := []struct {
+		method   string
+		entity   string
+		expected bool
+	}{
+		{"POST", "/api/v2/{account}/cds/sms/messages/", true},
+		{"post", "/api/v2/acme/cds/sms/messages", true},
+		{"PUT", "/api/v2/acme/cds/sms/messages/?dry=1", true},
+		{"POST", "api/v2/acme/cds/rcs/messages/", true},
+		{"POST", "/api/v2/acme/cds/otp/sms/", true},
+		{"PATCH", "/API/V2/acme/CDS/OTP/SMS", true},
+		{"POST", "/api/v2/acme/cds/sms/../otp/sms/", true},
+		{"GET", "/api/v2/acme/cds/otp/sms/", false},
+		{"GET", "/api/v2/acme/cds/sms/messages/", false},
+		{"DELETE", "/api/v2/acme/cds/otp/sms/otp-1/", false},
+		{"POST", "/api/v2/acme/cds/otp/sms/otp-1/validate/", false},
+		{"POST", "/api/v2/acme/cds/sms/blacklist/", false},
+		{"POST", "/api/v2/tokens/", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.entity, func(t *testing.T) {
+			if actual := IsSendCall(test.method, test.entity); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRawRefusesSendCalls(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	client, err := New(WithBaseURL(server.URL), WithAuthToken("token"), WithoutSuppression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	for _, entity := range []string{messagesPath, rcsMessagesPath, otpPath} {
+		_, err := Raw(client, http.MethodPost, &RawOptions{
+			Options: Options{EntityPath: entity, PathParams: map[string]string{"account": "acme"}},
+			Body:    map[string]string{"recipient": "+393331234567"},
+		})
+		if !errors.Is(err, ErrSendCall) {
+			t.Errorf("%s: expected ErrSendCall, got %v", entity, err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("expected nothing sent, got %d calls", calls)
+	}
+
+	if _, err := Raw(client, http.MethodGet, &RawOptions{Options: Options{EntityPath: otpPath, PathParams: map[string]string{"account": "acme"}}}); err != nil || calls != 1 {
+		t.Errorf("expected other calls placed, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package rdcom
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// SuggestionType is the type of a suggestion.
+type SuggestionType string
+
+// List of suggestion types.
+const (
+	// SuggestReply is a suggested reply, sent back as an inbound message.
+	SuggestReply SuggestionType = "reply"
+	// SuggestURL opens a URL.
+	SuggestURL SuggestionType = "url"
+	// SuggestDial dials a phone number.
+	SuggestDial SuggestionType = "dial"
+	// SuggestLocation shows a location on a map.
+	SuggestLocation SuggestionType = "location"
+)
+
+// Limits of RCS messages, as per the GSMA RCS Universal Profile.
+const (
+	MaxSuggestions     = 11
+	MaxCardSuggestions = 4
+	MaxSuggestionText  = 25
+	MaxCardTitle       = 200
+	MaxCardDescription = 2000
+	MinCarouselCards   = 2
+	MaxCarouselCards   = 10
+)
+
+// ErrInvalidRCSMessage is returned when an RCS message is not well formed.
+var ErrInvalidRCSMessage = errors.New("invalid RCS message")
+
+// Validate checks that the message is well formed; all problems are
+// reported, joined.
+func (m *RCSMessage) Validate() error {
+	var result error
+	report := func(format string, args ...any) {
+		result = errors.Join(result, fmt.Errorf("%w: "+format, append([]any{ErrInvalidRCSMessage}, args...)...))
+	}
+	contents := 0
+	if m.Text != "" {
+		contents++
+	}
+	if m.Media != nil {
+		contents++
+		if err := m.Media.validate(); err != nil {
+			report("media: %v", err)
+		}
+	}
+	if m.Card != nil {
+		contents++
+		if err := m.Card.validate(); err != nil {
+			report("card: %v", err)
+		}
+	}
+	if len(m.Carousel) > 0 {
+		contents++
+		if len(m.Carousel) < MinCarouselCards || len(m.Carousel) > MaxCarouselCards {
+			report("carousels must have between %d and %d cards", MinCarouselCards, MaxCarouselCards)
+		}
+		for i := range m.Carousel {
+			if err := m.Carousel[i].validate(); err != nil {
+				report("carousel card %d: %v", i+1, err)
+			}
+		}
+	}
+	if contents != 1 {
+		report("exactly one of text, media, card and carousel must be provided")
+	}
+	if len(m.Suggestions) > MaxSuggestions {
+		report("at most %d suggestions are allowed", MaxSuggestions)
+	}
+	for i := range m.Suggestions {
+		if err := m.Suggestions[i].validate(); err != nil {
+			report("suggestion %d: %v", i+1, err)
+		}
+	}
+	return result
+}
+
+// validate checks the rich card.
+func (c *RichCard) validate() error {
+	var result error
+	if c.Title == "" && c.Description == "" && c.Media == nil {
+		result = errors.Join(result, errors.New("cards must have a title, a description or media"))
+	}
+	if len(c.Title) > MaxCardTitle {
+		result = errors.Join(result, fmt.Errorf("title exceeds %d characters", MaxCardTitle))
+	}
+	if len(c.Description) > MaxCardDescription {
+		result = errors.Join(result, fmt.Errorf("description exceeds %d characters", MaxCardDescription))
+	}
+	if c.Media != nil {
+		if err := c.Media.validate(); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+	if len(c.Suggestions) > MaxCardSuggestions {
+		result = errors.Join(result, fmt.Errorf("at most %d suggestions are allowed in cards", MaxCardSuggestions))
+	}
+	for i := range c.Suggestions {
+		if err := c.Suggestions[i].validate(); err != nil {
+			result = errors.Join(result, fmt.Errorf("suggestion %d: %w", i+1, err))
+		}
+	}
+	return result
+}
+
+// validate checks the media.
+func (m *Media) validate() error {
+	if err := validateURL(m.URL); err != nil {
+		return err
+	}
+	if m.ThumbnailURL != "" {
+		if err := validateURL(m.ThumbnailURL); err != nil {
+			return fmt.Errorf("thumbnail: %w", err)
+		}
+	}
+	switch m.Height {
+	case "", "short", "medium", "tall":
+	default:
+		return fmt.Errorf("invalid media height %q", m.Height)
+	}
+	return nil
+}
+
+// validate checks the suggestion.
+func (s *Suggestion) validate() error {
+	if s.Text == "" {
+		return errors.New("suggestions must have a text")
+	}
+	if length := len([]rune(s.Text)); length > MaxSuggestionText {
+		return fmt.Errorf("suggestion text %q exceeds %d characters", s.Text, MaxSuggestionText)
+	}
+	switch s.Type {
+	case SuggestReply:
+	case SuggestURL:
+		return validateURL(s.URL)
+	case SuggestDial:
+		if s.Phone == "" {
+			return errors.New("dial suggestions must have a phone number")
+		}
+	case SuggestLocation:
+		if s.Latitude < -90 || s.Latitude > 90 || s.Longitude < -180 || s.Longitude > 180 {
+			return errors.New("invalid location coordinates")
+		}
+	default:
+		return fmt.Errorf("unknown suggestion type %q", s.Type)
+	}
+	return nil
+}
+
+// validateURL checks that the URL is an absolute HTTP(S) URL.
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: must be an absolute HTTP(S) URL", value)
+	}
+	return nil
+}
+
+// FallbackText returns the SMS fallback text: the explicit one if set,
+// otherwise a plain text rendering of the message content, with the URLs
+// of media and of URL suggestions.
+func (m *RCSMessage) FallbackText() string {
+	if m.Fallback != "" {
+		return m.Fallback
+	}
+	lines := []string{}
+	if m.Text != "" {
+		lines = append(lines, m.Text)
+	}
+	if m.Media != nil {
+		lines = append(lines, m.Media.URL)
+	}
+	cards := m.Carousel
+	if m.Card != nil {
+		cards = []RichCard{*m.Card}
+	}
+	for _, card := range cards {
+		lines = append(lines, card.fallbackLines()...)
+	}
+	for _, suggestion := range m.Suggestions {
+		if suggestion.Type == SuggestURL {
+			lines = append(lines, fmt.Sprintf("%s: %s", suggestion.Text, suggestion.URL))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fallbackLines renders the card as plain text lines.
+func (c *RichCard) fallbackLines() []string {
+	lines := []string{}
+	if c.Title != "" {
+		lines = append(lines, c.Title)
+	}
+	if c.Description != "" {
+		lines = append(lines, c.Description)
+	}
+	if c.Media != nil {
+		lines = append(lines, c.Media.URL)
+	}
+	for _, suggestion := range c.Suggestions {
+		if suggestion.Type == SuggestURL {
+			lines = append(lines, fmt.Sprintf("%s: %s", suggestion.Text, suggestion.URL))
+		}
+	}
+	return lines
+}
+
+// SendRCS validates and submits an RCS message for delivery, filling in the
+// SMS fallback text if missing; recipients in the suppression list are
+// skipped and reported in the result, and if no recipient is left nothing
+// is sent and ErrAllSuppressed is returned. ErrNoSuppressor is returned if
+// the client has no suppression list.
+func (m *MessageService) SendRCS(account string, message *RCSMessage) (*RCSMessage, error) {
+	if message == nil || len(message.Recipients) == 0 {
+		slog.Error("no recipients provided")
+		return nil, errors.New("no recipients provided")
+	}
+	if err := message.Validate(); err != nil {
+		slog.Error("invalid RCS message", "error", err)
+		return nil, err
+	}
+
+	if m.client.token == "" {
+		slog.Error("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	allowed, suppressed, err := m.client.suppress(message.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowed) == 0 {
+		slog.Warn("all recipients are suppressed", "recipients", suppressed)
+		return nil, fmt.Errorf("%w: %s", ErrAllSuppressed, strings.Join(suppressed, ", "))
+	}
+	outbound := *message
+	outbound.Recipients = allowed
+	outbound.Fallback = message.FallbackText()
+
+	result, err := Create(m.client, &outbound, &CreateOptions{
+		EntityPath: rcsMessagesPath,
+		PathParams: map[string]string{
+			"account": account,
+		},
+	})
+	if err != nil {
+		slog.Error("error placing API call", "error", err)
+		return nil, err
+	}
+	result.Suppressed = suppressed
+	slog.Debug("API call success", "id", result.ID, "suppressed", len(suppressed))
+	return result, nil
+}
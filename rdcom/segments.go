@@ -0,0 +1,103 @@
+package rdcom
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Encoding represents the character encoding used to deliver an SMS.
+type Encoding string
+
+// List of supported SMS encodings.
+const (
+	// GSM7 is the GSM 03.38 7-bit default alphabet.
+	GSM7 Encoding = "GSM-7"
+	// UCS2 is the 16-bit encoding used when the text contains characters
+	// outside of the GSM 03.38 alphabet.
+	UCS2 Encoding = "UCS-2"
+)
+
+// Segmentation describes how a text is split into SMS segments.
+type Segmentation struct {
+	// Encoding is the encoding the text requires.
+	Encoding Encoding `json:"encoding" yaml:"encoding"`
+	// Units is the length of the text in encoding units (septets for
+	// GSM-7, 16-bit code units for UCS-2).
+	Units int `json:"units" yaml:"units"`
+	// Segments is the number of SMS segments needed to deliver the text.
+	Segments int `json:"segments" yaml:"segments"`
+}
+
+// gsm7Basic is the GSM 03.38 basic character set.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension is the GSM 03.38 extension table; each of these characters
+// takes two septets, as it is preceded by an escape character.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// Segments computes the number of SMS segments needed to send the given
+// text: single messages carry up to 160 GSM-7 septets or 70 UCS-2 units,
+// concatenated ones reserve room for the user data header and carry 153
+// and 67 respectively; extension characters never straddle two segments.
+func Segments(text string) Segmentation {
+	if text == "" {
+		return Segmentation{Encoding: GSM7}
+	}
+
+	septets := []int{}
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			septets = append(septets, 1)
+		case strings.ContainsRune(gsm7Extension, r):
+			septets = append(septets, 2)
+		default:
+			return segmentUCS2(text)
+		}
+	}
+
+	units := 0
+	for _, n := range septets {
+		units += n
+	}
+	if units <= 160 {
+		return Segmentation{Encoding: GSM7, Units: units, Segments: 1}
+	}
+	segments, used := 1, 0
+	for _, n := range septets {
+		if used+n > 153 {
+			segments++
+			used = 0
+		}
+		used += n
+	}
+	return Segmentation{Encoding: GSM7, Units: units, Segments: segments}
+}
+
+// segmentUCS2 computes the segmentation of a text requiring UCS-2 encoding;
+// characters outside of the Basic Multilingual Plane take two units and
+// surrogate pairs are never split across segments.
+func segmentUCS2(text string) Segmentation {
+	widths := make([]int, 0, utf8.RuneCountInString(text))
+	units := 0
+	for _, r := range text {
+		width := 1
+		if r > 0xFFFF {
+			width = 2
+		}
+		widths = append(widths, width)
+		units += width
+	}
+	if units <= 70 {
+		return Segmentation{Encoding: UCS2, Units: units, Segments: 1}
+	}
+	segments, used := 1, 0
+	for _, n := range widths {
+		if used+n > 67 {
+			segments++
+			used = 0
+		}
+		used += n
+	}
+	return Segmentation{Encoding: UCS2, Units: units, Segments: segments}
+}
@@ -0,0 +1,42 @@
+package rdcom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	gsm := func(n int) string { return strings.Repeat("a", n) }
+	ucs := func(n int) string { return strings.Repeat("ж", n) }
+
+	tests := []struct {
+		name     string
+		text     string
+		expected Segmentation
+	}{
+		{"empty", "", Segmentation{Encoding: GSM7}},
+		{"GSM-7 single, full", gsm(160), Segmentation{Encoding: GSM7, Units: 160, Segments: 1}},
+		{"GSM-7 concatenated, one over", gsm(161), Segmentation{Encoding: GSM7, Units: 161, Segments: 2}},
+		{"GSM-7 two segments, full", gsm(306), Segmentation{Encoding: GSM7, Units: 306, Segments: 2}},
+		{"GSM-7 three segments, one over", gsm(307), Segmentation{Encoding: GSM7, Units: 307, Segments: 3}},
+		{"GSM-7 extension, single, full", gsm(158) + "€", Segmentation{Encoding: GSM7, Units: 160, Segments: 1}},
+		{"GSM-7 extension, one over", gsm(159) + "€", Segmentation{Encoding: GSM7, Units: 161, Segments: 2}},
+		{"GSM-7 extension not split", gsm(152) + "€" + gsm(152), Segmentation{Encoding: GSM7, Units: 306, Segments: 3}},
+		{"UCS-2 single, full", ucs(70), Segmentation{Encoding: UCS2, Units: 70, Segments: 1}},
+		{"UCS-2 concatenated, one over", ucs(71), Segmentation{Encoding: UCS2, Units: 71, Segments: 2}},
+		{"UCS-2 two segments, full", ucs(134), Segmentation{Encoding: UCS2, Units: 134, Segments: 2}},
+		{"UCS-2 three segments, one over", ucs(135), Segmentation{Encoding: UCS2, Units: 135, Segments: 3}},
+		{"UCS-2 forced by one character", gsm(69) + "ж", Segmentation{Encoding: UCS2, Units: 70, Segments: 1}},
+		{"UCS-2 surrogate pair, single, full", ucs(68) + "😀", Segmentation{Encoding: UCS2, Units: 70, Segments: 1}},
+		{"UCS-2 surrogate pair, one over", ucs(69) + "😀", Segmentation{Encoding: UCS2, Units: 71, Segments: 2}},
+		{"UCS-2 surrogate pair not split", ucs(66) + "😀" + ucs(66), Segmentation{Encoding: UCS2, Units: 134, Segments: 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := Segments(test.text); actual != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package rdcom
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// SenderStatus is the state of a sender registration.
+type SenderStatus string
+
+// List of sender registration states.
+const (
+	SenderPending   SenderStatus = "pending"
+	SenderApproved  SenderStatus = "approved"
+	SenderRejected  SenderStatus = "rejected"
+	SenderCancelled SenderStatus = "cancelled"
+)
+
+// Limits of alphanumeric senders, as per GSM 03.38 TP-Originating-Address.
+const (
+	MinSenderLength = 3
+	MaxSenderLength = 11
+)
+
+// ErrInvalidSender is returned when a sender does not abide by the rules
+// of alphanumeric senders.
+var ErrInvalidSender = errors.New("invalid sender")
+
+// ValidateSender checks that the given sender abides by the rules of
+// alphanumeric senders: between 3 and 11 characters among ASCII letters,
+// digits, spaces and ".-_&+", with at least one letter (all-digit senders
+// are phone numbers) and no leading, trailing or consecutive spaces.
+func ValidateSender(sender string) error {
+	if length := len(sender); length < MinSenderLength || length > MaxSenderLength {
+		return fmt.Errorf("%w: %q must be between %d and %d characters long", ErrInvalidSender, sender, MinSenderLength, MaxSenderLength)
+	}
+	letters := 0
+	for _, r := range sender {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+			letters++
+		case r >= '0' && r <= '9', strings.ContainsRune(" .-_&+", r):
+		default:
+			return fmt.Errorf("%w: %q contains invalid character %q", ErrInvalidSender, sender, r)
+		}
+	}
+	if letters == 0 {
+		return fmt.Errorf("%w: %q must contain at least one letter", ErrInvalidSender, sender)
+	}
+	if strings.TrimSpace(sender) != sender || strings.Contains(sender, "  ") {
+		return fmt.Errorf("%w: %q must not have leading, trailing or consecutive spaces", ErrInvalidSender, sender)
+	}
+	return nil
+}
+
+// Request submits a new sender registration request, to be used on the
+// given SMS gateways; the sender is validated before submission.
+func (s *SenderService) Request(account string, sender string, gateways []int, reason string) (*Sender, error) {
+	if err := ValidateSender(sender); err != nil {
+		slog.Error("invalid sender", "sender", sender, "error", err)
+		return nil, err
+	}
+
+	if s.client.token == "" {
+		slog.Error("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	result, err := Create(s.client, &Sender{Sender: sender, Gateways: gateways, Reason: reason}, &CreateOptions{
+		EntityPath: sendersPath,
+		PathParams: map[string]string{
+			"account": account,
+		},
+	})
+	if err != nil {
+		slog.Error("error placing API call", "error", err)
+		return nil, err
+	}
+	slog.Debug("API call success")
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package rdcom
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the API call
+// latency histogram buckets.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Call identifies a family of API calls by HTTP method and entity path,
+// before path parameters are substituted (e.g. "/api/v2/{account}/cds/sms/").
+type Call struct {
+	Method string `json:"method" yaml:"method"`
+	Path   string `json:"path" yaml:"path"`
+}
+
+// CallStatistics holds the aggregated statistics of a family of API calls.
+type CallStatistics struct {
+	// Count is the number of requests sent, including retries.
+	Count int64 `json:"count" yaml:"count"`
+	// Errors is the number of requests that failed, either because of a
+	// transport error or because the API returned an HTTP error status.
+	Errors int64 `json:"errors" yaml:"errors"`
+	// Latency is the total time spent waiting for responses.
+	Latency time.Duration `json:"latency" yaml:"latency"`
+	// Buckets holds the cumulative number of requests whose latency is
+	// less than or equal to the corresponding DefaultLatencyBuckets bound.
+	Buckets []int64 `json:"buckets" yaml:"buckets"`
+}
+
+// Statistics collects latency and error statistics of the API calls placed
+// by a client; it is safe for concurrent use.
+type Statistics struct {
+	lock  sync.Mutex
+	calls map[Call]*CallStatistics
+}
+
+// NewStatistics creates a new, empty statistics collector.
+func NewStatistics() *Statistics {
+	return &Statistics{
+		calls: map[Call]*CallStatistics{},
+	}
+}
+
+// WithStatistics sets the collector of API call statistics.
+func WithStatistics(statistics *Statistics) Option {
+	return WithHooks(statistics.Hooks())
+}
+
+// Hooks returns the hooks that feed API call outcomes to the statistics.
+func (s *Statistics) Hooks() Hooks {
+	return Hooks{
+		AfterResponse: func(event *Event) {
+			s.record(event.Call, event.Latency, event.StatusCode >= 400)
+		},
+		OnError: func(event *Event) {
+			// responses have already been accounted for
+			if event.StatusCode == 0 {
+				s.record(event.Call, event.Latency, true)
+			}
+		},
+	}
+}
+
+// Snapshot is a point-in-time copy of the statistics, by API call.
+type Snapshot map[Call]CallStatistics
+
+// Snapshot returns a copy of the current statistics, by API call.
+func (s *Statistics) Snapshot() Snapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snapshot := make(Snapshot, len(s.calls))
+	for call, statistics := range s.calls {
+		value := *statistics
+		value.Buckets = append([]int64(nil), statistics.Buckets...)
+		snapshot[call] = value
+	}
+	return snapshot
+}
+
+// Calls returns the list of API calls in the snapshot, sorted by path and
+// method; calls recorded after the snapshot was taken are not included.
+func (s Snapshot) Calls() []Call {
+	calls := make([]Call, 0, len(s))
+	for call := range s {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Path != calls[j].Path {
+			return calls[i].Path < calls[j].Path
+		}
+		return calls[i].Method < calls[j].Method
+	})
+	return calls
+}
+
+// record adds an API call outcome to the statistics.
+func (s *Statistics) record(call Call, latency time.Duration, failed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	statistics, ok := s.calls[call]
+	if !ok {
+		statistics = &CallStatistics{
+			Buckets: make([]int64, len(DefaultLatencyBuckets)),
+		}
+		s.calls[call] = statistics
+	}
+	statistics.Count++
+	if failed {
+		statistics.Errors++
+	}
+	statistics.Latency += latency
+	for i, bound := range DefaultLatencyBuckets {
+		if latency.Seconds() <= bound {
+			statistics.Buckets[i]++
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package rdcom
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Suppressor tells whether a recipient must never be messaged (e.g.
+// because it opted out); it is consulted by every send function and
+// suppressed recipients are skipped.
+type Suppressor interface {
+	Suppressed(number string) bool
+}
+
+// ErrAllSuppressed is returned when all the recipients of a message are
+// suppressed, and nothing is sent.
+var ErrAllSuppressed = errors.New("all recipients are suppressed")
+
+// ErrNoSuppressor is returned by the send functions of a client that has
+// neither a suppression list (see WithSuppressor) nor an explicit opt-out
+// (see WithoutSuppression), so that no message is ever sent unchecked.
+var ErrNoSuppressor = errors.New("no suppression list set")
+
+// WithSuppressor sets the suppression list consulted before sending.
+func WithSuppressor(suppressor Suppressor) Option {
+	return func(c *Client) {
+		slog.Debug("setting suppression list")
+		c.suppressor = suppressor
+		c.unsuppressed = false
+	}
+}
+
+// WithoutSuppression lets the client send messages without consulting a
+// suppression list, e.g. in tests or against a simulator.
+func WithoutSuppression() Option {
+	return func(c *Client) {
+		slog.Debug("disabling suppression list")
+		c.suppressor = nil
+		c.unsuppressed = true
+	}
+}
+
+// suppress splits the recipients into those that can be messaged and those
+// that are suppressed; it fails if the client has no suppression list and
+// did not opt out of it.
+func (c *Client) suppress(recipients []string) (allowed []string, suppressed []string, err error) {
+	if c.suppressor == nil {
+		if c.unsuppressed {
+			return recipients, nil, nil
+		}
+		slog.Error("no suppression list set, refusing to send")
+		return nil, nil, ErrNoSuppressor
+	}
+	for _, recipient := range recipients {
+		if c.suppressor.Suppressed(recipient) {
+			slog.Info("skipping suppressed recipient", "number", recipient)
+			suppressed = append(suppressed, recipient)
+		} else {
+			allowed = append(allowed, recipient)
+		}
+	}
+	return allowed, suppressed, nil
+}
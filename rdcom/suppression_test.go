@@ -0,0 +1,78 @@
+package rdcom
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// numbers is a Suppressor backed by a list of numbers.
+type numbers []string
+
+func (n numbers) Suppressed(number string) bool {
+	return slices.Contains(n, number)
+}
+
+func TestSendSuppression(t *testing.T) {
+	tests := []struct {
+		name        string
+		option      Option
+		recipients  []string
+		err         error
+		submissions int
+		suppressed  []string
+	}{
+		{"no suppression list", nil, []string{"+393331234567"}, ErrNoSuppressor, 0, nil},
+		{"opted out", WithoutSuppression(), []string{"+393331234567"}, nil, 1, nil},
+		{"empty suppression list", WithSuppressor(numbers{}), []string{"+393331234567"}, nil, 1, nil},
+		{"suppressed recipient", WithSuppressor(numbers{"+393331234568"}), []string{"+393331234567", "+393331234568"}, nil, 1, []string{"+393331234568"}},
+		{"all recipients suppressed", WithSuppressor(numbers{"+393331234567"}), []string{"+393331234567"}, ErrAllSuppressed, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := &platform{accepted: map[string]bool{}, done: make(chan struct{})}
+			server := httptest.NewServer(p)
+			defer server.Close()
+			options := []Option{WithBaseURL(server.URL), WithAuthToken("token")}
+			if test.option != nil {
+				options = append(options, test.option)
+			}
+			client, err := New(options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			for _, send := range []func() ([]string, error){
+				func() ([]string, error) {
+					sent, err := client.MessageService.Send("acme", &Message{Gateway: 1, Recipients: test.recipients, Text: "text"})
+					if sent == nil {
+						return nil, err
+					}
+					return sent.Suppressed, err
+				},
+				func() ([]string, error) {
+					sent, err := client.MessageService.SendRCS("acme", &RCSMessage{Gateway: 1, Recipients: test.recipients, Text: "text"})
+					if sent == nil {
+						return nil, err
+					}
+					return sent.Suppressed, err
+				},
+			} {
+				p.keys, p.accepted = nil, map[string]bool{}
+				suppressed, err := send()
+				if !errors.Is(err, test.err) {
+					t.Errorf("expected error %v, got %v", test.err, err)
+				}
+				if len(p.keys) != test.submissions {
+					t.Errorf("expected %d submissions, got %d", test.submissions, len(p.keys))
+				}
+				if !slices.Equal(suppressed, test.suppressed) {
+					t.Errorf("expected %v suppressed, got %v", test.suppressed, suppressed)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package rdcom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Tracer creates spans for API calls and propagates the trace context to the
+// API server; it is modelled after the OpenTelemetry API, so that a thin
+// adapter is enough to plug in an OpenTelemetry tracer and propagator.
+type Tracer interface {
+	// Start creates a new span, as a child of the span in the context if
+	// any, and returns a context holding it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the propagation headers for the span in the context
+	// into the given header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is an operation being traced.
+type Span interface {
+	// SetAttribute sets an attribute on the span.
+	SetAttribute(key string, value any)
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+// WithTracer enables tracing of API calls: each attempt at an API call
+// gets its own span, named after the method and entity path, and the trace
+// context is propagated to the API server in the request headers.
+func WithTracer(tracer Tracer) Option {
+	return WithHooks(Hooks{
+		BeforeRequest: func(event *Event) {
+			ctx, span := tracer.Start(event.Context, fmt.Sprintf("%s %s", event.Method, event.Path))
+			span.SetAttribute("http.request.method", event.Method)
+			span.SetAttribute("url.template", event.Path)
+			span.SetAttribute("url.full", event.URL.Redacted())
+			if event.Attempt > 1 {
+				span.SetAttribute("http.request.resend_count", event.Attempt-1)
+			}
+			tracer.Inject(ctx, event.Header)
+			event.Context = context.WithValue(ctx, tracingKey{}, span)
+		},
+		AfterResponse: func(event *Event) {
+			if span, ok := event.Context.Value(tracingKey{}).(Span); ok {
+				span.SetAttribute("http.response.status_code", event.StatusCode)
+				if event.StatusCode >= 400 {
+					span.RecordError(&HTTPError{StatusCode: event.StatusCode, Status: fmt.Sprintf("%d %s", event.StatusCode, http.StatusText(event.StatusCode))})
+				}
+				span.End()
+			}
+		},
+		OnError: func(event *Event) {
+			// spans of calls with a response have already been ended
+			if event.StatusCode != 0 {
+				return
+			}
+			if span, ok := event.Context.Value(tracingKey{}).(Span); ok {
+				span.RecordError(event.Err)
+				span.End()
+			}
+		},
+	})
+}
+
+// tracingKey is the key under which the span of an API call attempt is
+// stored in the event context.
+type tracingKey struct{}
+
+// TraceContextTracer is a minimal Tracer that propagates W3C Trace Context
+// ("traceparent") headers and hands completed spans to a callback; it lets
+// API calls join an existing trace without an OpenTelemetry dependency.
+type TraceContextTracer struct {
+	// OnEnd is invoked when a span ends; if nil, spans are logged at debug level.
+	OnEnd func(*SpanRecord)
+}
+
+// SpanRecord is a span created by the TraceContextTracer.
+type SpanRecord struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]any
+	Err        error
+
+	lock   sync.Mutex
+	tracer *TraceContextTracer
+}
+
+// Start creates a new span, as a child of the span (or remote parent set
+// with ContextWithTraceParent) in the context.
+func (t *TraceContextTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &SpanRecord{
+		SpanID:     randomHex(8),
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: map[string]any{},
+		tracer:     t,
+	}
+	if traceID, parentID, ok := parentFrom(ctx); ok {
+		span.TraceID = traceID
+		span.ParentID = parentID
+	} else {
+		span.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, spanRecordKey{}, span), span
+}
+
+// Inject writes the "traceparent" header for the span in the context.
+func (t *TraceContextTracer) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(spanRecordKey{}).(*SpanRecord); ok {
+		header.Set("traceparent", span.TraceParent())
+	}
+}
+
+// TraceParent returns the W3C "traceparent" header value for the span.
+func (s *SpanRecord) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID, s.SpanID)
+}
+
+// SetAttribute sets an attribute on the span.
+func (s *SpanRecord) SetAttribute(key string, value any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Attributes[key] = value
+}
+
+// RecordError marks the span as failed.
+func (s *SpanRecord) RecordError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Err = err
+}
+
+// End completes the span and hands it to the tracer callback.
+func (s *SpanRecord) End() {
+	s.lock.Lock()
+	s.EndTime = time.Now()
+	s.lock.Unlock()
+	if s.tracer.OnEnd != nil {
+		s.tracer.OnEnd(s)
+		return
+	}
+	slog.Debug("span ended", "name", s.Name, "trace", s.TraceID, "span", s.SpanID, "parent", s.ParentID, "duration", s.EndTime.Sub(s.StartTime), "attributes", s.Attributes, "error", s.Err)
+}
+
+// ContextWithTraceParent returns a context that makes API call spans
+// children of the remote span identified by the given W3C "traceparent"
+// header value (e.g. the one received by the embedding service); invalid
+// values are ignored.
+func ContextWithTraceParent(ctx context.Context, traceparent string) context.Context {
+	if !traceParentPattern.MatchString(traceparent) {
+		slog.Warn("ignoring invalid traceparent", "value", traceparent)
+		return ctx
+	}
+	return context.WithValue(ctx, traceParentKey{}, traceparent)
+}
+
+// traceParentPattern matches version 00 W3C "traceparent" header values.
+var traceParentPattern = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// spanRecordKey is the key under which the current SpanRecord is stored in
+// the context.
+type spanRecordKey struct{}
+
+// traceParentKey is the key under which a remote parent is stored in the
+// context.
+type traceParentKey struct{}
+
+// parentFrom returns the trace and parent span IDs from the context.
+func parentFrom(ctx context.Context) (string, string, bool) {
+	if span, ok := ctx.Value(spanRecordKey{}).(*SpanRecord); ok {
+		return span.TraceID, span.SpanID, true
+	}
+	if traceparent, ok := ctx.Value(traceParentKey{}).(string); ok {
+		// 00-<trace id>-<parent id>-<flags>
+		return traceparent[3:35], traceparent[36:52], true
+	}
+	return "", "", false
+}
+
+// randomHex returns a random hexadecimal string of the given size in bytes.
+func randomHex(size int) string {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		slog.Error("error generating random identifier", "error", err)
+	}
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,229 @@
+package rdcom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dihedron/sms/pii"
+)
+
+// UpdateOptions are the options of Update.
+type UpdateOptions struct {
+	Options `json:",inline"`
+	// IfMatch is the (optional) version the update is based on, i.e. the
+	// ETag returned by GetVersioned; if set, the API refuses the update with
+	// a stale ConflictError if the entity changed in the meantime.
+	IfMatch string `json:"if_match,omitempty" yaml:"if_match,omitempty"`
+}
+
+// PatchOptions are the options of Patch.
+type PatchOptions struct {
+	UpdateOptions `json:",inline"`
+	// Fields is the (optional) field mask: the JSON names of the only fields
+	// to send, with dots separating nested fields (e.g. "infos.email"); if
+	// empty, the patch is sent as it is, so partial updates are expressed
+	// with pointer fields marked omitempty, which are left out when nil.
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// staleRetries is how many times ReadModifyWrite reads and modifies an
+// entity again when it changes before it can be written back.
+const staleRetries = 3
+
+// GetVersioned performs an API request to retrieve one single entity along
+// with its version, i.e. the ETag response header; the version is empty if
+// the API does not provide it.
+func GetVersioned[T any](client *Client, options *GetOptions) (*T, string, error) {
+	request := client.api.R()
+
+	if options.QueryParams != nil {
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
+		request.SetQueryParams(options.QueryParams)
+	}
+
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
+	}
+
+	result := new(T)
+	request.SetResult(result)
+	response, err := request.Get(options.EntityPath)
+	if err != nil {
+		slog.Error("error performing GET API request", "entity", options.EntityPath, "error", err)
+		return nil, "", err
+	}
+	if response.IsError() {
+		slog.Error("request failed", "error", response.Error())
+		return nil, "", newHTTPError(response)
+	}
+
+	etag := response.Header().Get("ETag")
+	slog.Debug("GET API call successful", "path", options.EntityPath, "etag", etag)
+	return result, etag, nil
+}
+
+// Update performs an API request to replace an existing entity (PUT).
+func Update[T any](client *Client, entity *T, options *UpdateOptions) (*T, error) {
+	if entity == nil {
+		slog.Error("no entity provided")
+		return nil, errors.New("no entity provided")
+	}
+	slog.Debug("setting entity", "type", fmt.Sprintf("%T", entity), "value", *entity)
+	return modify[T](client, http.MethodPut, entity, options)
+}
+
+// Patch performs an API request to partially update an existing entity
+// (PATCH); the patch is usually a struct with pointer fields, or the entity
+// itself along with a field mask selecting what to send.
+func Patch[T any](client *Client, patch any, options *PatchOptions) (*T, error) {
+	if patch == nil {
+		slog.Error("no patch provided")
+		return nil, errors.New("no patch provided")
+	}
+	body := patch
+	if len(options.Fields) > 0 {
+		masked, err := mask(patch, options.Fields)
+		if err != nil {
+			slog.Error("error applying field mask", "fields", options.Fields, "error", err)
+			return nil, err
+		}
+		body = masked
+	}
+	slog.Debug("setting patch", "type", fmt.Sprintf("%T", patch), "fields", options.Fields)
+	return modify[T](client, http.MethodPatch, body, &options.UpdateOptions)
+}
+
+// ReadModifyWrite retrieves an entity, applies the changes and writes it back
+// with Update, so that changes made in the meantime by others are not lost:
+// if the API provides versions, the update is conditional and the cycle is
+// retried a few times when the entity changes before it can be written back,
+// then a stale ConflictError is returned; otherwise, the last write wins.
+// The same options address the entity both when reading and when writing.
+func ReadModifyWrite[T any](client *Client, options *UpdateOptions, changes func(*T) error) (*T, error) {
+	for attempt := 1; ; attempt++ {
+		entity, etag, err := GetVersioned[T](client, (*GetOptions)(&options.Options))
+		if err != nil {
+			slog.Error("error reading entity", "entity", options.EntityPath, "error", err)
+			return nil, err
+		}
+		if etag == "" {
+			slog.Warn("API provides no entity version, last write wins", "entity", options.EntityPath)
+		}
+		if err := changes(entity); err != nil {
+			slog.Error("error modifying entity", "entity", options.EntityPath, "error", err)
+			return nil, err
+		}
+		update := *options
+		update.IfMatch = etag
+		result, err := Update[T](client, entity, &update)
+		if etag != "" && IsStale(err) && attempt < staleRetries {
+			slog.Info("entity changed before it could be written back, retrying", "entity", options.EntityPath, "attempt", attempt)
+			continue
+		}
+		return result, err
+	}
+}
+
+// modify performs an API request to update an existing entity, with the
+// given method and body.
+func modify[T any](client *Client, method string, body any, options *UpdateOptions) (*T, error) {
+	request := client.api.R()
+
+	if options.QueryParams != nil {
+		slog.Debug("setting query params", "values", pii.Params(options.QueryParams))
+		request.SetQueryParams(options.QueryParams)
+	}
+
+	if options.Headers != nil {
+		slog.Debug("setting headers", "names", slices.Collect(maps.Keys(options.Headers)))
+		request.SetHeaders(options.Headers)
+	}
+
+	if params := client.pathParams(options.PathParams); params != nil {
+		slog.Debug("setting path params", "values", pii.Params(params))
+		request.SetPathParams(params)
+	}
+
+	if options.IfMatch != "" {
+		slog.Debug("setting precondition", "if-match", options.IfMatch)
+		request.SetHeader("If-Match", options.IfMatch)
+	}
+
+	result := new(T)
+	response, err := request.
+		SetBody(body).
+		SetResult(result).
+		Execute(method, options.EntityPath)
+	if err != nil {
+		slog.Error("error performing API request", "method", method, "error", err)
+		return nil, err
+	}
+	if response.IsError() {
+		slog.Error("request failed", "method", method, "error", response.Error())
+		return nil, newHTTPError(response)
+	}
+
+	slog.Debug("API call success", "result", result)
+	return result, nil
+}
+
+// mask returns the JSON object with only the given fields of the body, with
+// dots separating nested fields; fields missing from the body are an error,
+// so that a misspelt mask never silently sends nothing.
+func mask(body any, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	source := map[string]any{}
+	if err := decoder.Decode(&source); err != nil {
+		return nil, fmt.Errorf("field masks need an object body: %w", err)
+	}
+
+	result := map[string]any{}
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		var (
+			value any = source
+			ok        = true
+		)
+		for _, name := range path {
+			var object map[string]any
+			if object, ok = value.(map[string]any); !ok {
+				break
+			}
+			if value, ok = object[name]; !ok {
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("field %q not in the body", field)
+		}
+		target := result
+		for _, name := range path[:len(path)-1] {
+			next, ok := target[name].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				target[name] = next
+			}
+			target = next
+		}
+		target[path[len(path)-1]] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package rdcom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	account := &Account{Name: "ACME", Code: "acme", SmsCredits: 12.5}
+	account.Infos.Email = "info@example.com"
+	account.Infos.City = "Rome"
+
+	tests := []struct {
+		name     string
+		body     any
+		fields   []string
+		expected string
+		fails    bool
+	}{
+		{"top level", account, []string{"name"}, `{"name":"ACME"}`, false},
+		{"nested", account, []string{"infos.email", "infos.city", "code"}, `{"code":"acme","infos":{"city":"Rome","email":"info@example.com"}}`, false},
+		{"whole object", account, []string{"limits"}, `{"limits":{"max_lists":0,"max_recipients_per_day":0,"max_recipients_per_month":0,"max_recipients_per_year":0}}`, false},
+		{"numbers kept as they are", account, []string{"sms_credists"}, `{"sms_credists":12.5}`, false},
+		{"missing field", account, []string{"name", "nmae"}, "", true},
+		{"missing nested field", account, []string{"infos.mail"}, "", true},
+		{"nested field of a scalar", account, []string{"name.first"}, "", true},
+		{"not an object", []string{"a"}, []string{"name"}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			masked, err := mask(test.body, test.fields)
+			if test.fails {
+				if err == nil {
+					t.Errorf("expected an error, got %v", masked)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(masked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+// versioned is a fake API endpoint serving a versioned entity: it answers
+// GETs with the entity and its ETag, and applies PUTs and PATCHes only if
+// their If-Match matches the current version, unless conflicts is positive,
+// in which case someone else changes the entity (i.e. its version) first.
+type versioned struct {
+	lock sync.Mutex
+	// etags sets whether versions are provided at all.
+	etags     bool
+	version   int
+	entity    map[string]any
+	conflicts int
+	// requests records the method, If-Match header and body of updates.
+	requests [][3]string
+}
+
+func (v *versioned) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	etag := fmt.Sprintf(`"v%d"`, v.version)
+	if r.Method == http.MethodGet {
+		if v.etags {
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v.entity)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	v.requests = append(v.requests, [3]string{r.Method, r.Header.Get("If-Match"), strings.TrimSpace(string(body))})
+	if v.conflicts > 0 {
+		v.conflicts--
+		v.version++
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != fmt.Sprintf(`"v%d"`, v.version) {
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, v.version))
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	changes := map[string]any{}
+	json.Unmarshal(body, &changes)
+	for key, value := range changes {
+		v.entity[key] = value
+	}
+	v.version++
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v.entity)
+}
+
+// updates returns a client for the fake versioned endpoint.
+func updates(t *testing.T, v *versioned) *Client {
+	t.Helper()
+	if v.entity == nil {
+		v.entity = map[string]any{"name": "ACME", "code": "acme"}
+	}
+	server := httptest.NewServer(v)
+	t.Cleanup(server.Close)
+	client, err := New(WithBaseURL(server.URL), WithAuthToken("token"), WithoutSuppression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestPatch(t *testing.T) {
+	v := &versioned{etags: true}
+	client := updates(t, v)
+	account, etag, err := GetVersioned[Account](client, &GetOptions{EntityPath: "/accounts/acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != `"v0"` {
+		t.Errorf("expected the ETag returned, got %q", etag)
+	}
+
+	account.Name = "ACME Inc."
+	account.Infos.Email = "info@example.com"
+	options := &PatchOptions{
+		UpdateOptions: UpdateOptions{Options: Options{EntityPath: "/accounts/acme"}, IfMatch: etag},
+		Fields:        []string{"name", "infos.email"},
+	}
+	if _, err := Patch[Account](client, account, options); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][3]string{{http.MethodPatch, `"v0"`, `{"infos":{"email":"info@example.com"},"name":"ACME Inc."}`}}
+	if !reflect.DeepEqual(v.requests, expected) {
+		t.Errorf("expected %v, got %v", expected, v.requests)
+	}
+
+	// the version is now stale
+	_, err = Patch[Account](client, account, options)
+	if !IsStale(err) {
+		t.Errorf("expected a stale conflict, got %v", err)
+	}
+	if conflict := err.(*ConflictError); conflict.ETag != `"v1"` {
+		t.Errorf("expected the current version reported, got %q", conflict.ETag)
+	}
+
+	// a field missing from the body is never sent
+	options.Fields = []string{"infos.mail"}
+	v.requests = nil
+	if _, err := Patch[Account](client, account, options); err == nil || len(v.requests) > 0 {
+		t.Errorf("expected an error and nothing sent, got %v and %v", err, v.requests)
+	}
+}
+
+func TestReadModifyWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		etags     bool
+		conflicts int
+		attempts  int
+		stale     bool
+	}{
+		{"no conflict", true, 0, 1, false},
+		{"conflict, then written", true, 1, 2, false},
+		{"conflicts until out of retries", true, staleRetries, staleRetries, true},
+		{"no versions, last write wins", false, 1, 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &versioned{etags: test.etags, conflicts: test.conflicts}
+			client := updates(t, v)
+			reads := 0
+			result, err := ReadModifyWrite(client, &UpdateOptions{Options: Options{EntityPath: "/accounts/acme"}}, func(account *Account) error {
+				reads++
+				account.Name += "!"
+				return nil
+			})
+			if IsStale(err) != test.stale {
+				t.Fatalf("expected stale %t, got %v", test.stale, err)
+			}
+			if !test.stale && (err != nil || result.Name != "ACME!") {
+				t.Errorf("expected the change applied once, got %+v (error: %v)", result, err)
+			}
+			if reads != test.attempts || len(v.requests) != test.attempts {
+				t.Errorf("expected %d attempts, got %d reads and %d writes", test.attempts, reads, len(v.requests))
+			}
+			for i, request := range v.requests {
+				expected := ""
+				if test.etags {
+					// each attempt is based on the version read by it
+					expected = fmt.Sprintf(`"v%d"`, i)
+				}
+				if request[0] != http.MethodPut || request[1] != expected {
+					t.Errorf("attempt %d: expected PUT with If-Match %q, got %s with %q", i+1, expected, request[0], request[1])
+				}
+			}
+		})
+	}
+}
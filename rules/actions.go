@@ -0,0 +1,279 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/dihedron/sms/format"
+	"github.com/dihedron/sms/rdcom"
+	"resty.dev/v3"
+)
+
+// ActionType is the type of an action.
+type ActionType string
+
+// List of action types.
+const (
+	// Reply answers the message with the rendered template.
+	Reply ActionType = "reply"
+	// Webhook posts the message and the rule details as JSON to a URL.
+	Webhook ActionType = "webhook"
+	// Append appends the rendered template (or the message as JSON, if no
+	// template is given) as a line to a file.
+	Append ActionType = "append"
+	// Exec runs a command through the shell, with the message details in
+	// the environment.
+	Exec ActionType = "exec"
+	// OptOut adds the sender to the opt-out list; since opted-out numbers
+	// are never messaged, confirmation replies must come before it.
+	OptOut ActionType = "optout"
+)
+
+// Action is an action to perform on a matching message.
+type Action struct {
+	Type ActionType `json:"type" yaml:"type"`
+	// Template is the text/template used by reply and append actions.
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// URL is the URL webhook actions post to.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Headers holds additional headers webhook actions send.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Path is the file append actions write to.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Command is the shell command exec actions run.
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+
+	tmpl *template.Template
+}
+
+// compile validates the action and parses its template.
+func (a *Action) compile() error {
+	switch a.Type {
+	case Reply:
+		if a.Template == "" {
+			return errors.New("reply actions require a template")
+		}
+	case Webhook:
+		if a.URL == "" {
+			return errors.New("webhook actions require a URL")
+		}
+	case Append:
+		if a.Path == "" {
+			return errors.New("append actions require a path")
+		}
+	case Exec:
+		if a.Command == "" {
+			return errors.New("exec actions require a command")
+		}
+	case OptOut:
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	if a.Template != "" {
+		tmpl, err := template.New(string(a.Type)).Option("missingkey=zero").Parse(a.Template)
+		if err != nil {
+			return err
+		}
+		a.tmpl = tmpl
+	}
+	return nil
+}
+
+// Describe returns a human readable description of the action on the given
+// hit, with templates rendered; it has no side effects.
+func (a *Action) Describe(hit *Hit) string {
+	switch a.Type {
+	case Reply:
+		text, err := hit.render(a.tmpl)
+		if err != nil {
+			return fmt.Sprintf("reply to %s: template error: %v", hit.Message.From, err)
+		}
+		return fmt.Sprintf("reply to %s: %q", hit.Message.From, text)
+	case Webhook:
+		return fmt.Sprintf("post to %s", a.URL)
+	case Append:
+		if a.tmpl != nil {
+			text, err := hit.render(a.tmpl)
+			if err != nil {
+				return fmt.Sprintf("append to %s: template error: %v", a.Path, err)
+			}
+			return fmt.Sprintf("append to %s: %q", a.Path, text)
+		}
+		return fmt.Sprintf("append to %s: %s", a.Path, format.ToJSON(hit.Message))
+	case Exec:
+		return fmt.Sprintf("run %q", a.Command)
+	case OptOut:
+		return fmt.Sprintf("opt out %s", hit.Message.From)
+	}
+	return string(a.Type)
+}
+
+// Replier sends replies to inbound messages.
+type Replier interface {
+	Reply(hit *Hit, text string) error
+}
+
+// OptOuts records the numbers that opted out of receiving messages.
+type OptOuts interface {
+	OptOut(number string, reason string) error
+}
+
+// Engine performs the actions of the rules matching inbound messages.
+type Engine struct {
+	Config *Config
+	// Replier sends replies; if nil, reply actions fail.
+	Replier Replier
+	// OptOuts records opt-outs; if nil, opt-out actions fail.
+	OptOuts OptOuts
+}
+
+// Process evaluates the rules against the message and performs the actions
+// of the matching ones, returning the hits; all actions are attempted, and
+// the errors (if any) are returned joined.
+func (e *Engine) Process(message *rdcom.InboundMessage) ([]*Hit, error) {
+	hits := e.Config.Evaluate(message)
+	var result error
+	for _, hit := range hits {
+		if err := e.perform(hit); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+	return hits, result
+}
+
+// perform performs the actions of a single hit.
+func (e *Engine) perform(hit *Hit) error {
+	var result error
+	for _, action := range hit.Rule.Actions {
+		slog.Debug("performing action", "rule", hit.Rule.Name, "action", action.Type, "message", hit.Message.ID)
+		var err error
+		switch action.Type {
+		case Reply:
+			err = e.reply(&action, hit)
+		case Webhook:
+			err = post(&action, hit)
+		case Append:
+			err = appendTo(&action, hit)
+		case Exec:
+			err = run(&action, hit)
+		case OptOut:
+			err = e.optOut(hit)
+		}
+		if err != nil {
+			slog.Error("error performing action", "rule", hit.Rule.Name, "action", action.Type, "message", hit.Message.ID, "error", err)
+			result = errors.Join(result, fmt.Errorf("rule %s, action %s: %w", hit.Rule.Name, action.Type, err))
+		}
+	}
+	return result
+}
+
+// reply renders the template and sends it to the sender.
+func (e *Engine) reply(action *Action, hit *Hit) error {
+	if e.Replier == nil {
+		return errors.New("no replier configured")
+	}
+	text, err := hit.render(action.tmpl)
+	if err != nil {
+		return err
+	}
+	return e.Replier.Reply(hit, text)
+}
+
+// optOut adds the sender to the opt-out list.
+func (e *Engine) optOut(hit *Hit) error {
+	if e.OptOuts == nil {
+		return errors.New("no opt-out list configured")
+	}
+	return e.OptOuts.OptOut(hit.Message.From, fmt.Sprintf("rule %s: %s", hit.Rule.Name, hit.Message.Text))
+}
+
+// post sends the hit as a JSON document to the webhook.
+func post(action *Action, hit *Hit) error {
+	client := resty.New().SetTimeout(30 * time.Second)
+	defer client.Close()
+
+	payload := struct {
+		Rule string `json:"rule"`
+		*Hit
+		Time time.Time `json:"time"`
+	}{
+		Rule: hit.Rule.Name,
+		Hit:  hit,
+		Time: time.Now(),
+	}
+	response, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeaders(action.Headers).
+		SetBody(payload).
+		Post(action.URL)
+	if err != nil {
+		slog.Error("error posting message to webhook", "url", action.URL, "error", err)
+		return fmt.Errorf("error posting message to webhook: %w", err)
+	}
+	if response.IsError() {
+		slog.Error("webhook request failed", "url", action.URL, "status", response.StatusCode())
+		return fmt.Errorf("webhook error: %d (%s)", response.StatusCode(), response.Status())
+	}
+	slog.Debug("message posted to webhook", "url", action.URL)
+	return nil
+}
+
+// appendTo appends a line to the file.
+func appendTo(action *Action, hit *Hit) error {
+	// the file is the destination of the message, not a log: keep PII
+	data, err := json.Marshal(hit.Message)
+	if err != nil {
+		return err
+	}
+	line := string(data)
+	if action.tmpl != nil {
+		if line, err = hit.render(action.tmpl); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(filepath.Clean(action.Path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Error("error opening file", "path", action.Path, "error", err)
+		return err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, line); err != nil {
+		slog.Error("error appending to file", "path", action.Path, "error", err)
+		return err
+	}
+	return nil
+}
+
+// run runs the command through the shell, passing the message details in
+// the environment.
+func run(action *Action, hit *Hit) error {
+	command := exec.Command("/bin/sh", "-c", action.Command)
+	command.Env = append(os.Environ(),
+		fmt.Sprintf("SMS_RULE=%s", hit.Rule.Name),
+		fmt.Sprintf("SMS_INBOUND_ID=%s", hit.Message.ID),
+		fmt.Sprintf("SMS_INBOUND_GATEWAY=%s", strconv.Itoa(hit.Message.Gateway)),
+		fmt.Sprintf("SMS_INBOUND_FROM=%s", hit.Message.From),
+		fmt.Sprintf("SMS_INBOUND_TO=%s", hit.Message.To),
+		fmt.Sprintf("SMS_INBOUND_TEXT=%s", hit.Message.Text),
+		fmt.Sprintf("SMS_INBOUND_RECEIVED=%s", hit.Message.Received.Format(time.RFC3339)),
+	)
+	for i, group := range hit.Groups {
+		command.Env = append(command.Env, fmt.Sprintf("SMS_MATCH_%d=%s", i, group))
+	}
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		slog.Error("error running command", "command", action.Command, "error", err)
+		return fmt.Errorf("error running command: %w", err)
+	}
+	slog.Debug("command run", "command", action.Command)
+	return nil
+}
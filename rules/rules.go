@@ -0,0 +1,205 @@
+// Package rules implements a rules engine that matches inbound SMS
+// messages and triggers actions on them, such as auto-replies, webhooks
+// and opt-outs; rules are configured in a YAML file.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/dihedron/sms/rdcom"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the rules configuration file.
+type Config struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule associates a set of matching criteria with the actions to perform
+// on the messages that satisfy them.
+type Rule struct {
+	// Name identifies the rule in logs and outputs.
+	Name string `json:"name" yaml:"name"`
+	// Match holds the matching criteria.
+	Match Match `json:"match" yaml:"match"`
+	// Actions is the list of actions to perform, in order.
+	Actions []Action `json:"actions" yaml:"actions"`
+	// Final stops the evaluation of the rules that follow when the rule
+	// matches (e.g. for STOP keywords).
+	Final bool `json:"final,omitempty" yaml:"final,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// Match holds the criteria a message must satisfy; all the criteria that
+// are specified must be satisfied, and a rule with no criteria matches
+// every message.
+type Match struct {
+	// Keywords is a list of keywords, one of which must be the first word
+	// of the message (case insensitive).
+	Keywords []string `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	// Regex is a regular expression the message text must match; its
+	// capture groups are available to templates.
+	Regex string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	// SenderPrefixes is a list of prefixes, one of which the sender number
+	// (in E.164 format) must start with.
+	SenderPrefixes []string `json:"sender_prefixes,omitempty" yaml:"sender_prefixes,omitempty"`
+	// Gateways is a list of IDs of SMS gateways, one of which the message
+	// must have been received on.
+	Gateways []int `json:"gateways,omitempty" yaml:"gateways,omitempty"`
+}
+
+// ErrInvalidRules is returned when the rules configuration is invalid.
+var ErrInvalidRules = errors.New("invalid rules")
+
+// Load reads and validates the rules configuration file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		slog.Error("error reading rules file", "path", path, "error", err)
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		slog.Error("error parsing rules file", "path", path, "error", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRules, err)
+	}
+	if err := config.Compile(); err != nil {
+		slog.Error("invalid rules file", "path", path, "error", err)
+		return nil, err
+	}
+	slog.Debug("rules loaded", "path", path, "count", len(config.Rules))
+	return config, nil
+}
+
+// Compile validates the rules and prepares them for evaluation.
+func (c *Config) Compile() error {
+	var result error
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if rule.Match.Regex != "" {
+			regex, err := regexp.Compile(rule.Match.Regex)
+			if err != nil {
+				result = errors.Join(result, fmt.Errorf("%w: rule %s: %w", ErrInvalidRules, rule.Name, err))
+			}
+			rule.regex = regex
+		}
+		if len(rule.Actions) == 0 {
+			result = errors.Join(result, fmt.Errorf("%w: rule %s has no actions", ErrInvalidRules, rule.Name))
+		}
+		for j := range rule.Actions {
+			if err := rule.Actions[j].compile(); err != nil {
+				result = errors.Join(result, fmt.Errorf("%w: rule %s, action %d: %w", ErrInvalidRules, rule.Name, j+1, err))
+			}
+		}
+	}
+	return result
+}
+
+// Matches reports whether the message satisfies the rule criteria and, if
+// so, returns the regular expression capture groups (if any).
+func (r *Rule) Matches(message *rdcom.InboundMessage) ([]string, bool) {
+	if len(r.Match.Keywords) > 0 {
+		fields := strings.Fields(message.Text)
+		if len(fields) == 0 {
+			return nil, false
+		}
+		found := false
+		for _, keyword := range r.Match.Keywords {
+			if strings.EqualFold(strings.Trim(fields[0], ".,;:!?"), keyword) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	if len(r.Match.SenderPrefixes) > 0 {
+		found := false
+		for _, prefix := range r.Match.SenderPrefixes {
+			if strings.HasPrefix(message.From, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	if len(r.Match.Gateways) > 0 {
+		found := false
+		for _, gateway := range r.Match.Gateways {
+			if message.Gateway == gateway {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	groups := []string{}
+	if r.regex != nil {
+		if groups = r.regex.FindStringSubmatch(message.Text); groups == nil {
+			return nil, false
+		}
+	}
+	return groups, true
+}
+
+// Evaluate returns the rules matching the message, in order, along with
+// their capture groups; evaluation stops at the first final rule.
+func (c *Config) Evaluate(message *rdcom.InboundMessage) []*Hit {
+	hits := []*Hit{}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if groups, ok := rule.Matches(message); ok {
+			slog.Debug("rule matched", "rule", rule.Name, "message", message.ID)
+			hits = append(hits, &Hit{Rule: rule, Message: message, Groups: groups})
+			if rule.Final {
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// Hit is a rule matching a message.
+type Hit struct {
+	Rule    *Rule                 `json:"-" yaml:"-"`
+	Message *rdcom.InboundMessage `json:"message" yaml:"message"`
+	// Groups holds the regular expression capture groups, the whole match
+	// first.
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// render executes the template against the hit; templates can refer to
+// .Rule, .Message (e.g. .Message.From, .Message.Text) and .Groups.
+func (h *Hit) render(tmpl *template.Template) (string, error) {
+	var builder strings.Builder
+	data := struct {
+		Rule    string
+		Message *rdcom.InboundMessage
+		Groups  []string
+	}{
+		Rule:    h.Rule.Name,
+		Message: h.Message,
+		Groups:  h.Groups,
+	}
+	if err := tmpl.Execute(&builder, data); err != nil {
+		slog.Error("error rendering template", "rule", h.Rule.Name, "error", err)
+		return "", err
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,15 @@
+package scheduler
+
+import (
+	"os"
+	"syscall"
+)
+
+// alive reports whether the process with the given PID is running.
+func alive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
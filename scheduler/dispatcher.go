@@ -0,0 +1,294 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Sender sends messages; the rdcom MessageService is a Sender.
+type Sender interface {
+	Send(account string, message *rdcom.Message) (*rdcom.Message, error)
+}
+
+// DefaultBatchSize is the default maximum number of recipients per message.
+const DefaultBatchSize = 100
+
+// Dispatcher sends the due recipients of the jobs in a queue.
+type Dispatcher struct {
+	Queue  *Queue
+	Sender Sender
+	// Rate is the maximum number of recipients dispatched per minute, across
+	// all jobs; if zero, there is no limit.
+	Rate int
+	// BatchSize is the maximum number of recipients per message; if zero,
+	// DefaultBatchSize is used.
+	BatchSize int
+
+	dispatched []time.Time
+}
+
+// Recover puts the recipients that were in flight when a previous
+// dispatcher stopped abruptly back in the pending state: they may or may
+// not have been messaged, so they are sent again in the same batch and with
+// the same client reference, and the platform discards the duplicates.
+func (d *Dispatcher) Recover() error {
+	jobs, err := d.Queue.List()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		recovered := 0
+		for _, recipient := range job.Recipients {
+			if recipient.Status == InFlight {
+				recipient.Status = Pending
+				recipient.Error = "dispatch interrupted, retrying"
+				recovered++
+			}
+		}
+		if recovered > 0 {
+			slog.Warn("recipients in flight at last stop will be retried", "job", job.ID, "count", recovered)
+			job.settle()
+			unlock, err := d.Queue.lockJob(job.ID)
+			if err != nil {
+				return err
+			}
+			d.cancelled(job)
+			err = d.Queue.Save(job)
+			unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run dispatches due jobs at every interval, until the context is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) error {
+	if err := d.Recover(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Dispatch(ctx, time.Now()); err != nil {
+			slog.Error("error dispatching jobs", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the recipients that are due at the given time, within the
+// rate limit, and returns how many were dispatched; the state of each job
+// is saved before and after each message, so that dispatching can resume
+// after a crash.
+func (d *Dispatcher) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	jobs, err := d.Queue.List()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	var result error
+	for _, job := range jobs {
+		if job.Status != Pending && job.Status != Running {
+			continue
+		}
+		for ctx.Err() == nil {
+			due := d.due(job, now)
+			if len(due) == 0 {
+				break
+			}
+			count, err := d.send(job, due)
+			total += count
+			if err != nil {
+				result = errors.Join(result, err)
+				break
+			}
+		}
+	}
+	return total, result
+}
+
+// due returns the next batch of recipients of the job that are due at the
+// given time and outside quiet hours, within the rate limit.
+func (d *Dispatcher) due(job *Job, now time.Time) []*Recipient {
+	// capacity is the size of a full batch, limit what the rate allows now
+	capacity := d.BatchSize
+	if capacity <= 0 {
+		capacity = DefaultBatchSize
+	}
+	limit := capacity
+	if d.Rate > 0 {
+		d.dispatched = slices.DeleteFunc(d.dispatched, func(t time.Time) bool {
+			return now.Sub(t) >= time.Minute
+		})
+		capacity = min(capacity, d.Rate)
+		limit = min(capacity, d.Rate-len(d.dispatched))
+	}
+	if limit <= 0 || now.Before(job.Start) {
+		return nil
+	}
+	// batches whose dispatch was attempted are retried as they were, with
+	// the same client reference, once all their recipients are ready; a
+	// batch larger than the limits (e.g. lowered since) is sent whole when
+	// the rate limit allows a full batch, since the platform would discard
+	// any part of it sent again with the same reference as a duplicate
+	retried := map[string]bool{}
+	for _, recipient := range job.Recipients {
+		if recipient.Status != Pending || recipient.Ref == "" || retried[recipient.Ref] {
+			continue
+		}
+		retried[recipient.Ref] = true
+		due := []*Recipient{}
+		ready := true
+		for _, other := range job.Recipients {
+			if other.Status == Pending && other.Ref == recipient.Ref {
+				due = append(due, other)
+				ready = ready && job.ready(other, now)
+			}
+		}
+		if !ready {
+			continue
+		}
+		if len(due) > limit && limit < capacity {
+			return nil
+		}
+		return due
+	}
+	due := []*Recipient{}
+	for _, recipient := range job.Recipients {
+		if len(due) >= limit {
+			break
+		}
+		if recipient.Status != Pending || recipient.Ref != "" {
+			continue
+		}
+		if job.ready(recipient, now) {
+			due = append(due, recipient)
+		}
+	}
+	return due
+}
+
+// send dispatches a batch of recipients of the job.
+func (d *Dispatcher) send(job *Job, batch []*Recipient) (int, error) {
+	ref := batch[0].Ref
+	if ref == "" {
+		ref = rdcom.NewClientRef()
+	}
+	// the job is read back before the batch is marked in flight, so that a
+	// cancellation since the job was listed is neither overwritten nor sent
+	unlock, err := d.Queue.lockJob(job.ID)
+	if err != nil {
+		return 0, err
+	}
+	if d.cancelled(job) {
+		unlock()
+		slog.Info("job cancelled, recipients not dispatched", "job", job.ID, "count", len(batch))
+		return 0, nil
+	}
+	numbers := make([]string, 0, len(batch))
+	for _, recipient := range batch {
+		recipient.Status = InFlight
+		recipient.Ref = ref
+		numbers = append(numbers, recipient.Number)
+	}
+	err = d.Queue.Save(job)
+	unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Debug("dispatching recipients", "job", job.ID, "count", len(batch))
+	sent, err := d.Sender.Send(job.Account, &rdcom.Message{
+		ClientRef:  ref,
+		Gateway:    job.Gateway,
+		Sender:     job.Sender,
+		Recipients: numbers,
+		Text:       job.Text,
+	})
+	now := time.Now()
+	var result error
+	switch {
+	case err == nil || rdcom.IsConflict(err):
+		// a conflict means that an earlier attempt with the same reference
+		// was accepted, but its response was lost
+		if sent == nil {
+			sent = &rdcom.Message{}
+		}
+		for _, recipient := range batch {
+			if slices.Contains(sent.Suppressed, recipient.Number) {
+				recipient.Status = Suppressed
+				continue
+			}
+			recipient.Status = Sent
+			recipient.Error = ""
+			recipient.MessageID = sent.ID
+			recipient.Sent = now
+			d.dispatched = append(d.dispatched, now)
+		}
+	case errors.Is(err, rdcom.ErrAllSuppressed):
+		for _, recipient := range batch {
+			recipient.Status = Suppressed
+		}
+	case rdcom.IsPermanent(err):
+		slog.Error("recipients rejected", "job", job.ID, "error", err)
+		for _, recipient := range batch {
+			recipient.Status = Failed
+			recipient.Error = err.Error()
+		}
+	default:
+		// transient failure: try again at the next round, with the same
+		// reference as the message may have reached the platform anyway
+		slog.Warn("error dispatching recipients, will retry", "job", job.ID, "error", err)
+		for _, recipient := range batch {
+			recipient.Status = Pending
+			recipient.Error = err.Error()
+		}
+		result = err
+	}
+	job.settle()
+
+	// the job may have been cancelled while the batch was being sent
+	unlock, err = d.Queue.lockJob(job.ID)
+	if err != nil {
+		return len(batch), errors.Join(result, err)
+	}
+	defer unlock()
+	d.cancelled(job)
+	if err := d.Queue.Save(job); err != nil {
+		return len(batch), errors.Join(result, err)
+	}
+	slog.Info("recipients dispatched", "job", job.ID, "count", len(batch), "status", job.Status)
+	return len(batch), result
+}
+
+// cancelled reads the job back from the queue and, if it was cancelled in
+// the meantime, applies the cancellation to the pending recipients of the
+// job too; it must be called holding the job lock.
+func (d *Dispatcher) cancelled(job *Job) bool {
+	stored, err := d.Queue.Get(job.ID)
+	if err != nil {
+		slog.Warn("error reading job back from queue", "job", job.ID, "error", err)
+		return false
+	}
+	if stored.Status != Cancelled {
+		return false
+	}
+	job.Status = Cancelled
+	for _, recipient := range job.Recipients {
+		if recipient.Status == Pending {
+			recipient.Status = Cancelled
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// recorder is a Sender that records the messages sent.
+type recorder struct {
+	messages []*rdcom.Message
+}
+
+func (r *recorder) Send(account string, message *rdcom.Message) (*rdcom.Message, error) {
+	r.messages = append(r.messages, message)
+	return &rdcom.Message{ID: "1"}, nil
+}
+
+func TestDispatcherHonoursCancellation(t *testing.T) {
+	queue, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := NewJob("account", 1, "", "text", []string{"+393331234567", "+393331234568"}, time.Now().Add(-time.Minute), time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Add(job); err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &recorder{}
+	dispatcher := &Dispatcher{Queue: queue, Sender: sender, BatchSize: 1}
+
+	// the dispatcher holds a copy of the job read before the cancellation
+	listed, err := queue.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	count, err := dispatcher.send(listed, listed.Recipients[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 || len(sender.messages) != 0 {
+		t.Errorf("expected nothing sent, got %d recipients in %d messages", count, len(sender.messages))
+	}
+
+	stored, err := queue.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != Cancelled {
+		t.Errorf("expected job %s, got %s", Cancelled, stored.Status)
+	}
+	for _, recipient := range stored.Recipients {
+		if recipient.Status != Cancelled {
+			t.Errorf("expected recipient %s, got %s", Cancelled, recipient.Status)
+		}
+	}
+
+	if count, err := dispatcher.Dispatch(t.Context(), time.Now()); err != nil || count != 0 {
+		t.Errorf("expected nothing dispatched, got %d (error: %v)", count, err)
+	}
+}
+
+func TestDispatcherRetriesBatches(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := time.Date(2026, 6, 10, 20, 59, 0, 0, rome)
+	batch := func(numbers ...string) *Job {
+		job := &Job{Start: failed, QuietHours: &QuietHours{From: 21 * 60, To: 8 * 60}, Status: Running}
+		for _, number := range numbers {
+			job.Recipients = append(job.Recipients, &Recipient{Number: number, Due: failed, Status: Pending, Ref: "ref"})
+		}
+		return job
+	}
+
+	tests := []struct {
+		name       string
+		dispatcher *Dispatcher
+		job        *Job
+		now        time.Time
+		expected   int
+	}{
+		{"retry within quiet hours", &Dispatcher{}, batch("+393331234567"), failed.Add(time.Minute), 0},
+		{"retry after quiet hours", &Dispatcher{}, batch("+393331234567"), time.Date(2026, 6, 11, 8, 0, 0, 0, rome), 1},
+		{"retry within quiet hours of one recipient", &Dispatcher{}, batch("+393331234567", "+819012345678"), time.Date(2026, 6, 11, 14, 0, 0, 0, rome), 0},
+		{"retry larger than batch size", &Dispatcher{BatchSize: 2}, batch("+393331234567", "+393331234568", "+393331234569"), failed, 3},
+		{"retry larger than rate", &Dispatcher{Rate: 2}, batch("+393331234567", "+393331234568", "+393331234569"), failed, 3},
+		{"retry larger than rate left", &Dispatcher{Rate: 2, dispatched: []time.Time{failed.Add(-30 * time.Second)}}, batch("+393331234567", "+393331234568", "+393331234569"), failed, 0},
+		{"retry larger than rate after window", &Dispatcher{Rate: 2, dispatched: []time.Time{failed.Add(-time.Minute)}}, batch("+393331234567", "+393331234568", "+393331234569"), failed, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if due := test.dispatcher.due(test.job, test.now); len(due) != test.expected {
+				t.Errorf("expected %d recipients due, got %d", test.expected, len(due))
+			}
+		})
+	}
+}
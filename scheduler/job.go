@@ -0,0 +1,239 @@
+// Package scheduler implements a persistent, file-backed queue of deferred
+// SMS jobs and a dispatcher that sends them when due, spreading recipients
+// over a time window and honouring quiet hours in each destination's
+// local time.
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/phone"
+)
+
+// Status is the state of a job or of a single recipient.
+type Status string
+
+// List of job and recipient states.
+const (
+	// Pending jobs (and recipients) are waiting to be dispatched.
+	Pending Status = "pending"
+	// Running jobs have been partly dispatched.
+	Running Status = "running"
+	// InFlight recipients are being sent; a recipient found in this state
+	// after a crash may or may not have been messaged, and is sent again
+	// with the same client reference.
+	InFlight Status = "in-flight"
+	// Sent recipients have been accepted by the platform.
+	Sent Status = "sent"
+	// Done jobs have been dispatched to all recipients.
+	Done Status = "done"
+	// Suppressed recipients were skipped because they are in the
+	// suppression list.
+	Suppressed Status = "suppressed"
+	// Failed recipients could not be sent; failed jobs have no recipient
+	// left to dispatch and at least one failure.
+	Failed Status = "failed"
+	// Cancelled jobs (and recipients) will not be dispatched.
+	Cancelled Status = "cancelled"
+)
+
+// Job is a deferred message to a set of recipients.
+type Job struct {
+	ID      string `json:"id" yaml:"id"`
+	Account string `json:"account" yaml:"account"`
+	Gateway int    `json:"gateway" yaml:"gateway"`
+	Sender  string `json:"sender,omitempty" yaml:"sender,omitempty"`
+	Text    string `json:"text" yaml:"text" pii:"text"`
+	// Start is the time before which nothing is sent.
+	Start time.Time `json:"start" yaml:"start"`
+	// End is the (optional) end of the window recipients are spread over;
+	// if zero, all recipients are due at Start.
+	End time.Time `json:"end,omitzero" yaml:"end,omitempty"`
+	// QuietHours is the (optional) daily interval, in each recipient's
+	// local time, during which the recipient must not be messaged.
+	QuietHours *QuietHours `json:"quiet_hours,omitempty" yaml:"quiet_hours,omitempty"`
+	// Recipients holds the recipients and their dispatch state.
+	Recipients []*Recipient `json:"recipients" yaml:"recipients"`
+	Status     Status       `json:"status" yaml:"status"`
+	Created    time.Time    `json:"created" yaml:"created"`
+	Updated    time.Time    `json:"updated" yaml:"updated"`
+}
+
+// Recipient is a recipient of a job and its dispatch state.
+type Recipient struct {
+	Number string `json:"number" yaml:"number" pii:"phone"`
+	// Due is the time the recipient is due, before quiet hours are applied.
+	Due    time.Time `json:"due" yaml:"due"`
+	Status Status    `json:"status" yaml:"status"`
+	// Ref is the client reference of the message the recipient was last
+	// dispatched in; it is reused when the dispatch is retried, so that the
+	// platform discards duplicates.
+	Ref       string    `json:"ref,omitempty" yaml:"ref,omitempty"`
+	MessageID string    `json:"message_id,omitempty" yaml:"message_id,omitempty"`
+	Sent      time.Time `json:"sent,omitzero" yaml:"sent,omitempty"`
+	Error     string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NewJob creates a job, spreading the recipients evenly over the window
+// between start and end (if end is not zero).
+func NewJob(account string, gateway int, sender string, text string, recipients []string, start time.Time, end time.Time, quiet *QuietHours) (*Job, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients provided")
+	}
+	if text == "" {
+		return nil, errors.New("no message text provided")
+	}
+	if !end.IsZero() && !end.After(start) {
+		return nil, fmt.Errorf("end of window %s is not after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	now := time.Now()
+	job := &Job{
+		ID:         now.UTC().Format("20060102T150405") + "-" + randomSuffix(),
+		Account:    account,
+		Gateway:    gateway,
+		Sender:     sender,
+		Text:       text,
+		Start:      start,
+		End:        end,
+		QuietHours: quiet,
+		Status:     Pending,
+		Created:    now,
+		Updated:    now,
+	}
+	step := time.Duration(0)
+	if !end.IsZero() {
+		step = end.Sub(start) / time.Duration(len(recipients))
+	}
+	for i, number := range recipients {
+		job.Recipients = append(job.Recipients, &Recipient{
+			Number: number,
+			Due:    start.Add(step * time.Duration(i)),
+			Status: Pending,
+		})
+	}
+	return job, nil
+}
+
+// NextDue returns the earliest time a pending recipient can be sent, taking
+// quiet hours into account, and whether there is any pending recipient.
+func (j *Job) NextDue() (time.Time, bool) {
+	next := time.Time{}
+	for _, recipient := range j.Recipients {
+		if recipient.Status != Pending {
+			continue
+		}
+		due := j.QuietHours.Defer(recipient.Number, recipient.Due)
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// ready reports whether the recipient can be sent at the given time, i.e.
+// whether it is due and the time is outside quiet hours in its local time.
+func (j *Job) ready(recipient *Recipient, now time.Time) bool {
+	return !recipient.Due.After(now) && !j.QuietHours.Defer(recipient.Number, now).After(now)
+}
+
+// Count returns the number of recipients in the given state.
+func (j *Job) Count(status Status) int {
+	count := 0
+	for _, recipient := range j.Recipients {
+		if recipient.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// settle updates the job status from the state of its recipients.
+func (j *Job) settle() {
+	if j.Status == Cancelled {
+		return
+	}
+	if j.Count(Pending) > 0 || j.Count(InFlight) > 0 {
+		if j.Count(Sent) > 0 || j.Count(Failed) > 0 {
+			j.Status = Running
+		}
+		return
+	}
+	if j.Count(Failed) > 0 {
+		j.Status = Failed
+	} else {
+		j.Status = Done
+	}
+}
+
+// QuietHours is a daily interval, in local time, during which recipients
+// must not be messaged; the interval can span midnight (e.g. 21:00-08:00).
+type QuietHours struct {
+	// From and To are minutes since midnight.
+	From int `json:"from" yaml:"from"`
+	To   int `json:"to" yaml:"to"`
+}
+
+// ParseQuietHours parses a quiet hours specification in "HH:MM-HH:MM" format.
+func ParseQuietHours(value string) (*QuietHours, error) {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q: use HH:MM-HH:MM", value)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: use HH:MM-HH:MM", value)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: use HH:MM-HH:MM", value)
+	}
+	quiet := &QuietHours{
+		From: start.Hour()*60 + start.Minute(),
+		To:   end.Hour()*60 + end.Minute(),
+	}
+	if quiet.From == quiet.To {
+		return nil, fmt.Errorf("invalid quiet hours %q: empty interval", value)
+	}
+	return quiet, nil
+}
+
+// String returns the quiet hours in "HH:MM-HH:MM" format.
+func (q *QuietHours) String() string {
+	if q == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.From/60, q.From%60, q.To/60, q.To%60)
+}
+
+// Defer returns the first time, not before the given one, that is outside
+// quiet hours in the local time of the given number; numbers whose time
+// zone is unknown use the local time zone of the host.
+func (q *QuietHours) Defer(number string, t time.Time) time.Time {
+	if q == nil {
+		return t
+	}
+	location, ok := phone.LocationOf(number)
+	if !ok {
+		location = time.Local
+	}
+	local := t.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+	quiet := false
+	if q.From < q.To {
+		quiet = minutes >= q.From && minutes < q.To
+	} else {
+		quiet = minutes >= q.From || minutes < q.To
+	}
+	if !quiet {
+		return t
+	}
+	// quiet hours end today or, if they span midnight and it is evening, tomorrow
+	end := time.Date(local.Year(), local.Month(), local.Day(), q.To/60, q.To%60, 0, 0, location)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
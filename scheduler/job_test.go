@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestQuietHoursDefer(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		italian  = "+393331234567"
+		japanese = "+819012345678"
+	)
+	night := &QuietHours{From: 21 * 60, To: 8 * 60}
+	lunch := &QuietHours{From: 12 * 60, To: 14 * 60}
+	early := &QuietHours{From: 0, To: 2*60 + 30}
+
+	tests := []struct {
+		name     string
+		quiet    *QuietHours
+		number   string
+		t        time.Time
+		expected time.Time
+	}{
+		{"no quiet hours", nil, italian, time.Date(2026, 6, 10, 23, 0, 0, 0, rome), time.Date(2026, 6, 10, 23, 0, 0, 0, rome)},
+		{"outside quiet hours", night, italian, time.Date(2026, 6, 10, 12, 0, 0, 0, rome), time.Date(2026, 6, 10, 12, 0, 0, 0, rome)},
+		{"start of quiet hours", night, italian, time.Date(2026, 6, 10, 21, 0, 0, 0, rome), time.Date(2026, 6, 11, 8, 0, 0, 0, rome)},
+		{"end of quiet hours", night, italian, time.Date(2026, 6, 11, 8, 0, 0, 0, rome), time.Date(2026, 6, 11, 8, 0, 0, 0, rome)},
+		{"evening, across midnight", night, italian, time.Date(2026, 6, 10, 22, 30, 0, 0, rome), time.Date(2026, 6, 11, 8, 0, 0, 0, rome)},
+		{"after midnight", night, italian, time.Date(2026, 6, 11, 3, 0, 0, 0, rome), time.Date(2026, 6, 11, 8, 0, 0, 0, rome)},
+		{"across the end of the year", night, italian, time.Date(2026, 12, 31, 23, 0, 0, 0, rome), time.Date(2027, 1, 1, 8, 0, 0, 0, rome)},
+		{"within the day", lunch, italian, time.Date(2026, 6, 10, 13, 0, 0, 0, rome), time.Date(2026, 6, 10, 14, 0, 0, 0, rome)},
+		{"before daytime quiet hours", lunch, italian, time.Date(2026, 6, 10, 11, 59, 0, 0, rome), time.Date(2026, 6, 10, 11, 59, 0, 0, rome)},
+		{"recipient time zone", night, japanese, time.Date(2026, 6, 10, 15, 0, 0, 0, rome), time.Date(2026, 6, 11, 8, 0, 0, 0, tokyo)},
+		{"recipient time zone, outside quiet hours", night, italian, time.Date(2026, 6, 10, 22, 0, 0, 0, tokyo), time.Date(2026, 6, 10, 22, 0, 0, 0, tokyo)},
+		{"DST starts overnight", night, italian, time.Date(2026, 3, 28, 22, 0, 0, 0, rome), time.Date(2026, 3, 29, 8, 0, 0, 0, rome)},
+		{"DST ends overnight", night, italian, time.Date(2026, 10, 24, 23, 0, 0, 0, rome), time.Date(2026, 10, 25, 8, 0, 0, 0, rome)},
+		{"end of quiet hours skipped by DST", early, italian, time.Date(2026, 3, 29, 1, 0, 0, 0, rome), time.Date(2026, 3, 29, 3, 30, 0, 0, rome)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.quiet.Defer(test.number, test.t)
+			if !actual.Equal(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
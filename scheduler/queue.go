@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrJobNotFound is returned when a job does not exist in the queue.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrQueueLocked is returned when another dispatcher holds the queue.
+var ErrQueueLocked = errors.New("queue locked by another dispatcher")
+
+// staleJobLock is the age after which a job lock is considered left behind
+// by a crashed process; job locks are only held while a job file is read
+// and written.
+const staleJobLock = 10 * time.Second
+
+// Queue is a persistent job queue, stored as one JSON file per job in a
+// directory; each file is replaced atomically on every change, so that the
+// queue survives crashes.
+type Queue struct {
+	dir string
+}
+
+// Open opens the queue in the given directory, creating it if needed.
+func Open(dir string) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		slog.Error("error creating queue directory", "path", dir, "error", err)
+		return nil, err
+	}
+	return &Queue{dir: filepath.Clean(dir)}, nil
+}
+
+// Add stores a new job in the queue.
+func (q *Queue) Add(job *Job) error {
+	return q.Save(job)
+}
+
+// Save writes the job to the queue.
+func (q *Queue) Save(job *Job) error {
+	job.Updated = time.Now()
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := q.path(job.ID)
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		slog.Error("error writing job", "path", temp, "error", err)
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		slog.Error("error replacing job", "path", path, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Get reads the job with the given ID.
+func (q *Queue) Get(id string) (*Job, error) {
+	if strings.ContainsAny(id, `/\`) || id == "" {
+		return nil, fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	data, err := os.ReadFile(q.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if err != nil {
+		slog.Error("error reading job", "id", id, "error", err)
+		return nil, err
+	}
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		slog.Error("error parsing job", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// List returns the jobs in the queue, sorted by creation time; jobs that
+// cannot be read are logged and skipped.
+func (q *Queue) List() ([]*Job, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		slog.Error("error reading queue directory", "path", q.dir, "error", err)
+		return nil, err
+	}
+	jobs := []*Job{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		job, err := q.Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			slog.Warn("skipping unreadable job", "file", entry.Name(), "error", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	return jobs, nil
+}
+
+// Cancel cancels the job, so that its pending recipients are never sent.
+func (q *Queue) Cancel(id string) (*Job, error) {
+	unlock, err := q.lockJob(id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	job, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	switch job.Status {
+	case Done, Failed, Cancelled:
+		return job, fmt.Errorf("job %s is already %s", id, job.Status)
+	}
+	for _, recipient := range job.Recipients {
+		if recipient.Status == Pending {
+			recipient.Status = Cancelled
+		}
+	}
+	job.Status = Cancelled
+	return job, q.Save(job)
+}
+
+// Lock makes the caller the only dispatcher of the queue, until the returned
+// function is called; stale locks left by crashed dispatchers are taken over.
+func (q *Queue) Lock() (func(), error) {
+	path := filepath.Join(q.dir, ".lock")
+	for range 2 {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			slog.Error("error creating queue lock", "path", path, "error", err)
+			return nil, err
+		}
+		data, _ := os.ReadFile(path)
+		pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+		if pid > 0 && alive(pid) {
+			return nil, fmt.Errorf("%w (pid %d)", ErrQueueLocked, pid)
+		}
+		slog.Warn("removing stale queue lock", "path", path, "pid", pid)
+		os.Remove(path)
+	}
+	return nil, ErrQueueLocked
+}
+
+// lockJob serialises the changes to the job with the given ID across
+// processes (e.g. a dispatcher and a cancellation), until the returned
+// function is called; the job must be read again after locking it.
+func (q *Queue) lockJob(id string) (func(), error) {
+	if strings.ContainsAny(id, `/\`) || id == "" {
+		return nil, fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	path := q.path(id) + ".lock"
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			slog.Error("error creating job lock", "path", path, "error", err)
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleJobLock {
+			slog.Warn("removing stale job lock", "path", path)
+			os.Remove(path)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// path returns the path of the file of the job with the given ID.
+func (q *Queue) path(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
+
+// randomSuffix returns a short random hexadecimal string.
+func randomSuffix() string {
+	buffer := make([]byte, 3)
+	if _, err := rand.Read(buffer); err != nil {
+		slog.Error("error generating random identifier", "error", err)
+	}
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,791 @@
+package simulator
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dihedron/sms/phone"
+	"github.com/dihedron/sms/rdcom"
+)
+
+// routes registers the API endpoints.
+func (s *Simulator) routes() {
+	for pattern, handler := range map[string]http.HandlerFunc{
+		"GET /api/v2/tokens":                                   s.listTokens,
+		"GET /api/v2/tokens/{$}":                               s.listTokens,
+		"POST /api/v2/tokens/{$}":                              s.createToken,
+		"DELETE /api/v2/tokens/{$}":                            s.deleteToken,
+		"GET /api/v2/accounts":                                 s.listAccounts,
+		"GET /api/v2/accounts/{$}":                             s.listAccounts,
+		"GET /api/v2/{account}/cds/sms/{$}":                    s.listGateways,
+		"GET /api/v2/{account}/cds/sms/senders/{$}":            s.listSenders,
+		"POST /api/v2/{account}/cds/sms/senders/{$}":           s.requestSender,
+		"GET /api/v2/{account}/cds/sms/senders/{id}/{$}":       s.getSender,
+		"DELETE /api/v2/{account}/cds/sms/senders/{id}/{$}":    s.cancelSender,
+		"GET /api/v2/{account}/cds/sms/blacklist/{$}":          s.listBlacklist,
+		"POST /api/v2/{account}/cds/sms/blacklist/{$}":         s.addBlacklist,
+		"GET /api/v2/{account}/cds/sms/inbound/{$}":            s.listInbound,
+		"GET /api/v2/{account}/cds/sms/inbound/{id}/{$}":       s.getInbound,
+		"GET /api/v2/{account}/cds/sms/messages/{$}":           s.listMessages("sms"),
+		"POST /api/v2/{account}/cds/sms/messages/{$}":          s.sendSMS,
+		"GET /api/v2/{account}/cds/sms/messages/{id}/{$}":      s.getMessage("sms"),
+		"GET /api/v2/{account}/cds/sms/messages/{id}/dlr/{$}":  s.getReports,
+		"GET /api/v2/{account}/cds/rcs/messages/{$}":           s.listMessages("rcs"),
+		"POST /api/v2/{account}/cds/rcs/messages/{$}":          s.sendRCS,
+		"GET /api/v2/{account}/cds/rcs/messages/{id}/{$}":      s.getMessage("rcs"),
+		"POST /api/v2/{account}/cds/otp/sms/{$}":               s.sendOTP,
+		"GET /api/v2/{account}/cds/otp/sms/{id}/{$}":           s.getOTP,
+		"POST /api/v2/{account}/cds/otp/sms/{id}/validate/{$}": s.validateOTP,
+		"DELETE /api/v2/{account}/cds/otp/sms/{id}/{$}":        s.revokeOTP,
+	} {
+		s.mux.Handle(pattern, s.authenticated(handler))
+	}
+}
+
+// authenticated wraps a handler so that it is only invoked for requests
+// carrying valid credentials: basic credentials are accepted as long as
+// they are not empty; unknown tokens are registered on first use, unless
+// the simulator is strict, while expired and deleted tokens are refused.
+func (s *Simulator) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if username, password, ok := request.BasicAuth(); ok {
+			if username == "" || password == "" {
+				writeError(writer, http.StatusUnauthorized, "invalid credentials")
+				return
+			}
+			handler(writer, request)
+			return
+		}
+		value, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if !ok || value == "" {
+			writeError(writer, http.StatusUnauthorized, "authentication credentials were not provided")
+			return
+		}
+		s.lock.Lock()
+		token, known := s.tokens[value]
+		switch {
+		case known && !token.ExpiryDate.IsZero() && time.Now().After(token.ExpiryDate):
+			known = false
+		case !known && !s.config.Strict && !s.revoked[value]:
+			slog.Info("registering token on first use")
+			s.tokens[value] = &rdcom.Token{Token: value, ExpiryDate: time.Now().Add(s.config.TokenLifetime).UTC().Truncate(time.Second)}
+			known = true
+		}
+		s.lock.Unlock()
+		if !known {
+			writeError(writer, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+// acquire locks the state and brings it up to date; the caller must
+// release the lock.
+func (s *Simulator) acquire() {
+	s.lock.Lock()
+	s.settle(time.Now())
+}
+
+// account returns the account in the request path, registering it if
+// unknown and the simulator is not strict; if nil is returned, the error
+// response has been written. The lock must be held.
+func (s *Simulator) account(writer http.ResponseWriter, request *http.Request) *account {
+	code := request.PathValue("account")
+	if a, ok := s.accounts[code]; ok {
+		return a
+	}
+	if s.config.Strict {
+		writeError(writer, http.StatusNotFound, fmt.Sprintf("account %s not found", code))
+		return nil
+	}
+	slog.Info("registering account on first use", "account", code)
+	a := newAccount(code, s.config.Credit)
+	s.accounts[code] = a
+	return a
+}
+
+// paginate writes a list, paginated as requested by the client: the offset
+// is the index of the page, as the client counts it.
+func paginate[T any](writer http.ResponseWriter, request *http.Request, items []T) {
+	query := request.URL.Query()
+	if query.Get("paginated-view") != "true" {
+		writeJSON(writer, http.StatusOK, items)
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	pages := max(1, int(math.Ceil(float64(len(items))/float64(limit))))
+	first := min(offset*limit, len(items))
+	last := min(first+limit, len(items))
+	type payload struct {
+		TotPages               int     `json:"tot_pages"`
+		CurrentPageFirstRecord int     `json:"current_page_first_record"`
+		CurrentPageLastRecord  int     `json:"current_page_last_record"`
+		Limit                  int     `json:"limit"`
+		Offset                 int     `json:"offset"`
+		Count                  int     `json:"count"`
+		CountIsEstimate        bool    `json:"count_is_estimate"`
+		Next                   *string `json:"next"`
+		Previous               *string `json:"previous"`
+		Results                []T     `json:"results"`
+	}
+	page := &payload{
+		TotPages:               pages,
+		CurrentPageFirstRecord: first + 1,
+		CurrentPageLastRecord:  last,
+		Limit:                  limit,
+		Offset:                 offset,
+		Count:                  len(items),
+		Results:                items[first:last],
+	}
+	link := func(offset int) *string {
+		values := request.URL.Query()
+		values.Set("offset", strconv.Itoa(offset))
+		value := request.URL.Path + "?" + values.Encode()
+		return &value
+	}
+	if offset+1 < pages {
+		page.Next = link(offset + 1)
+	}
+	if offset > 0 {
+		page.Previous = link(offset - 1)
+	}
+	writeJSON(writer, http.StatusOK, page)
+}
+
+// e164 reports whether all the numbers are in E.164 format, writing a bad
+// request response if not.
+func e164(writer http.ResponseWriter, numbers ...string) bool {
+	for _, number := range numbers {
+		if normalised, err := phone.Normalise(number, ""); err != nil || normalised != number {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("%s is not a valid E.164 number", number))
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulator) listTokens(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	tokens := make([]rdcom.Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Token < tokens[j].Token })
+	paginate(writer, request, tokens)
+}
+
+func (s *Simulator) createToken(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	token := &rdcom.Token{
+		Token:      "sim-" + s.secret(32),
+		ExpiryDate: time.Now().Add(s.config.TokenLifetime).UTC().Truncate(time.Second),
+	}
+	s.tokens[token.Token] = token
+	slog.Info("token created", "expiry", token.ExpiryDate)
+	writeJSON(writer, http.StatusCreated, token)
+}
+
+func (s *Simulator) deleteToken(writer http.ResponseWriter, request *http.Request) {
+	body := &rdcom.Token{}
+	if !readJSON(writer, request, body) {
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	token, ok := s.tokens[body.Token]
+	if !ok {
+		writeError(writer, http.StatusNotFound, "token not found")
+		return
+	}
+	delete(s.tokens, body.Token)
+	s.revoked[body.Token] = true
+	slog.Info("token deleted")
+	writeJSON(writer, http.StatusOK, token)
+}
+
+func (s *Simulator) listAccounts(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	accounts := make([]rdcom.Account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, a.Account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Code < accounts[j].Code })
+	paginate(writer, request, accounts)
+}
+
+func (s *Simulator) listGateways(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if a := s.account(writer, request); a != nil {
+		writeJSON(writer, http.StatusOK, a.Gateways)
+	}
+}
+
+func (s *Simulator) listSenders(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if a := s.account(writer, request); a != nil {
+		writeJSON(writer, http.StatusOK, a.Senders)
+	}
+}
+
+// senderOf returns the sender registration in the request path; if nil is
+// returned, the error response has been written. The lock must be held.
+func (s *Simulator) senderOf(writer http.ResponseWriter, request *http.Request) *rdcom.Sender {
+	a := s.account(writer, request)
+	if a == nil {
+		return nil
+	}
+	id, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		writeError(writer, http.StatusNotFound, "sender not found")
+		return nil
+	}
+	sender := a.sender(id)
+	if sender == nil {
+		writeError(writer, http.StatusNotFound, "sender not found")
+	}
+	return sender
+}
+
+func (s *Simulator) getSender(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if sender := s.senderOf(writer, request); sender != nil {
+		writeJSON(writer, http.StatusOK, sender)
+	}
+}
+
+func (s *Simulator) requestSender(writer http.ResponseWriter, request *http.Request) {
+	body := &rdcom.Sender{}
+	if !readJSON(writer, request, body) {
+		return
+	}
+	if err := rdcom.ValidateSender(body.Sender); err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	if len(body.Gateways) == 0 {
+		body.Gateways = []int{a.defaultGateway().ID}
+	}
+	for _, id := range body.Gateways {
+		if a.gateway(id) == nil {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("unknown SMS gateway %d", id))
+			return
+		}
+	}
+	for _, sender := range a.Senders {
+		if sender.Sender == body.Sender && (sender.Status == rdcom.SenderPending || sender.Status == rdcom.SenderApproved) {
+			writeError(writer, http.StatusConflict, fmt.Sprintf("sender %s is already %s", sender.Sender, sender.Status))
+			return
+		}
+	}
+	now := time.Now().UTC()
+	sender := &rdcom.Sender{
+		ID:       len(a.Senders) + 1,
+		Sender:   body.Sender,
+		Status:   rdcom.SenderPending,
+		Gateways: body.Gateways,
+		Reason:   body.Reason,
+		Created:  now,
+		Updated:  now,
+	}
+	a.Senders = append(a.Senders, sender)
+	slog.Info("sender registration requested", "account", a.Account.Code, "sender", sender.Sender)
+	writeJSON(writer, http.StatusCreated, sender)
+}
+
+func (s *Simulator) cancelSender(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	sender := s.senderOf(writer, request)
+	if sender == nil {
+		return
+	}
+	if sender.Status != rdcom.SenderPending {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("sender %s is %s", sender.Sender, sender.Status))
+		return
+	}
+	sender.Status = rdcom.SenderCancelled
+	sender.Updated = time.Now().UTC()
+	slog.Info("sender registration cancelled", "sender", sender.Sender)
+	writeJSON(writer, http.StatusOK, sender)
+}
+
+func (s *Simulator) listBlacklist(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if a := s.account(writer, request); a != nil {
+		paginate(writer, request, a.Blacklist)
+	}
+}
+
+func (s *Simulator) addBlacklist(writer http.ResponseWriter, request *http.Request) {
+	body := &rdcom.BlacklistEntry{}
+	if !readJSON(writer, request, body) || !e164(writer, body.Number) {
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	for _, entry := range a.Blacklist {
+		if entry.Number == body.Number {
+			writeJSON(writer, http.StatusOK, entry)
+			return
+		}
+	}
+	entry := rdcom.BlacklistEntry{Number: body.Number, Reason: body.Reason, Created: time.Now().UTC()}
+	a.Blacklist = append(a.Blacklist, entry)
+	slog.Info("number blacklisted", "account", a.Account.Code)
+	writeJSON(writer, http.StatusCreated, entry)
+}
+
+func (s *Simulator) listInbound(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	filter := &rdcom.InboundFilter{
+		Sender:  query.Get("from"),
+		Keyword: query.Get("search"),
+	}
+	for name, value := range map[string]*time.Time{"received_after": &filter.From, "received_before": &filter.To} {
+		if query.Has(name) {
+			parsed, err := time.Parse(time.RFC3339, query.Get(name))
+			if err != nil {
+				writeError(writer, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", name, err))
+				return
+			}
+			*value = parsed
+		}
+	}
+	if query.Has("gateway") {
+		gateway, err := strconv.Atoi(query.Get("gateway"))
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("invalid gateway: %v", err))
+			return
+		}
+		filter.Gateway = &gateway
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	messages := []rdcom.InboundMessage{}
+	for _, message := range a.Inbound {
+		if filter.Matches(&message) {
+			messages = append(messages, message)
+		}
+	}
+	paginate(writer, request, messages)
+}
+
+func (s *Simulator) getInbound(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	for _, message := range a.Inbound {
+		if message.ID == request.PathValue("id") {
+			writeJSON(writer, http.StatusOK, message)
+			return
+		}
+	}
+	writeError(writer, http.StatusNotFound, "message not found")
+}
+
+// submission is a message about to be accepted.
+type submission struct {
+	kind       string
+	gateway    *rdcom.SMSGateway
+	sender     string
+	recipients []string
+	text       string
+	ref        string
+}
+
+// submit bills and records a message, drawing the delivery outcome for
+// each recipient; if nil is returned, the error response has been written.
+// The lock must be held.
+func (s *Simulator) submit(writer http.ResponseWriter, a *account, message *submission) *Sent {
+	if message.ref != "" {
+		if id, ok := a.refs[message.ref]; ok {
+			slog.Warn("duplicate client reference", "ref", message.ref, "id", id)
+			writeError(writer, http.StatusConflict, fmt.Sprintf("client reference %s already accepted as %s", message.ref, id))
+			return nil
+		}
+	}
+	if message.sender != "" && rdcom.ValidateSender(message.sender) == nil && !a.approved(message.sender, message.gateway.ID) {
+		writeError(writer, http.StatusBadRequest, fmt.Sprintf("sender %s is not approved for SMS gateway %d", message.sender, message.gateway.ID))
+		return nil
+	}
+	quotation := rdcom.Quote(message.gateway, message.recipients, message.text)
+	if !a.Account.EnableSmsUnlimitedCredit && quotation.Total > a.Account.SmsCredits {
+		writeError(writer, http.StatusPaymentRequired, fmt.Sprintf("insufficient credit: %.4f needed, %.4f available", quotation.Total, a.Account.SmsCredits))
+		return nil
+	}
+	if !a.Account.EnableSmsUnlimitedCredit {
+		a.Account.SmsCredits -= quotation.Total
+	}
+
+	prefix := "msg"
+	if message.kind == "rcs" {
+		prefix = "rcs"
+	}
+	now := time.Now().UTC()
+	sent := &Sent{
+		ID:         s.next(prefix),
+		Kind:       message.kind,
+		Account:    a.Account.Code,
+		ClientRef:  message.ref,
+		Gateway:    message.gateway.ID,
+		Sender:     message.sender,
+		Recipients: message.recipients,
+		Text:       message.text,
+		Status:     Queued,
+		Segments:   quotation.Segments,
+		Cost:       quotation.Total,
+		Created:    now,
+	}
+	for _, recipient := range message.recipients {
+		report := &Report{Recipient: recipient, Status: Queued, Updated: now, due: now.Add(s.config.DeliveryDelay)}
+		switch {
+		case a.blacklisted(recipient):
+			report.outcome, report.Reason, report.due = Rejected, "blacklisted", now
+		case slices.Contains(quotation.Unpriced, recipient):
+			report.outcome, report.Reason, report.due = Rejected, "no route to destination", now
+		default:
+			report.outcome = s.outcome()
+		}
+		sent.Reports = append(sent.Reports, report)
+	}
+	if message.ref != "" {
+		a.refs[message.ref] = sent.ID
+	}
+	s.sent = append(s.sent, sent)
+	s.settle(now)
+	slog.Info("message accepted", "id", sent.ID, "account", a.Account.Code, "gateway", sent.Gateway, "recipients", len(sent.Recipients), "cost", sent.Cost)
+	return sent
+}
+
+func (s *Simulator) sendSMS(writer http.ResponseWriter, request *http.Request) {
+	message := &rdcom.Message{}
+	if !readJSON(writer, request, message) {
+		return
+	}
+	if len(message.Recipients) == 0 || message.Text == "" {
+		writeError(writer, http.StatusBadRequest, "recipients and text are required")
+		return
+	}
+	if !e164(writer, message.Recipients...) {
+		return
+	}
+	ref := request.Header.Get(rdcom.IdempotencyKeyHeader)
+	if ref == "" {
+		ref = message.ClientRef
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	gateway := a.gateway(message.Gateway)
+	if gateway == nil {
+		writeError(writer, http.StatusBadRequest, fmt.Sprintf("unknown SMS gateway %d", message.Gateway))
+		return
+	}
+	sent := s.submit(writer, a, &submission{
+		kind:       "sms",
+		gateway:    gateway,
+		sender:     message.Sender,
+		recipients: message.Recipients,
+		text:       message.Text,
+		ref:        ref,
+	})
+	if sent != nil {
+		sent.InReplyTo = message.InReplyTo
+		writeJSON(writer, http.StatusCreated, sent)
+	}
+}
+
+func (s *Simulator) sendRCS(writer http.ResponseWriter, request *http.Request) {
+	message := &rdcom.RCSMessage{}
+	if !readJSON(writer, request, message) {
+		return
+	}
+	if len(message.Recipients) == 0 {
+		writeError(writer, http.StatusBadRequest, "recipients are required")
+		return
+	}
+	if err := message.Validate(); err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !e164(writer, message.Recipients...) {
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	gateway := a.gateway(message.Gateway)
+	if gateway == nil || !gateway.RcsReady {
+		writeError(writer, http.StatusBadRequest, fmt.Sprintf("SMS gateway %d is not RCS ready", message.Gateway))
+		return
+	}
+	// RCS messages are billed as their SMS fallback
+	sent := s.submit(writer, a, &submission{
+		kind:       "rcs",
+		gateway:    gateway,
+		recipients: message.Recipients,
+		text:       message.FallbackText(),
+	})
+	if sent != nil {
+		sent.RCS = message
+		writeJSON(writer, http.StatusCreated, sent)
+	}
+}
+
+// listMessages returns a handler listing the messages of the given kind.
+func (s *Simulator) listMessages(kind string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		s.acquire()
+		defer s.lock.Unlock()
+		a := s.account(writer, request)
+		if a == nil {
+			return
+		}
+		messages := []*Sent{}
+		for _, sent := range s.sent {
+			if sent.Account == a.Account.Code && (sent.Kind == kind || kind == "sms" && sent.Kind == "otp") {
+				messages = append(messages, sent)
+			}
+		}
+		paginate(writer, request, messages)
+	}
+}
+
+// message returns the message in the request path; if nil is returned,
+// the error response has been written. The lock must be held.
+func (s *Simulator) message(writer http.ResponseWriter, request *http.Request) *Sent {
+	a := s.account(writer, request)
+	if a == nil {
+		return nil
+	}
+	for _, sent := range s.sent {
+		if sent.Account == a.Account.Code && sent.ID == request.PathValue("id") {
+			return sent
+		}
+	}
+	writeError(writer, http.StatusNotFound, "message not found")
+	return nil
+}
+
+// getMessage returns a handler returning a message of the given kind,
+// with its delivery reports.
+func (s *Simulator) getMessage(kind string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		s.acquire()
+		defer s.lock.Unlock()
+		sent := s.message(writer, request)
+		if sent == nil {
+			return
+		}
+		if sent.Kind != kind && !(kind == "sms" && sent.Kind == "otp") {
+			writeError(writer, http.StatusNotFound, "message not found")
+			return
+		}
+		writeJSON(writer, http.StatusOK, sent)
+	}
+}
+
+func (s *Simulator) getReports(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if sent := s.message(writer, request); sent != nil {
+		writeJSON(writer, http.StatusOK, sent.Reports)
+	}
+}
+
+// otpRequest is the request to send an OTP.
+type otpRequest struct {
+	Recipient string `json:"recipient"`
+	// Gateway is the (optional) SMS gateway; the default one is used if 0.
+	Gateway int `json:"gateway,omitempty"`
+	// Length is the (optional) number of digits, 6 by default.
+	Length int `json:"length,omitempty"`
+	// Validity is the (optional) validity in seconds, 300 by default.
+	Validity int `json:"validity,omitempty"`
+	// Text is the (optional) text, where "{code}" is replaced with the code.
+	Text string `json:"text,omitempty"`
+}
+
+func (s *Simulator) sendOTP(writer http.ResponseWriter, request *http.Request) {
+	body := &otpRequest{}
+	if !readJSON(writer, request, body) || !e164(writer, body.Recipient) {
+		return
+	}
+	if body.Length == 0 {
+		body.Length = 6
+	}
+	if body.Length < 4 || body.Length > 10 {
+		writeError(writer, http.StatusBadRequest, "length must be between 4 and 10 digits")
+		return
+	}
+	if body.Validity <= 0 {
+		body.Validity = 300
+	}
+	if body.Text == "" {
+		body.Text = "Your verification code is {code}"
+	} else if !strings.Contains(body.Text, "{code}") {
+		writeError(writer, http.StatusBadRequest, "text must contain the {code} placeholder")
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	gateway := a.defaultGateway()
+	if body.Gateway != 0 {
+		if gateway = a.gateway(body.Gateway); gateway == nil {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("unknown SMS gateway %d", body.Gateway))
+			return
+		}
+	}
+	digits := make([]byte, body.Length)
+	for i := range digits {
+		digits[i] = byte('0' + s.random.Intn(10))
+	}
+	code := string(digits)
+	sent := s.submit(writer, a, &submission{
+		kind:       "otp",
+		gateway:    gateway,
+		recipients: []string{body.Recipient},
+		text:       strings.ReplaceAll(body.Text, "{code}", code),
+	})
+	if sent == nil {
+		return
+	}
+	otp := &OTP{
+		ID:        s.next("otp"),
+		Account:   a.Account.Code,
+		Recipient: body.Recipient,
+		Gateway:   gateway.ID,
+		Code:      code,
+		Status:    OTPPending,
+		Message:   sent.ID,
+		Created:   sent.Created,
+		Expires:   sent.Created.Add(time.Duration(body.Validity) * time.Second),
+	}
+	s.otps[otp.ID] = otp
+	writeJSON(writer, http.StatusCreated, otp.public())
+}
+
+// public returns a copy of the OTP without the code.
+func (o *OTP) public() *OTP {
+	public := *o
+	public.Code = ""
+	return &public
+}
+
+// otpOf returns the OTP in the request path; if nil is returned, the
+// error response has been written. The lock must be held.
+func (s *Simulator) otpOf(writer http.ResponseWriter, request *http.Request) *OTP {
+	a := s.account(writer, request)
+	if a == nil {
+		return nil
+	}
+	otp, ok := s.otps[request.PathValue("id")]
+	if !ok || otp.Account != a.Account.Code {
+		writeError(writer, http.StatusNotFound, "OTP not found")
+		return nil
+	}
+	return otp
+}
+
+func (s *Simulator) getOTP(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	if otp := s.otpOf(writer, request); otp != nil {
+		writeJSON(writer, http.StatusOK, otp.public())
+	}
+}
+
+func (s *Simulator) validateOTP(writer http.ResponseWriter, request *http.Request) {
+	body := &struct {
+		Code string `json:"code"`
+	}{}
+	if !readJSON(writer, request, body) {
+		return
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	otp := s.otpOf(writer, request)
+	if otp == nil {
+		return
+	}
+	switch {
+	case otp.Status == OTPExpired:
+		writeError(writer, http.StatusGone, "OTP expired")
+	case otp.Status != OTPPending:
+		writeError(writer, http.StatusConflict, fmt.Sprintf("OTP is %s", otp.Status))
+	case body.Code != otp.Code:
+		otp.Attempts++
+		if otp.Attempts >= MaxOTPAttempts {
+			otp.Status = OTPBlocked
+		}
+		writeError(writer, http.StatusBadRequest, "invalid code")
+	default:
+		otp.Attempts++
+		otp.Status = OTPValidated
+		writeJSON(writer, http.StatusOK, otp.public())
+	}
+}
+
+func (s *Simulator) revokeOTP(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	otp := s.otpOf(writer, request)
+	if otp == nil {
+		return
+	}
+	if otp.Status != OTPPending {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("OTP is %s", otp.Status))
+		return
+	}
+	otp.Status = OTPRevoked
+	writeJSON(writer, http.StatusOK, otp.public())
+}
@@ -0,0 +1,197 @@
+package simulator
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// InspectionPath is the path under which the simulator serves its own
+// endpoints, outside of the API: they need no authentication and are
+// never delayed nor failed.
+const InspectionPath = "/_simulator/"
+
+// inspection registers the inspection endpoints:
+//   - GET / shows the messages sent, as a web page;
+//   - GET sent lists the messages sent, as JSON, optionally filtered by
+//     account, kind and recipient;
+//   - GET state dumps the whole state, OTP codes included, as JSON;
+//   - POST inbound injects an inbound message;
+//   - POST reset discards the state.
+func (s *Simulator) inspection() {
+	s.mux.HandleFunc("GET "+InspectionPath+"{$}", s.page)
+	s.mux.HandleFunc("GET "+InspectionPath+"sent", s.listSent)
+	s.mux.HandleFunc("GET "+InspectionPath+"state", s.dumpState)
+	s.mux.HandleFunc("POST "+InspectionPath+"inbound", s.injectInbound)
+	s.mux.HandleFunc("POST "+InspectionPath+"reset", s.resetState)
+}
+
+// selectSent returns the messages sent matching the query, newest first.
+// The lock must be held.
+func (s *Simulator) selectSent(request *http.Request) []*Sent {
+	query := request.URL.Query()
+	result := []*Sent{}
+	for _, sent := range slices.Backward(s.sent) {
+		if query.Has("account") && sent.Account != query.Get("account") {
+			continue
+		}
+		if query.Has("kind") && sent.Kind != query.Get("kind") {
+			continue
+		}
+		if query.Has("recipient") && !slices.Contains(sent.Recipients, query.Get("recipient")) {
+			continue
+		}
+		result = append(result, sent)
+	}
+	return result
+}
+
+func (s *Simulator) listSent(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	writeJSON(writer, http.StatusOK, s.selectSent(request))
+}
+
+func (s *Simulator) dumpState(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	defer s.lock.Unlock()
+	tokens := make([]*rdcom.Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Token < tokens[j].Token })
+	otps := make([]*OTP, 0, len(s.otps))
+	for _, otp := range s.otps {
+		otps = append(otps, otp)
+	}
+	sort.Slice(otps, func(i, j int) bool { return otps[i].ID < otps[j].ID })
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"tokens":   tokens,
+		"accounts": s.accounts,
+		"sent":     s.sent,
+		"otps":     otps,
+	})
+}
+
+// inboundRequest is the request to inject an inbound message.
+type inboundRequest struct {
+	Account string `json:"account"`
+	// Gateway is the (optional) gateway; the first MO-ready one is used if 0.
+	Gateway int    `json:"gateway,omitempty"`
+	From    string `json:"from"`
+	// To is the (optional) number the message is addressed to.
+	To   string `json:"to,omitempty"`
+	Text string `json:"text"`
+}
+
+// DefaultInboundNumber is the number inbound messages are addressed to if
+// none is given.
+const DefaultInboundNumber = "+393400000000"
+
+func (s *Simulator) injectInbound(writer http.ResponseWriter, request *http.Request) {
+	body := &inboundRequest{}
+	if !readJSON(writer, request, body) || !e164(writer, body.From) {
+		return
+	}
+	if body.Account == "" || body.Text == "" {
+		writeError(writer, http.StatusBadRequest, "account and text are required")
+		return
+	}
+	if body.To == "" {
+		body.To = DefaultInboundNumber
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	request.SetPathValue("account", body.Account)
+	a := s.account(writer, request)
+	if a == nil {
+		return
+	}
+	if body.Gateway == 0 {
+		for _, gateway := range a.Gateways {
+			if gateway.MoReady {
+				body.Gateway = gateway.ID
+				break
+			}
+		}
+	}
+	if gateway := a.gateway(body.Gateway); gateway == nil || !gateway.MoReady {
+		writeError(writer, http.StatusBadRequest, "no MO-ready SMS gateway")
+		return
+	}
+	message := rdcom.InboundMessage{
+		ID:       s.next("in"),
+		Gateway:  body.Gateway,
+		From:     body.From,
+		To:       body.To,
+		Text:     body.Text,
+		Received: time.Now().UTC(),
+	}
+	a.Inbound = append(a.Inbound, message)
+	slog.Info("inbound message injected", "id", message.ID, "account", a.Account.Code)
+	writeJSON(writer, http.StatusCreated, message)
+}
+
+func (s *Simulator) resetState(writer http.ResponseWriter, request *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+	slog.Info("simulator state reset")
+	writeJSON(writer, http.StatusOK, map[string]string{})
+}
+
+func (s *Simulator) page(writer http.ResponseWriter, request *http.Request) {
+	s.acquire()
+	sent := s.selectSent(request)
+	data := map[string]any{
+		"Sent":     sent,
+		"Accounts": len(s.accounts),
+		"Tokens":   len(s.tokens),
+		"OTPs":     len(s.otps),
+		"Now":      time.Now().Format(time.RFC3339),
+	}
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := pageTemplate.Execute(writer, data)
+	s.lock.Unlock()
+	if err != nil {
+		slog.Error("error rendering inspection page", "error", err)
+	}
+}
+
+// pageTemplate is the template of the inspection web page.
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>RDCom simulator</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; }
+.delivered { color: #080; } .failed, .rejected { color: #c00; } .expired, .partially_delivered { color: #a60; } .queued { color: #666; }
+</style>
+</head>
+<body>
+<h1>RDCom simulator</h1>
+<p>{{ len .Sent }} message(s) sent, {{ .Accounts }} account(s), {{ .Tokens }} token(s), {{ .OTPs }} OTP(s) at {{ .Now }};
+as JSON: <a href="sent">messages</a>, <a href="state">state</a>.</p>
+<table>
+<tr><th>ID</th><th>Submitted</th><th>Account</th><th>Kind</th><th>Gateway</th><th>Sender</th><th>Text</th><th>Segments</th><th>Cost</th><th>Status</th><th>Delivery reports</th></tr>
+{{ range .Sent }}<tr>
+<td>{{ .ID }}</td><td>{{ time .Created }}</td><td>{{ .Account }}</td><td>{{ .Kind }}</td><td>{{ .Gateway }}</td><td>{{ .Sender }}</td>
+<td>{{ .Text }}</td><td>{{ .Segments }}</td><td>{{ printf "%.4f" .Cost }}</td><td class="{{ .Status }}">{{ .Status }}</td>
+<td>{{ range .Reports }}{{ .Recipient }}: <span class="{{ .Status }}">{{ .Status }}</span>{{ if .Reason }} ({{ .Reason }}){{ end }}<br>{{ end }}</td>
+</tr>{{ end }}
+</table>
+</body>
+</html>
+`))
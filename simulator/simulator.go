@@ -0,0 +1,296 @@
+// Package simulator implements a stateful, local HTTP server that mimics
+// the RDCom v2 API endpoints used by the client (tokens, accounts, SMS
+// gateways, senders, blacklist, inbound and outbound messages with their
+// delivery reports, OTPs), so that the application can be exercised
+// without a platform account and without sending real messages.
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// Outcome is the final delivery state of a message to a recipient.
+type Outcome string
+
+// List of delivery outcomes.
+const (
+	// Delivered messages reached the handset.
+	Delivered Outcome = "delivered"
+	// Failed messages were refused by the network or the handset.
+	Failed Outcome = "failed"
+	// Expired messages could not be delivered within their validity.
+	Expired Outcome = "expired"
+	// Rejected messages were never sent, e.g. because the recipient is in
+	// the platform blacklist or has no route.
+	Rejected Outcome = "rejected"
+)
+
+// Config holds the behaviour of the simulator.
+type Config struct {
+	// Latency is the delay added to every API response.
+	Latency time.Duration
+	// Jitter is the maximum random delay added on top of Latency.
+	Jitter time.Duration
+	// ErrorRate is the probability (0 to 1) that an API call fails before
+	// being processed.
+	ErrorRate float64
+	// LostRate is the probability (0 to 1) that an API call is processed
+	// but its response is lost, i.e. replaced with a gateway timeout; it
+	// exercises the client handling of duplicate submissions.
+	LostRate float64
+	// ErrorStatuses are the HTTP statuses injected errors are picked from;
+	// if empty, 503 is used.
+	ErrorStatuses []int
+	// Outcomes holds the relative weights of the delivery outcomes; if
+	// empty, all messages are delivered.
+	Outcomes map[Outcome]float64
+	// DeliveryDelay is how long after submission delivery reports arrive.
+	DeliveryDelay time.Duration
+	// SenderReview is how long after submission sender registrations are
+	// approved; zero means they stay pending.
+	SenderReview time.Duration
+	// Tokens are the tokens accepted from the start.
+	Tokens []string
+	// TokenLifetime is the validity of the tokens created by the simulator.
+	TokenLifetime time.Duration
+	// Accounts are the codes of the accounts available from the start.
+	Accounts []string
+	// Credit is the initial SMS credit of each account; a negative value
+	// means unlimited credit.
+	Credit float64
+	// Strict sets whether unknown tokens and accounts are refused; by
+	// default they are registered on first use, so that any profile works.
+	Strict bool
+	// Seed is the seed of the random generator; zero means a random seed.
+	Seed int64
+}
+
+// Simulator is an HTTP handler simulating the RDCom API; its state lives
+// in memory and is lost on exit. It is safe for concurrent use.
+type Simulator struct {
+	config Config
+	mux    *http.ServeMux
+
+	lock     sync.Mutex
+	random   *rand.Rand
+	sequence int
+	tokens   map[string]*rdcom.Token
+	revoked  map[string]bool
+	accounts map[string]*account
+	sent     []*Sent
+	otps     map[string]*OTP
+}
+
+// New creates a simulator with the given configuration, seeding the tokens
+// and accounts it lists.
+func New(config Config) *Simulator {
+	if len(config.ErrorStatuses) == 0 {
+		config.ErrorStatuses = []int{http.StatusServiceUnavailable}
+	}
+	if config.TokenLifetime <= 0 {
+		config.TokenLifetime = 90 * 24 * time.Hour
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Simulator{
+		config: config,
+		random: rand.New(rand.NewSource(seed)),
+	}
+	s.reset()
+
+	s.mux = http.NewServeMux()
+	s.routes()
+	s.inspection()
+	return s
+}
+
+// reset discards all the state and seeds the configured tokens and
+// accounts again.
+func (s *Simulator) reset() {
+	s.sequence = 0
+	s.tokens = map[string]*rdcom.Token{}
+	s.revoked = map[string]bool{}
+	s.accounts = map[string]*account{}
+	s.sent = nil
+	s.otps = map[string]*OTP{}
+	for _, value := range s.config.Tokens {
+		s.tokens[value] = &rdcom.Token{Token: value, ExpiryDate: time.Now().Add(s.config.TokenLifetime).UTC().Truncate(time.Second)}
+	}
+	for _, code := range s.config.Accounts {
+		s.accounts[code] = newAccount(code, s.config.Credit)
+	}
+	slog.Debug("simulator state reset", "tokens", len(s.tokens), "accounts", len(s.accounts))
+}
+
+// ServeHTTP serves API calls, with the configured latency and errors, and
+// the inspection pages.
+func (s *Simulator) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	start := time.Now()
+	if !strings.HasPrefix(request.URL.Path, InspectionPath) {
+		if !s.delay(request.Context()) {
+			return
+		}
+		if s.chance(s.config.ErrorRate) {
+			status := s.errorStatus()
+			slog.Info("injecting error", "method", request.Method, "path", request.URL.Path, "status", status)
+			if status == http.StatusTooManyRequests {
+				writer.Header().Set("Retry-After", "1")
+			}
+			writeError(writer, status, "simulated error")
+			return
+		}
+		if s.chance(s.config.LostRate) {
+			slog.Info("losing response", "method", request.Method, "path", request.URL.Path)
+			s.mux.ServeHTTP(&discarder{header: http.Header{}}, request)
+			writeError(writer, http.StatusGatewayTimeout, "simulated lost response")
+			return
+		}
+	}
+	recorder := &recorder{ResponseWriter: writer, status: http.StatusOK}
+	s.mux.ServeHTTP(recorder, request)
+	slog.Debug("request served", "method", request.Method, "path", request.URL.Path, "status", recorder.status, "duration", time.Since(start))
+}
+
+// delay waits for the configured latency, plus jitter; it returns false if
+// the client went away in the meantime.
+func (s *Simulator) delay(ctx context.Context) bool {
+	latency := s.config.Latency
+	if s.config.Jitter > 0 {
+		s.lock.Lock()
+		latency += time.Duration(s.random.Int63n(int64(s.config.Jitter)))
+		s.lock.Unlock()
+	}
+	if latency <= 0 {
+		return true
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// chance returns true with the given probability.
+func (s *Simulator) chance(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.random.Float64() < probability
+}
+
+// errorStatus picks the status of an injected error.
+func (s *Simulator) errorStatus() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.config.ErrorStatuses[s.random.Intn(len(s.config.ErrorStatuses))]
+}
+
+// outcome picks a delivery outcome according to the configured weights;
+// the lock must be held.
+func (s *Simulator) outcome() Outcome {
+	total := 0.0
+	outcomes := make([]Outcome, 0, len(s.config.Outcomes))
+	for outcome, weight := range s.config.Outcomes {
+		if weight > 0 {
+			total += weight
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	if total == 0 {
+		return Delivered
+	}
+	// map iteration order is random: keep the draw reproducible
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i] < outcomes[j] })
+	draw := s.random.Float64() * total
+	for _, outcome := range outcomes {
+		if draw < s.config.Outcomes[outcome] {
+			return outcome
+		}
+		draw -= s.config.Outcomes[outcome]
+	}
+	return outcomes[len(outcomes)-1]
+}
+
+// next returns a new identifier with the given prefix; the lock must be
+// held.
+func (s *Simulator) next(prefix string) string {
+	s.sequence++
+	return fmt.Sprintf("%s-%06d", prefix, s.sequence)
+}
+
+// secret returns a random hexadecimal string of the given length; the lock
+// must be held.
+func (s *Simulator) secret(length int) string {
+	const digits = "0123456789abcdef"
+	buffer := make([]byte, length)
+	for i := range buffer {
+		buffer[i] = digits[s.random.Intn(len(digits))]
+	}
+	return string(buffer)
+}
+
+// writeJSON writes a value as a JSON response with the given status.
+func writeJSON(writer http.ResponseWriter, status int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		slog.Error("error writing response", "error", err)
+	}
+}
+
+// writeError writes an error response in the format of the platform.
+func writeError(writer http.ResponseWriter, status int, detail string) {
+	writeJSON(writer, status, map[string]string{"detail": detail})
+}
+
+// readJSON decodes the request body into the given value, writing a bad
+// request response if it is not valid.
+func readJSON(writer http.ResponseWriter, request *http.Request, value any) bool {
+	if err := json.NewDecoder(request.Body).Decode(value); err != nil {
+		slog.Warn("invalid request body", "path", request.URL.Path, "error", err)
+		writeError(writer, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// recorder captures the status of a response, for logging.
+type recorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and writes it.
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// discarder is a response writer that throws the response away.
+type discarder struct {
+	header http.Header
+}
+
+func (d *discarder) Header() http.Header            { return d.header }
+func (d *discarder) Write(data []byte) (int, error) { return len(data), nil }
+func (d *discarder) WriteHeader(int)                {}
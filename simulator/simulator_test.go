@@ -0,0 +1,314 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// start starts a simulator with the given configuration (seeded, so that
+// runs are reproducible) and returns it with a client talking to it.
+func start(t *testing.T, config Config, options ...rdcom.Option) (*Simulator, *rdcom.Client) {
+	t.Helper()
+	config.Seed = 1
+	s := New(config)
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	options = append([]rdcom.Option{rdcom.WithBaseURL(server.URL), rdcom.WithAuthToken("token"), rdcom.WithoutSuppression()}, options...)
+	client, err := rdcom.New(options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return s, client
+}
+
+// status returns the HTTP status of an API error, or 0.
+func status(err error) int {
+	var e *rdcom.HTTPError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// message returns an SMS message of a single segment to an Italian number.
+func message(ref string) *rdcom.Message {
+	return &rdcom.Message{ClientRef: ref, Gateway: 1, Recipients: []string{"+393331234567"}, Text: "hello"}
+}
+
+func TestSend(t *testing.T) {
+	s, client := start(t, Config{Credit: 10, DeliveryDelay: time.Hour})
+	sent, err := client.MessageService.Send("acme", message("ref-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.ID == "" || sent.Status != Queued || sent.Segments != 1 {
+		t.Errorf("expected a queued message of 1 segment, got %+v", sent)
+	}
+
+	// the message is delivered as soon as the reports are due
+	s.acquire()
+	defer s.lock.Unlock()
+	if len(s.sent) != 1 || s.sent[0].Reports[0].Status != Queued {
+		t.Fatalf("expected 1 message awaiting its report, got %+v", s.sent)
+	}
+	s.settle(time.Now().Add(time.Hour))
+	if s.sent[0].Status != string(Delivered) || s.sent[0].Reports[0].Status != string(Delivered) {
+		t.Errorf("expected the message delivered, got %+v", s.sent[0])
+	}
+	if credit := s.accounts["acme"].Account.SmsCredits; credit != 10-prices["IT"] {
+		t.Errorf("expected the message billed, got a credit of %g", credit)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	_, client := start(t, Config{Latency: 50 * time.Millisecond, Jitter: 10 * time.Millisecond})
+	begin := time.Now()
+	if _, err := client.AccountService.List(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the call delayed by at least 50ms, took %s", elapsed)
+	}
+
+	_, client = start(t, Config{Latency: time.Second}, rdcom.WithTimeout(20*time.Millisecond))
+	if _, err := client.AccountService.List(); err == nil {
+		t.Errorf("expected the call to time out")
+	}
+}
+
+func TestErrorInjection(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected int
+	}{
+		{"default status", Config{Credit: -1, ErrorRate: 1}, http.StatusServiceUnavailable},
+		{"configured status", Config{Credit: -1, ErrorRate: 1, ErrorStatuses: []int{http.StatusTooManyRequests}}, http.StatusTooManyRequests},
+		{"no errors", Config{Credit: -1}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, client := start(t, test.config)
+			_, err := client.MessageService.Send("acme", message(""))
+			if actual := status(err); actual != test.expected {
+				t.Errorf("expected status %d, got %d (error: %v)", test.expected, actual, err)
+			}
+			// injected errors fail the call before it is processed
+			s.acquire()
+			defer s.lock.Unlock()
+			if sent := len(s.sent); (test.expected == 0) != (sent == 1) {
+				t.Errorf("expected %t, got %d messages sent", test.expected == 0, sent)
+			}
+		})
+	}
+}
+
+func TestLostResponse(t *testing.T) {
+	s, client := start(t, Config{Credit: -1, LostRate: 1})
+	_, err := client.MessageService.Send("acme", message("ref-1"))
+	if status(err) != http.StatusGatewayTimeout {
+		t.Fatalf("expected a gateway timeout, got %v", err)
+	}
+	s.acquire()
+	if len(s.sent) != 1 {
+		t.Errorf("expected the message accepted anyway, got %d messages", len(s.sent))
+	}
+	s.config.LostRate = 0
+	s.lock.Unlock()
+
+	// retrying with the same reference is refused as a duplicate...
+	_, err = client.MessageService.Send("acme", message("ref-1"))
+	var conflict *rdcom.ConflictError
+	if !errors.As(err, &conflict) || conflict.Stale() {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+	// ... while a new one is accepted
+	if _, err := client.MessageService.Send("acme", message("ref-2")); err != nil {
+		t.Errorf("expected the message accepted, got %v", err)
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	if len(s.sent) != 2 {
+		t.Errorf("expected 2 messages sent, got %d", len(s.sent))
+	}
+}
+
+func TestCredit(t *testing.T) {
+	tests := []struct {
+		name     string
+		credit   float64
+		expected []int
+		left     float64
+	}{
+		{"enough for one", prices["IT"] * 1.5, []int{0, http.StatusPaymentRequired}, prices["IT"] * 0.5},
+		{"exhausted", 0, []int{http.StatusPaymentRequired}, 0},
+		{"unlimited", -1, []int{0, 0, 0}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, client := start(t, Config{Credit: test.credit})
+			for i, expected := range test.expected {
+				if _, err := client.MessageService.Send("acme", message("")); status(err) != expected {
+					t.Errorf("send %d: expected status %d, got %v", i+1, expected, err)
+				}
+			}
+			s.acquire()
+			defer s.lock.Unlock()
+			if credit := s.accounts["acme"].Account.SmsCredits; credit < test.left-1e-9 || credit > test.left+1e-9 {
+				t.Errorf("expected a credit of %g, got %g", test.left, credit)
+			}
+		})
+	}
+}
+
+// sendOTP sends an OTP with a plain HTTP request, since the client has no
+// service for OTPs and refuses raw calls that send messages.
+func sendOTP(t *testing.T, s *Simulator, body string) *OTP {
+	t.Helper()
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v2/acme/cds/otp/sms/", bytes.NewBufferString(body))
+	request.Header.Set("Authorization", "Bearer token")
+	s.ServeHTTP(response, request)
+	if response.Code != http.StatusCreated {
+		t.Fatalf("expected the OTP sent, got %d: %s", response.Code, response.Body)
+	}
+	otp := &OTP{}
+	if err := json.Unmarshal(response.Body.Bytes(), otp); err != nil {
+		t.Fatal(err)
+	}
+	if otp.Code != "" {
+		t.Errorf("expected the code not to be disclosed, got %s", otp.Code)
+	}
+	s.acquire()
+	defer s.lock.Unlock()
+	otp.Code = s.otps[otp.ID].Code
+	return otp
+}
+
+// validate validates an OTP code through the client and returns the HTTP
+// status.
+func validate(client *rdcom.Client, otp *OTP, code string) int {
+	response, err := rdcom.Raw(client, http.MethodPost, &rdcom.RawOptions{
+		Options: rdcom.Options{
+			EntityPath: "/api/v2/{account}/cds/otp/sms/{id}/validate/",
+			PathParams: map[string]string{"account": "acme", "id": otp.ID},
+		},
+		Body: map[string]string{"code": code},
+	})
+	if response == nil {
+		return status(err)
+	}
+	return response.StatusCode
+}
+
+func TestOTP(t *testing.T) {
+	s, client := start(t, Config{Credit: -1})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{
+			`{"recipient":"3331234567"}`,
+			`{"recipient":"+393331234567","length":3}`,
+			`{"recipient":"+393331234567","text":"no placeholder"}`,
+		} {
+			response := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/api/v2/acme/cds/otp/sms/", bytes.NewBufferString(body))
+			request.Header.Set("Authorization", "Bearer token")
+			s.ServeHTTP(response, request)
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected a bad request, got %d", body, response.Code)
+			}
+		}
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		otp := sendOTP(t, s, `{"recipient":"+393331234567","length":8}`)
+		if len(otp.Code) != 8 || otp.Status != OTPPending {
+			t.Fatalf("expected a pending OTP of 8 digits, got %+v", otp)
+		}
+		if actual := validate(client, otp, otp.Code); actual != http.StatusOK {
+			t.Errorf("expected the code accepted, got %d", actual)
+		}
+		if actual := validate(client, otp, otp.Code); actual != http.StatusConflict {
+			t.Errorf("expected the code accepted only once, got %d", actual)
+		}
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		otp := sendOTP(t, s, `{"recipient":"+393331234567"}`)
+		for range MaxOTPAttempts {
+			if actual := validate(client, otp, "wrong"); actual != http.StatusBadRequest {
+				t.Errorf("expected the code refused, got %d", actual)
+			}
+		}
+		if actual := validate(client, otp, otp.Code); actual != http.StatusConflict {
+			t.Errorf("expected the OTP blocked, got %d", actual)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		otp := sendOTP(t, s, `{"recipient":"+393331234567","validity":60}`)
+		s.acquire()
+		s.otps[otp.ID].Expires = time.Now().Add(-time.Second)
+		s.lock.Unlock()
+		if actual := validate(client, otp, otp.Code); actual != http.StatusGone {
+			t.Errorf("expected the OTP expired, got %d", actual)
+		}
+	})
+}
+
+func TestTokenExpiry(t *testing.T) {
+	s, client := start(t, Config{Tokens: []string{"token"}, Strict: true, TokenLifetime: time.Hour})
+	s.acquire()
+	expiry := s.tokens["token"].ExpiryDate
+	s.lock.Unlock()
+	if remaining := time.Until(expiry); remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Errorf("expected the token to expire in 1h, got %s", remaining)
+	}
+	if _, err := client.AccountService.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.acquire()
+	s.tokens["token"].ExpiryDate = time.Now().Add(-time.Second)
+	s.lock.Unlock()
+	if _, err := client.AccountService.List(); status(err) != http.StatusUnauthorized {
+		t.Errorf("expected the expired token refused, got %v", err)
+	}
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	s, client := start(t, Config{})
+	created, err := client.TokenService.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	other, err := rdcom.New(rdcom.WithBaseURL(server.URL), rdcom.WithAuthToken(created.Token), rdcom.WithoutSuppression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { other.Close() })
+	if _, err := other.AccountService.List(); err != nil {
+		t.Fatalf("expected the new token accepted, got %v", err)
+	}
+
+	// deleted tokens are refused, although unknown ones are registered on
+	// first use when the simulator is not strict
+	if _, err := client.TokenService.Delete(created.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.AccountService.List(); status(err) != http.StatusUnauthorized {
+		t.Errorf("expected the deleted token refused, got %v", err)
+	}
+}
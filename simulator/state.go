@@ -0,0 +1,287 @@
+package simulator
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dihedron/sms/rdcom"
+)
+
+// List of message states, besides the delivery outcomes.
+const (
+	// Queued messages have been accepted and await delivery reports.
+	Queued = "queued"
+	// PartiallyDelivered messages have different outcomes for different
+	// recipients.
+	PartiallyDelivered = "partially_delivered"
+)
+
+// List of OTP states.
+const (
+	OTPPending   = "pending"
+	OTPValidated = "validated"
+	OTPExpired   = "expired"
+	OTPRevoked   = "revoked"
+	OTPBlocked   = "blocked"
+)
+
+// MaxOTPAttempts is the number of wrong codes after which an OTP is
+// blocked.
+const MaxOTPAttempts = 3
+
+// Sent is a message accepted by the simulator, as listed by the inspection
+// endpoint; its JSON form is also the API response to sends, which the
+// client decodes as a message.
+type Sent struct {
+	ID string `json:"id"`
+	// Kind is the kind of message: "sms", "rcs" or "otp".
+	Kind       string   `json:"kind"`
+	Account    string   `json:"account"`
+	ClientRef  string   `json:"client_ref,omitempty"`
+	Gateway    int      `json:"gateway"`
+	Sender     string   `json:"sender,omitempty"`
+	Recipients []string `json:"recipients"`
+	// Text is the text sent by SMS (the fallback text for RCS messages).
+	Text      string `json:"text,omitempty"`
+	InReplyTo string `json:"in_reply_to,omitempty"`
+	// RCS is the RCS message as submitted, for RCS messages.
+	RCS      *rdcom.RCSMessage `json:"rcs,omitempty"`
+	Status   string            `json:"status"`
+	Segments int               `json:"segments"`
+	Cost     float64           `json:"cost"`
+	Created  time.Time         `json:"created"`
+	// Reports holds the delivery reports, one per recipient.
+	Reports []*Report `json:"reports"`
+}
+
+// Report is the delivery report of a message to a recipient.
+type Report struct {
+	Recipient string `json:"recipient"`
+	// Status is Queued until the report arrives, then the outcome.
+	Status  string    `json:"status"`
+	Reason  string    `json:"reason,omitempty"`
+	Updated time.Time `json:"updated"`
+	// outcome is the outcome the report will have once due.
+	outcome Outcome
+	due     time.Time
+}
+
+// OTP is a one-time password sent by SMS.
+type OTP struct {
+	ID        string `json:"id"`
+	Account   string `json:"account"`
+	Recipient string `json:"recipient"`
+	Gateway   int    `json:"gateway"`
+	// Code is only shown by the inspection endpoint, never by the API.
+	Code     string    `json:"code,omitempty"`
+	Status   string    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Message  string    `json:"message"`
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`
+}
+
+// account is the state of a simulated account.
+type account struct {
+	Account   rdcom.Account          `json:"account"`
+	Gateways  []rdcom.SMSGateway     `json:"gateways"`
+	Senders   []*rdcom.Sender        `json:"senders"`
+	Blacklist []rdcom.BlacklistEntry `json:"blacklist"`
+	Inbound   []rdcom.InboundMessage `json:"inbound"`
+	// refs maps the client references of messages to their IDs.
+	refs map[string]string
+}
+
+// prices are the segment prices of the standard gateway, by ISO 3166-1
+// alpha-2 code; the two-way gateway charges 50% more.
+var prices = map[string]float64{
+	"IT": 0.045,
+	"SM": 0.06,
+	"VA": 0.06,
+	"CH": 0.07,
+	"FR": 0.065,
+	"DE": 0.075,
+	"AT": 0.07,
+	"ES": 0.06,
+	"GB": 0.04,
+	"IE": 0.06,
+	"NL": 0.08,
+	"BE": 0.075,
+	"US": 0.01,
+	"CA": 0.01,
+}
+
+// newAccount creates a simulated account with a standard and a two-way
+// SMS gateway.
+func newAccount(code string, credit float64) *account {
+	now := time.Now().UTC().Truncate(time.Second)
+	a := &account{
+		Account: rdcom.Account{
+			Name:           "Simulated account " + code,
+			Code:           code,
+			Enabled:        true,
+			Created:        now,
+			ExpirationDate: now.AddDate(1, 0, 0),
+			EnableOtpSms:   true,
+			SmsCredits:     credit,
+		},
+		Gateways: []rdcom.SMSGateway{
+			{
+				ID:             1,
+				Label:          map[string]string{"en": "Standard", "it": "Standard"},
+				IsDefault:      true,
+				SenderReady:    true,
+				EnableSmsDlr:   true,
+				GatewayType:    "standard",
+				GatewayTypeRaw: 1,
+				Prices:         map[string]float64{},
+			},
+			{
+				ID:             2,
+				Label:          map[string]string{"en": "Two-way", "it": "Bidirezionale"},
+				SenderReady:    true,
+				TwowayReady:    true,
+				MoReady:        true,
+				RcsReady:       true,
+				EnableSmsDlr:   true,
+				GatewayType:    "pro",
+				GatewayTypeRaw: 2,
+				Prices:         map[string]float64{},
+			},
+		},
+		Senders:   []*rdcom.Sender{},
+		Blacklist: []rdcom.BlacklistEntry{},
+		Inbound:   []rdcom.InboundMessage{},
+		refs:      map[string]string{},
+	}
+	if credit < 0 {
+		a.Account.SmsCredits = 0
+		a.Account.EnableSmsUnlimitedCredit = true
+	}
+	a.Account.Infos.Name = a.Account.Name
+	a.Account.Infos.Company = "Simulator"
+	a.Account.Infos.Country = "IT"
+	a.Account.Limits.MaxRecipientsPerDay = 10000
+	a.Account.Limits.MaxRecipientsPerMonth = 100000
+	a.Account.Limits.MaxRecipientsPerYear = 1000000
+	a.Account.Limits.MaxLists = 100
+	for country, price := range prices {
+		a.Gateways[0].Prices[country] = price
+		a.Gateways[1].Prices[country] = price * 1.5
+	}
+	return a
+}
+
+// gateway returns the SMS gateway with the given ID, or nil.
+func (a *account) gateway(id int) *rdcom.SMSGateway {
+	for i := range a.Gateways {
+		if a.Gateways[i].ID == id {
+			return &a.Gateways[i]
+		}
+	}
+	return nil
+}
+
+// defaultGateway returns the default SMS gateway.
+func (a *account) defaultGateway() *rdcom.SMSGateway {
+	for i := range a.Gateways {
+		if a.Gateways[i].IsDefault {
+			return &a.Gateways[i]
+		}
+	}
+	return &a.Gateways[0]
+}
+
+// sender returns the sender registration with the given ID, or nil.
+func (a *account) sender(id int) *rdcom.Sender {
+	for _, sender := range a.Senders {
+		if sender.ID == id {
+			return sender
+		}
+	}
+	return nil
+}
+
+// approved reports whether the alphanumeric sender is approved for use on
+// the given gateway.
+func (a *account) approved(name string, gateway int) bool {
+	for _, sender := range a.Senders {
+		if sender.Sender == name && sender.Status == rdcom.SenderApproved && slices.Contains(sender.Gateways, gateway) {
+			return true
+		}
+	}
+	return false
+}
+
+// blacklisted reports whether the number is in the platform blacklist.
+func (a *account) blacklisted(number string) bool {
+	for _, entry := range a.Blacklist {
+		if entry.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+// settle brings the state up to date with the passing of time: delivery
+// reports that are due arrive, sender registrations under review are
+// approved and OTPs expire. The lock must be held.
+func (s *Simulator) settle(now time.Time) {
+	for _, sent := range s.sent {
+		if sent.Status != Queued {
+			continue
+		}
+		pending := 0
+		for _, report := range sent.Reports {
+			if report.Status != Queued {
+				continue
+			}
+			if now.Before(report.due) {
+				pending++
+				continue
+			}
+			report.Status = string(report.outcome)
+			report.Updated = report.due
+		}
+		if pending == 0 {
+			sent.Status = aggregate(sent.Reports)
+			slog.Debug("delivery reports received", "id", sent.ID, "status", sent.Status)
+		}
+	}
+	if s.config.SenderReview > 0 {
+		for _, a := range s.accounts {
+			for _, sender := range a.Senders {
+				if sender.Status == rdcom.SenderPending && !now.Before(sender.Created.Add(s.config.SenderReview)) {
+					sender.Status = rdcom.SenderApproved
+					sender.Notes = "approved by the simulator"
+					sender.Updated = sender.Created.Add(s.config.SenderReview)
+					slog.Debug("sender approved", "account", a.Account.Code, "sender", sender.Sender)
+				}
+			}
+		}
+	}
+	for _, otp := range s.otps {
+		if otp.Status == OTPPending && !now.Before(otp.Expires) {
+			otp.Status = OTPExpired
+		}
+	}
+}
+
+// aggregate returns the status of a message whose delivery reports have
+// all arrived.
+func aggregate(reports []*Report) string {
+	status := ""
+	for _, report := range reports {
+		switch {
+		case status == "":
+			status = report.Status
+		case status != report.Status:
+			return PartiallyDelivered
+		}
+	}
+	if status == "" {
+		return string(Delivered)
+	}
+	return status
+}
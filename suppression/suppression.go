@@ -0,0 +1,241 @@
+// Package suppression implements a local, file-backed list of phone
+// numbers that must never be messaged, e.g. because they opted out or
+// because they are flagged as do-not-contact.
+package suppression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is a suppressed number.
+type Entry struct {
+	// Number is the suppressed number, in E.164 format.
+	Number string `json:"number" yaml:"number" pii:"phone"`
+	// Reason is why the number is suppressed.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Source is where the suppression comes from (e.g. "stop", "crm",
+	// "platform", "manual").
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Added is the time the number was added to the list.
+	Added time.Time `json:"added" yaml:"added"`
+}
+
+// List is a suppression list persisted as a JSON file; changes are saved
+// immediately, and changes made to the file by other processes are picked
+// up on access. It is safe for concurrent use.
+type List struct {
+	path     string
+	lock     sync.Mutex
+	modified time.Time
+	entries  map[string]Entry
+}
+
+// Open loads the suppression list from the given file; a missing file
+// yields an empty list, which is created on the first change.
+func Open(path string) (*List, error) {
+	l := &List{
+		path:    filepath.Clean(path),
+		entries: map[string]Entry{},
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	slog.Debug("suppression list loaded", "path", l.path, "count", len(l.entries))
+	return l, nil
+}
+
+// Add adds the entries to the list, returning the number of entries that
+// were not already in it; entries already in the list are left unchanged.
+func (l *List) Add(entries ...Entry) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.refresh(); err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, entry := range entries {
+		if _, ok := l.entries[entry.Number]; ok {
+			continue
+		}
+		if entry.Added.IsZero() {
+			entry.Added = time.Now()
+		}
+		l.entries[entry.Number] = entry
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, l.save()
+}
+
+// Remove removes the numbers from the list, returning the number of
+// entries actually removed.
+func (l *List) Remove(numbers ...string) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.refresh(); err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, number := range numbers {
+		if _, ok := l.entries[number]; ok {
+			delete(l.entries, number)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, l.save()
+}
+
+// Get returns the entry for the given number, if suppressed.
+func (l *List) Get(number string) (Entry, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.refresh(); err != nil {
+		// fail closed: better not to send than to message an opted-out number
+		slog.Error("error refreshing suppression list, assuming number is suppressed", "number", number, "error", err)
+		return Entry{Number: number, Reason: "suppression list unavailable"}, true
+	}
+	entry, ok := l.entries[number]
+	return entry, ok
+}
+
+// Suppressed reports whether the number must not be messaged.
+func (l *List) Suppressed(number string) bool {
+	_, ok := l.Get(number)
+	return ok
+}
+
+// Entries returns the entries in the list, sorted by number.
+func (l *List) Entries() []Entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.refresh(); err != nil {
+		slog.Error("error refreshing suppression list", "error", err)
+	}
+	entries := make([]Entry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Number < entries[j].Number
+	})
+	return entries
+}
+
+// Filter splits the recipients into those that can be messaged and those
+// that are suppressed.
+func (l *List) Filter(recipients []string) (allowed []string, suppressed []string) {
+	for _, recipient := range recipients {
+		if l.Suppressed(recipient) {
+			suppressed = append(suppressed, recipient)
+		} else {
+			allowed = append(allowed, recipient)
+		}
+	}
+	return allowed, suppressed
+}
+
+// OptOut adds the number to the list as a STOP request; it lets the list
+// record opt-outs from the rules engine.
+func (l *List) OptOut(number string, reason string) error {
+	added, err := l.Add(Entry{Number: number, Reason: reason, Source: "stop"})
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		slog.Info("number opted out", "number", number, "reason", reason)
+	}
+	return nil
+}
+
+// refresh reloads the list if the file was modified by someone else.
+func (l *List) refresh() error {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if !l.modified.IsZero() {
+			l.entries = map[string]Entry{}
+			l.modified = time.Time{}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(l.modified) {
+		return nil
+	}
+	return l.load()
+}
+
+// load reads the list from file.
+func (l *List) load() error {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("error accessing suppression list", "path", l.path, "error", err)
+		return err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		slog.Error("error reading suppression list", "path", l.path, "error", err)
+		return err
+	}
+	entries := []Entry{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			slog.Error("error parsing suppression list", "path", l.path, "error", err)
+			return fmt.Errorf("invalid suppression list %s: %w", l.path, err)
+		}
+	}
+	l.entries = make(map[string]Entry, len(entries))
+	for _, entry := range entries {
+		l.entries[entry.Number] = entry
+	}
+	l.modified = info.ModTime()
+	return nil
+}
+
+// save writes the list to file atomically.
+func (l *List) save() error {
+	entries := make([]Entry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Number < entries[j].Number
+	})
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := l.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		slog.Error("error writing suppression list", "path", temp, "error", err)
+		return err
+	}
+	if err := os.Rename(temp, l.path); err != nil {
+		slog.Error("error replacing suppression list", "path", l.path, "error", err)
+		return err
+	}
+	if info, err := os.Stat(l.path); err == nil {
+		l.modified = info.ModTime()
+	}
+	slog.Debug("suppression list saved", "path", l.path, "count", len(entries))
+	return nil
+}